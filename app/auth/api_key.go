@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the request header integrations send their API key in, instead of a JWT
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyScopesContextKey is the Gin context key under which the scopes of the API key used
+// for the request are stored. Requests authenticated with a JWT do not have it.
+const APIKeyScopesContextKey = "api_key_scopes"
+
+// apiKeyPrefix marks API keys so they are recognisable in configuration and secret scanners
+const apiKeyPrefix = "ik_"
+
+// GenerateAPIKey creates a random API key and the prefix it is listed under. Only the hash of
+// the key is stored, the key itself is handed to the client once.
+func GenerateAPIKey() (key string, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
+
+// APIKeyScopes returns the permissions an API key can be granted: every permission except user
+// management, so a key can never create other keys or accounts
+func APIKeyScopes() []string {
+	scopes := []string{}
+	for _, permission := range allPermissions {
+		if permission != PermissionUsersManage {
+			scopes = append(scopes, permission)
+		}
+	}
+	return scopes
+}
+
+// IsValidAPIKeyScope reports whether scope can be granted to an API key
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAPIKeyInContext stores the API key used for the request in the Gin context. The key acts on
+// behalf of the user who created it, so handlers recording a user keep working.
+func SetAPIKeyInContext(c *gin.Context, keyID, name, createdBy string, scopes []string) {
+	c.Set(APIKeyScopesContextKey, scopes)
+	c.Set("api_key_id", keyID)
+	c.Set("user_id", createdBy)
+	c.Set("username", "api-key:"+name)
+	c.Set("role", "")
+}
+
+// ContextHasPermission reports whether the request may use permission: the scopes of its API key,
+// or the role of its JWT
+func ContextHasPermission(c *gin.Context, permission string) bool {
+	if value, ok := c.Get(APIKeyScopesContextKey); ok {
+		scopes, _ := value.([]string)
+		for _, scope := range scopes {
+			if scope == permission {
+				return true
+			}
+		}
+		return false
+	}
+	return HasPermission(c.GetString("role"), permission)
+}
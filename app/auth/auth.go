@@ -1,10 +1,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,34 +14,49 @@ import (
 )
 
 var (
-	// Using environment variable for JWT secret is more secure
-	// Default to a fallback value if not set
-	jwtSecret = []byte(getEnvOrDefault("JWT_SECRET", "changethislateronproductionwithenv"))
-	
-	// Token expiration time
-	tokenExpiration = getEnvOrDefaultDuration("JWT_EXPIRATION", 24*time.Hour)    
-	
+	// Signing and verification keys, set from the loaded configuration by Configure
+	signingKey       jwtKey
+	verificationKeys = map[string][]byte{}
+
+	// Access tokens are short-lived, sessions are kept alive with rotating refresh tokens
+	tokenExpiration        = 15 * time.Minute
+	refreshTokenExpiration = 30 * 24 * time.Hour
+
 	// Common errors
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserExists         = errors.New("user already exists")
 	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrUserInactive       = errors.New("user is deactivated")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+	ErrNotConfigured      = errors.New("auth is not configured")
+
+	// revocationCheck reports whether an access token ID has been revoked, set once the database is ready
+	revocationCheck func(jti string) (bool, error)
 )
 
+// ClaimsContextKey is the Gin context key holding the *Claims of the authenticated request
+const ClaimsContextKey = "claims"
+
 // User represents authentication user model
 type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"-"` // Never expose password in JSON
-	Role     string `json:"role"`
+	ID              string     `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Password        string     `json:"-"` // Never expose password in JSON
+	Role            string     `json:"role"`
+	Active          bool       `json:"active"`
+	TwoFactor       bool       `json:"two_factor_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"` // Null until the user follows the verification link
 }
 
 // Claims defines the JWT claims structure
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TwoFactor bool   `json:"tfa,omitempty"` // The session was verified with a second factor
 	jwt.RegisteredClaims
 }
 
@@ -60,9 +75,11 @@ type RegisterRequest struct {
 
 // TokenResponse is returned on successful authentication
 type TokenResponse struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	User      User      `json:"user"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
 }
 
 // HashPassword creates a bcrypt hash from password
@@ -72,21 +89,32 @@ func HashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
+// GenerateTemporaryPassword creates a random password for invited users and admin resets
+func GenerateTemporaryPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 // VerifyPassword checks if provided password matches stored hash
 func VerifyPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(user User) (string, time.Time, error) {
+// GenerateToken creates a new access token for a user and returns it with its claims,
+// whose ID is the jti used to revoke the token. twoFactor marks sessions verified with a second factor.
+func GenerateToken(user User, twoFactor bool) (string, *Claims, error) {
 	expirationTime := time.Now().Add(tokenExpiration)
 	
 	claims := Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Role:     user.Role,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		TwoFactor: twoFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -97,30 +125,48 @@ func GenerateToken(user User) (string, time.Time, error) {
 		},
 	}
 
+	if len(signingKey.Secret) == 0 {
+		return "", nil, ErrNotConfigured
+	}
+
+	// The kid header tells ValidateToken which key to verify with once keys are rotated
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	token.Header["kid"] = signingKey.ID
+	tokenString, err := token.SignedString(signingKey.Secret)
 	
-	return tokenString, expirationTime, err
+	return tokenString, &claims, err
 }
 
 // ValidateToken validates and parses the JWT token
 func ValidateToken(tokenString string) (*Claims, error) {
+	// Tokens are only ever signed with HS256, any other algorithm is rejected before the signature is checked
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return jwtSecret, nil
-	})
+		return verificationKey(token)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if revocationCheck != nil {
+		revoked, err := revocationCheck(claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
 	}
 
-	return nil, ErrInvalidToken
+	return claims, nil
 }
 
 // ExtractTokenFromGinContext extracts the JWT token from a Gin context
@@ -158,48 +204,22 @@ func GetUserFromContext(c *gin.Context) (*User, error) {
 	return user, nil
 }
 
-// AuthMiddleware creates a Gin middleware for JWT authentication
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := ExtractTokenFromGinContext(c)
-		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "no token provided",
-			})
-			return
-		}
-
-		claims, err := ValidateToken(token)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid token",
-			})
-			return
-		}
-
-		// Set claims in context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
-
-		c.Next()
-	}
-}
-
-// Helper functions remain unchanged
-func getEnvOrDefault(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+// SetClaimsInContext stores the claims of a validated token in the Gin context,
+// both whole under ClaimsContextKey and field by field for GetUserFromContext
+func SetClaimsInContext(c *gin.Context, claims *Claims) {
+	c.Set(ClaimsContextKey, claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
 }
 
-func getEnvOrDefaultDuration(key string, defaultValue time.Duration) time.Duration {
-	if value, exists := os.LookupEnv(key); exists {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
+// GetClaimsFromContext returns the claims stored by SetClaimsInContext
+func GetClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(ClaimsContextKey)
+	if !exists {
+		return nil, false
 	}
-	return defaultValue
+	claims, ok := value.(*Claims)
+	return claims, ok
 }
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// defaultKeyID is the kid of the key built from jwt-secret when no jwt-keys are configured
+const defaultKeyID = "default"
+
+// minSecretLength is the shortest secret accepted for HS256, which uses a 256-bit key
+const minSecretLength = 32
+
+// knownSecrets are secrets that have been published and must never sign tokens
+var knownSecrets = map[string]bool{
+	"changethislateronproductionwithenv":       true,
+	"inisecretyangsupersecretsemogatidakjebol": true,
+}
+
+// jwtKey is an HMAC secret identified by the kid header of the tokens it signs
+type jwtKey struct {
+	ID     string
+	Secret []byte
+}
+
+// Configure sets up token signing, expiry and the roles that need two-factor authentication from the loaded configuration. It fails when no
+// secret is configured or a secret is weak, so the server never starts with a guessable key.
+func Configure(config *settings.Config) error {
+	keys := map[string][]byte{}
+	signingKeyID := config.JWTSigningKey
+
+	if len(config.JWTKeys) == 0 {
+		if err := checkSecret("jwt-secret", config.JWTSecret); err != nil {
+			return err
+		}
+		keys[defaultKeyID] = []byte(config.JWTSecret)
+		signingKeyID = defaultKeyID
+	} else {
+		for i, key := range config.JWTKeys {
+			id := strings.TrimSpace(key.ID)
+			if id == "" {
+				return fmt.Errorf("jwt-keys[%d]: id is required", i)
+			}
+			if _, exists := keys[id]; exists {
+				return fmt.Errorf("jwt-keys[%d]: duplicate id %q", i, id)
+			}
+			if err := checkSecret(fmt.Sprintf("jwt-keys[%d] (%s)", i, id), key.Secret); err != nil {
+				return err
+			}
+			keys[id] = []byte(key.Secret)
+		}
+		if signingKeyID == "" && len(config.JWTKeys) == 1 {
+			signingKeyID = strings.TrimSpace(config.JWTKeys[0].ID)
+		}
+		if _, ok := keys[signingKeyID]; !ok {
+			return fmt.Errorf("jwt-signing-key %q does not match any of jwt-keys", signingKeyID)
+		}
+	}
+
+	access, err := parseExpiration("jwt-expiration", config.JWTExpiration, 15*time.Minute)
+	if err != nil {
+		return err
+	}
+	refresh, err := parseExpiration("jwt-refresh-expiration", config.JWTRefreshExpiration, 30*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	roles := map[string]bool{}
+	for _, role := range config.Auth.TwoFactorRoles {
+		if !IsValidRole(role) {
+			return fmt.Errorf("auth two-factor-roles: unknown role %q", role)
+		}
+		roles[role] = true
+	}
+
+	signingKey = jwtKey{ID: signingKeyID, Secret: keys[signingKeyID]}
+	verificationKeys = keys
+	tokenExpiration = access
+	refreshTokenExpiration = refresh
+	twoFactorRoles = roles
+	return nil
+}
+
+// checkSecret rejects missing, short, low-variety and published secrets
+func checkSecret(name, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("%s is not set", name)
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("%s must be at least %d characters", name, minSecretLength)
+	}
+	if knownSecrets[secret] {
+		return fmt.Errorf("%s is a published default, generate a new secret", name)
+	}
+
+	distinct := map[rune]bool{}
+	for _, r := range secret {
+		distinct[r] = true
+	}
+	if len(distinct) < 10 {
+		return fmt.Errorf("%s has too few distinct characters", name)
+	}
+	return nil
+}
+
+// parseExpiration parses a token lifetime, falling back to def when it is not set
+func parseExpiration(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return d, nil
+}
+
+// verificationKey returns the secret for the kid of a token. Tokens without a kid predate
+// key rotation and are checked against the current signing key.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if len(verificationKeys) == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return signingKey.Secret, nil
+	}
+	secret, ok := verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return secret, nil
+}
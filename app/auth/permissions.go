@@ -0,0 +1,110 @@
+package auth
+
+// Roles that can be assigned to users. Accounts created before roles were enforced keep
+// RoleUser, which grants no admin permissions until an admin assigns a role.
+const (
+	RoleAdmin         = "admin"          // Everything, including user and role management
+	RoleCatalogEditor = "catalog_editor" // Products and master data
+	RoleWarehouse     = "warehouse"      // Stock, receiving, transfers and stock counts
+	RoleMarketing     = "marketing"      // Read-only catalog, manages banners, newsletters and promotions
+	RoleApprover      = "approver"       // Reviews products and publishes, unpublishes and archives them
+	RoleUser          = "user"
+)
+
+// Permissions required by the admin routes. Read permissions cover GET requests,
+// write permissions everything else on the same resource.
+const (
+	PermissionProductsRead     = "products:read"
+	PermissionProductsWrite    = "products:write"
+	PermissionProductsDelete   = "products:delete"
+	PermissionProductsPublish  = "products:publish"
+	PermissionMasterDataRead   = "master-data:read"
+	PermissionMasterDataWrite  = "master-data:write"
+	PermissionStockRead        = "stock:read"
+	PermissionStockWrite       = "stock:write"
+	PermissionPurchasingRead   = "purchasing:read"
+	PermissionPurchasingWrite  = "purchasing:write"
+	PermissionReportsRead      = "reports:read"
+	PermissionBannersRead      = "banners:read"
+	PermissionBannersWrite     = "banners:write"
+	PermissionNewslettersRead  = "newsletters:read"
+	PermissionNewslettersWrite = "newsletters:write"
+	PermissionPromotionsRead   = "promotions:read"
+	PermissionPromotionsWrite  = "promotions:write"
+	PermissionUsersManage      = "users:manage"
+	PermissionAuditLogsRead    = "audit-logs:read"
+)
+
+// allPermissions lists every permission, all of which the admin role holds
+var allPermissions = []string{
+	PermissionProductsRead, PermissionProductsWrite, PermissionProductsDelete, PermissionProductsPublish,
+	PermissionMasterDataRead, PermissionMasterDataWrite,
+	PermissionStockRead, PermissionStockWrite,
+	PermissionPurchasingRead, PermissionPurchasingWrite,
+	PermissionReportsRead,
+	PermissionBannersRead, PermissionBannersWrite,
+	PermissionNewslettersRead, PermissionNewslettersWrite,
+	PermissionPromotionsRead, PermissionPromotionsWrite,
+	PermissionUsersManage, PermissionAuditLogsRead,
+}
+
+// rolePermissions lists the permissions of every role except admin, which has them all
+var rolePermissions = map[string][]string{
+	RoleCatalogEditor: {
+		PermissionProductsRead, PermissionProductsWrite, PermissionProductsDelete,
+		PermissionMasterDataRead, PermissionMasterDataWrite,
+		PermissionStockRead, PermissionReportsRead,
+		PermissionPromotionsRead,
+	},
+	RoleWarehouse: {
+		PermissionProductsRead, PermissionMasterDataRead,
+		PermissionStockRead, PermissionStockWrite,
+		PermissionPurchasingRead, PermissionPurchasingWrite,
+		PermissionReportsRead,
+	},
+	RoleMarketing: {
+		PermissionProductsRead, PermissionMasterDataRead,
+		PermissionBannersRead, PermissionBannersWrite,
+		PermissionNewslettersRead, PermissionNewslettersWrite,
+		PermissionPromotionsRead, PermissionPromotionsWrite,
+	},
+	RoleApprover: {
+		PermissionProductsRead, PermissionProductsPublish, PermissionMasterDataRead,
+	},
+	RoleUser: {},
+}
+
+// Roles returns every assignable role in display order
+func Roles() []string {
+	return []string{RoleAdmin, RoleCatalogEditor, RoleWarehouse, RoleMarketing, RoleApprover, RoleUser}
+}
+
+// IsValidRole reports whether role can be assigned to a user
+func IsValidRole(role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the permissions granted to role
+func RolePermissions(role string) []string {
+	if role == RoleAdmin {
+		return append([]string{}, allPermissions...)
+	}
+	return append([]string{}, rolePermissions[role]...)
+}
+
+// HasPermission reports whether role grants permission
+func HasPermission(role, permission string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
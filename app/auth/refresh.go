@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// SetRevocationCheck installs the lookup ValidateToken uses to reject revoked token IDs
+func SetRevocationCheck(check func(jti string) (bool, error)) {
+	revocationCheck = check
+}
+
+// GenerateRefreshToken creates a random refresh token and its expiry. Only the hash of the
+// token is stored, the token itself is handed to the client once.
+func GenerateRefreshToken() (string, time.Time, error) {
+	token, err := GenerateRandomToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Now().Add(refreshTokenExpiration), nil
+}
+
+// GenerateRandomToken creates a random 256-bit URL-safe token, for links sent by email and refresh tokens
+func GenerateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest under which a random token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
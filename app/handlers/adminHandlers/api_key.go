@@ -0,0 +1,113 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllAPIKeys handles listing API keys, newest first. Revoked keys are included with include_revoked=true.
+func GetAllAPIKeys(c *gin.Context) {
+	includeRevoked := c.DefaultQuery("include_revoked", "false") == "true"
+
+	keys, err := db.FetchAPIKeys(includeRevoked)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch API keys", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"api_keys": keys,
+		"scopes":   auth.APIKeyScopes(),
+	})
+}
+
+// CreateAPIKey handles creating an API key with the requested scopes. The key is returned once
+// in the response and cannot be retrieved afterwards.
+func CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errorField := "name"
+		handlers.SendError(c, http.StatusBadRequest, "Name is required", &errorField)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		errorField := "scopes"
+		handlers.SendError(c, http.StatusBadRequest, "At least one scope is required", &errorField)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidAPIKeyScope(scope) {
+			errorField := "scopes"
+			handlers.SendError(c, http.StatusBadRequest, "Invalid scope "+scope+", use any of: "+strings.Join(auth.APIKeyScopes(), ", "), &errorField)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errorField := "expires_at"
+		handlers.SendError(c, http.StatusBadRequest, "Expiry must be in the future", &errorField)
+		return
+	}
+
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to generate API key", nil)
+		return
+	}
+
+	userID, _ := helpers.CurrentUser(c)
+	apiKey, err := db.InsertAPIKey(models.APIKey{
+		Name:      req.Name,
+		KeyPrefix: prefix,
+		KeyHash:   auth.HashToken(key),
+		Scopes:    req.Scopes,
+		CreatedBy: userID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create API key", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityAPIKey, apiKey.ID, models.AuditActionCreate, nil, apiKey)
+
+	handlers.SendSuccess(c, http.StatusCreated, models.CreatedAPIKey{APIKey: apiKey, Key: key})
+}
+
+// RevokeAPIKey handles revoking an API key, which is rejected from the next request on
+func RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	apiKey, err := db.RevokeAPIKey(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "API key not found", nil)
+			return
+		}
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke API key", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityAPIKey, id, models.AuditActionRevoke, nil, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, apiKey)
+}
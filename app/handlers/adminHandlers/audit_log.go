@@ -0,0 +1,56 @@
+package adminHandlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllAuditLogs handles listing changes made through the admin API, newest first. q searches the
+// username, entity ID and changed fields; filters narrow by user, entity, action, changed field and date.
+func GetAllAuditLogs(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	queryStr := c.DefaultQuery("q", "")
+	filters := helpers.ExtractAuditLogFilters(c)
+
+	totalCount, err := db.CountAuditLogs(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count audit logs", nil)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	entries, err := db.FetchAuditLogs(limit, offset, queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch audit logs", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      entries,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+	})
+}
+
+// recordAudit records a change with helpers.RecordAudit. The change is already saved by then, so a
+// failure to record is logged rather than failing the request, which clients would retry.
+func recordAudit(c *gin.Context, entityType string, entityID interface{}, action string, before, after interface{}) {
+	if err := helpers.RecordAudit(c, entityType, entityID, action, before, after); err != nil {
+		log.Printf("Error %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/goods_receipt"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllGoodsReceipts handles retrieving goods receipts with pagination, search and filtering
+func GetAllGoodsReceipts(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractGoodsReceiptFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountGoodsReceipts(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count goods receipts", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated receipts with filters applied
+	receipts, err := db.FetchGoodsReceipts(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch goods receipts", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      receipts,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetGoodsReceiptByID handles retrieving a single goods receipt with its items
+func GetGoodsReceiptByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	receipt, err := db.FetchGoodsReceiptByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Goods receipt not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch goods receipt", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, receipt)
+}
+
+// CreateGoodsReceipt handles creating a draft goods receipt
+func CreateGoodsReceipt(c *gin.Context) {
+	var req models.SaveGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := goods_receipt.ValidateSaveReceipt(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	receipt, err := db.InsertGoodsReceipt(&req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create goods receipt: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGoodsReceipt, receipt.ID, models.AuditActionCreate, nil, receipt)
+
+	handlers.SendSuccess(c, http.StatusCreated, receipt)
+}
+
+// UpdateGoodsReceipt handles editing a draft goods receipt
+func UpdateGoodsReceipt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	var req models.SaveGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := goods_receipt.ValidateSaveReceipt(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existing, err := db.FetchGoodsReceiptByID(id)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to fetch goods receipt")
+		return
+	}
+
+	receipt, err := db.UpdateGoodsReceipt(id, &req)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to update goods receipt")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGoodsReceipt, id, models.AuditActionUpdate, existing, receipt)
+
+	handlers.SendSuccess(c, http.StatusOK, receipt)
+}
+
+// PostGoodsReceipt handles adding the items of a draft receipt to stock
+func PostGoodsReceipt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchGoodsReceiptByID(id)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to fetch goods receipt")
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	receipt, err := db.PostGoodsReceipt(id, userID, username)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to post goods receipt")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGoodsReceipt, id, models.AuditActionUpdate, existing, receipt)
+
+	handlers.SendSuccess(c, http.StatusOK, receipt)
+}
+
+// CancelGoodsReceipt handles cancelling a draft goods receipt
+func CancelGoodsReceipt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchGoodsReceiptByID(id)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to fetch goods receipt")
+		return
+	}
+
+	receipt, err := db.CancelGoodsReceipt(id)
+	if err != nil {
+		sendGoodsReceiptError(c, err, "Failed to cancel goods receipt")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGoodsReceipt, id, models.AuditActionUpdate, existing, receipt)
+
+	handlers.SendSuccess(c, http.StatusOK, receipt)
+}
+
+// GetProductBatches handles listing the receipt batches of a product with the quantity still on hand
+func GetProductBatches(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	product, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	batches, err := db.FetchProductBatches(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product batches", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"product_no":     id,
+		"tanggal_terima": product.TanggalTerima,
+		"usia":           product.Usia,
+		"batches":        batches,
+	})
+}
+
+// sendGoodsReceiptError maps goods receipt errors to the matching HTTP response
+func sendGoodsReceiptError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "not_found":
+		handlers.SendError(c, http.StatusNotFound, "Goods receipt not found", nil)
+	case err.Error() == "invalid_status":
+		handlers.SendError(c, http.StatusConflict, "Goods receipt is not in a status that allows this action", nil)
+	case err.Error() == "no_items":
+		handlers.SendError(c, http.StatusConflict, "Goods receipt has no items", nil)
+	case errors.Is(err, db.ErrInsufficientStock):
+		errorField := "items"
+		handlers.SendError(c, http.StatusConflict, "Insufficient stock: "+err.Error(), &errorField)
+	case errors.Is(err, db.ErrPurchaseOrderLineUnavailable):
+		errorField := "items.purchase_order_item_id"
+		handlers.SendError(c, http.StatusConflict, "Purchase order line cannot be received: "+err.Error(), &errorField)
+	default:
+		handlers.SendError(c, http.StatusInternalServerError, message+": "+err.Error(), nil)
+	}
+}
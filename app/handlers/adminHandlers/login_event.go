@@ -0,0 +1,46 @@
+package adminHandlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllLoginEvents handles listing login attempts, newest first, filterable by user, IP, result and date
+func GetAllLoginEvents(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	queryStr := c.DefaultQuery("q", "")
+	filters := helpers.ExtractLoginEventFilters(c)
+
+	totalCount, err := db.CountLoginEvents(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count login events", nil)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	events, err := db.FetchLoginEvents(limit, offset, queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch login events", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      events,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+	})
+}
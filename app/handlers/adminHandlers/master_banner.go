@@ -155,6 +155,8 @@ func CreateBanner(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityBanner, banner.ID, models.AuditActionCreate, nil, banner)
+
 	handlers.SendSuccess(c, http.StatusCreated, banner)
 }
 
@@ -212,6 +214,8 @@ func UpdateBanner(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityBanner, id, models.AuditActionUpdate, existingBanner, updatedBanner)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedBanner)
 }
 
@@ -224,6 +228,17 @@ func DeleteBanner(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingBanner, err := db.FetchBannerByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Banner not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing banner", nil)
+		}
+		return
+	}
+
 	err = db.DeleteBanner(id)
 	if err != nil {
 		if err.Error() == "not_found" {
@@ -234,6 +249,8 @@ func DeleteBanner(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityBanner, id, models.AuditActionDelete, existingBanner, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Banner deleted successfully"})
 }
 
@@ -304,5 +321,13 @@ func RestoreBanner(c *gin.Context) {
 		return
 	}
 
+	restoredBanner, err := db.FetchBannerByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored banner", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityBanner, id, models.AuditActionRestore, nil, restoredBanner)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Banner restored successfully"})
 }
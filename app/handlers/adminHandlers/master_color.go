@@ -106,6 +106,8 @@ func CreateColor(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityColor, color.ID, models.AuditActionCreate, nil, color)
+
 	handlers.SendSuccess(c, http.StatusCreated, color)
 }
 
@@ -158,6 +160,8 @@ func UpdateColor(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityColor, id, models.AuditActionUpdate, existingColor, updatedColor)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedColor)
 }
 
@@ -170,6 +174,17 @@ func DeleteColor(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingColor, err := db.FetchColorByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Color not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing color", nil)
+		}
+		return
+	}
+
 	err = db.DeleteColor(id)
 	if err != nil {
 		if err.Error() == "not_found" {
@@ -180,6 +195,8 @@ func DeleteColor(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityColor, id, models.AuditActionDelete, existingColor, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Color deleted successfully"})
 }
 
@@ -250,5 +267,13 @@ func RestoreColor(c *gin.Context) {
 		return
 	}
 
+	restoredColor, err := db.FetchColorByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored color", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityColor, id, models.AuditActionRestore, nil, restoredColor)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Color restored successfully"})
 }
@@ -106,6 +106,8 @@ func CreateGender(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGender, gender.ID, models.AuditActionCreate, nil, gender)
+
 	handlers.SendSuccess(c, http.StatusCreated, gender)
 }
 
@@ -119,7 +121,7 @@ func UpdateGender(c *gin.Context) {
 	}
 
 	// Fetch the existing gender value first to verify it exists
-	_, err = db.FetchGenderByID(id)
+	existingGender, err := db.FetchGenderByID(id)
 	if err != nil {
 		if err.Error() == "not_found" {
 			handlers.SendError(c, http.StatusNotFound, "Gender value not found", nil)
@@ -143,6 +145,8 @@ func UpdateGender(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGender, id, models.AuditActionUpdate, existingGender, updatedGender)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedGender)
 }
 
@@ -155,12 +159,25 @@ func DeleteGender(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingGender, err := db.FetchGenderByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Gender value not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing gender value", nil)
+		}
+		return
+	}
+
 	err = db.DeleteGender(id)
 	if err != nil {
 		handlers.SendError(c, http.StatusInternalServerError, "Failed to delete gender value: "+err.Error(), nil)
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGender, id, models.AuditActionDelete, existingGender, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Gender value deleted successfully"})
 }
 
@@ -227,5 +244,13 @@ func RestoreGender(c *gin.Context) {
 		return
 	}
 
+	restoredGender, err := db.FetchGenderByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored gender", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGender, id, models.AuditActionRestore, nil, restoredGender)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Gender value restored successfully"})
 }
@@ -106,6 +106,8 @@ func CreateGrup(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGrup, grup.ID, models.AuditActionCreate, nil, grup)
+
 	handlers.SendSuccess(c, http.StatusCreated, grup)
 }
 
@@ -119,7 +121,7 @@ func UpdateGrup(c *gin.Context) {
 	}
 
 	// Fetch the existing grup value first to verify it exists
-	_, err = db.FetchGrupByID(id)
+	existingGrup, err := db.FetchGrupByID(id)
 	if err != nil {
 		if err.Error() == "not_found" {
 			handlers.SendError(c, http.StatusNotFound, "Grup value not found", nil)
@@ -143,6 +145,8 @@ func UpdateGrup(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGrup, id, models.AuditActionUpdate, existingGrup, updatedGrup)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedGrup)
 }
 
@@ -155,12 +159,25 @@ func DeleteGrup(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingGrup, err := db.FetchGrupByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Grup value not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing grup value", nil)
+		}
+		return
+	}
+
 	err = db.DeleteGrup(id)
 	if err != nil {
 		handlers.SendError(c, http.StatusInternalServerError, "Failed to delete grup value: "+err.Error(), nil)
 		return
 	}
 
+	recordAudit(c, models.AuditEntityGrup, id, models.AuditActionDelete, existingGrup, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Grup value deleted successfully"})
 }
 
@@ -227,5 +244,13 @@ func RestoreGrup(c *gin.Context) {
 		return
 	}
 
+	restoredGrup, err := db.FetchGrupByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored grup", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityGrup, id, models.AuditActionRestore, nil, restoredGrup)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Grup value restored successfully"})
 }
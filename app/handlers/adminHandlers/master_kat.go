@@ -106,6 +106,8 @@ func CreateKat(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityKat, kat.ID, models.AuditActionCreate, nil, kat)
+
 	handlers.SendSuccess(c, http.StatusCreated, kat)
 }
 
@@ -119,7 +121,7 @@ func UpdateKat(c *gin.Context) {
 	}
 
 	// Fetch the existing category value first to verify it exists
-	_, err = db.FetchKatByID(id)
+	existingKat, err := db.FetchKatByID(id)
 	if err != nil {
 		if err.Error() == "not_found" {
 			handlers.SendError(c, http.StatusNotFound, "Category value not found", nil)
@@ -143,6 +145,8 @@ func UpdateKat(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityKat, id, models.AuditActionUpdate, existingKat, updatedKat)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedKat)
 }
 
@@ -155,12 +159,25 @@ func DeleteKat(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingKat, err := db.FetchKatByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Category value not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing category value", nil)
+		}
+		return
+	}
+
 	err = db.DeleteKat(id)
 	if err != nil {
 		handlers.SendError(c, http.StatusInternalServerError, "Failed to delete category value: "+err.Error(), nil)
 		return
 	}
 
+	recordAudit(c, models.AuditEntityKat, id, models.AuditActionDelete, existingKat, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Category value deleted successfully"})
 }
 
@@ -227,5 +244,13 @@ func RestoreKat(c *gin.Context) {
 		return
 	}
 
+	restoredKat, err := db.FetchKatByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored kat", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityKat, id, models.AuditActionRestore, nil, restoredKat)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Category value restored successfully"})
 }
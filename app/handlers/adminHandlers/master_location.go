@@ -0,0 +1,268 @@
+package adminHandlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/master_location"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllLocations handles fetching all locations with pagination, search and an optional type filter
+func GetAllLocations(c *gin.Context) {
+	// Read query params
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+	queryStr := c.DefaultQuery("q", "")
+	tipe := strings.ToLower(c.DefaultQuery("tipe", ""))
+	sortColumn := c.DefaultQuery("sort", "id")
+	sortDirection := c.DefaultQuery("order", "asc")
+
+	limit, err1 := strconv.Atoi(limitStr)
+	offset, err2 := strconv.Atoi(offsetStr)
+
+	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid pagination parameters", nil)
+		return
+	}
+
+	// Get current page from offset
+	page := (offset / limit) + 1
+
+	// Fetch total count with search term applied
+	totalCount, err := db.CountAllLocations(queryStr, tipe)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count locations", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated locations with search term applied
+	locations, err := db.FetchAllLocations(limit, offset, queryStr, tipe, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch locations", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"locations":  locations,
+		"page":       page,
+		"total_page": totalPages,
+		"total":      totalCount,
+		"sort":       sortColumn,
+		"order":      sortDirection,
+	})
+}
+
+// GetLocationByID handles fetching a single location by ID
+func GetLocationByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	location, err := db.FetchLocationByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Location not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch location", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, location)
+}
+
+// CreateLocation handles creating a new location
+func CreateLocation(c *gin.Context) {
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := master_location.ValidateLocation(&location, true); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	// Insert to database
+	err := db.InsertLocation(&location)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create location: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityLocation, location.ID, models.AuditActionCreate, nil, location)
+
+	handlers.SendSuccess(c, http.StatusCreated, location)
+}
+
+// UpdateLocation handles updating an existing location
+func UpdateLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	// Fetch the existing location first to verify it exists
+	existingLocation, err := db.FetchLocationByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Location not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing location", nil)
+		}
+		return
+	}
+
+	// Parse request body
+	var locationToUpdate models.Location
+	if err := c.ShouldBindJSON(&locationToUpdate); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := master_location.ValidateLocation(&locationToUpdate, false); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	// Update the record
+	updatedLocation, err := db.UpdateLocation(id, &locationToUpdate)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to update location: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityLocation, id, models.AuditActionUpdate, existingLocation, updatedLocation)
+
+	handlers.SendSuccess(c, http.StatusOK, updatedLocation)
+}
+
+// DeleteLocation handles soft-deleting a location
+func DeleteLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	// Fetch the record first, the audit log keeps what was deleted
+	existingLocation, err := db.FetchLocationByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Location not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing location", nil)
+		}
+		return
+	}
+
+	err = db.DeleteLocation(id)
+	if err != nil {
+		switch err.Error() {
+		case "not_found":
+			handlers.SendError(c, http.StatusNotFound, "Location not found", nil)
+		case "default_location":
+			handlers.SendError(c, http.StatusConflict, "The default location cannot be deleted", nil)
+		case "location_has_stock":
+			handlers.SendError(c, http.StatusConflict, "Location still holds stock; transfer it out first", nil)
+		default:
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to delete location: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityLocation, id, models.AuditActionDelete, existingLocation, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Location deleted successfully"})
+}
+
+// GetDeletedLocations retrieves all soft-deleted locations with pagination
+func GetDeletedLocations(c *gin.Context) {
+	// Read query params
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "tanggal_hapus")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	limit, err1 := strconv.Atoi(limitStr)
+	offset, err2 := strconv.Atoi(offsetStr)
+
+	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid pagination parameters", nil)
+		return
+	}
+
+	// Get current page from offset
+	page := (offset / limit) + 1
+
+	// Fetch total count with search term applied
+	totalCount, err := db.CountDeletedLocations(queryStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count deleted locations", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated deleted locations with search term applied
+	locations, err := db.FetchDeletedLocations(limit, offset, queryStr, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch deleted locations", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"locations":  locations,
+		"page":       page,
+		"total_page": totalPages,
+		"total":      totalCount,
+		"sort":       sortColumn,
+		"order":      sortDirection,
+	})
+}
+
+// RestoreLocation handles restoring a soft-deleted location
+func RestoreLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	err = db.RestoreLocation(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to restore location: "+err.Error(), nil)
+		return
+	}
+
+	restoredLocation, err := db.FetchLocationByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored location", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityLocation, id, models.AuditActionRestore, nil, restoredLocation)
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Location restored successfully"})
+}
@@ -84,6 +84,17 @@ func UpdateNewsletter(c *gin.Context) {
 		return
 	}
 
+	// Fetch the existing newsletter entry first to verify it exists
+	existingNewsletter, err := db.FetchNewsletterByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Newsletter entry not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing newsletter entry", nil)
+		}
+		return
+	}
+
 	var newsletter models.Newsletter
 	if err := c.ShouldBindJSON(&newsletter); err != nil {
 		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
@@ -96,6 +107,8 @@ func UpdateNewsletter(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityNewsletter, id, models.AuditActionUpdate, existingNewsletter, updatedNewsletter)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedNewsletter)
 }
 
@@ -108,6 +121,17 @@ func DeleteNewsletter(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingNewsletter, err := db.FetchNewsletterByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Newsletter entry not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing newsletter entry", nil)
+		}
+		return
+	}
+
 	err = db.DeleteNewsletter(id)
 	if err != nil {
 		if err.Error() == "not_found" {
@@ -118,6 +142,8 @@ func DeleteNewsletter(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityNewsletter, id, models.AuditActionDelete, existingNewsletter, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Newsletter entry deleted successfully"})
 }
 
@@ -182,5 +208,13 @@ func RestoreNewsletter(c *gin.Context) {
 		return
 	}
 
+	restoredNewsletter, err := db.FetchNewsletterByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored newsletter", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityNewsletter, id, models.AuditActionRestore, nil, restoredNewsletter)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Newsletter entry restored successfully"})
 }
@@ -132,6 +132,13 @@ func GetProductByID(c *gin.Context) {
 		return
 	}
 
+	timeline, err := db.FetchProductPriceTimeline(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch price history", nil)
+		return
+	}
+	product.PriceTimeline = &timeline
+
 	handlers.SendSuccess(c, http.StatusOK, product)
 }
 
@@ -296,9 +303,11 @@ func CreateProduct(c *gin.Context) {
 		TanggalTerima: tanggalTerima,
 		Status:        req.Status,
 		Supplier:      req.Supplier,
-		DiupdateOleh:  req.DiupdateOleh,
 	}
 
+	// The editor is whoever the token belongs to, not what the client claims
+	_, product.DiupdateOleh = helpers.CurrentUser(c)
+
 	log.Println("Product struct created:", product)
 
 	// Handle rating JSON
@@ -330,7 +339,7 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	// Define fields that need to be converted from IDs to values
-	fieldsToConvert := []string{"Grup", "Unit", "Kat", "Gender", "Tipe"}
+	fieldsToConvert := []string{"Grup", "Unit", "Kat", "Gender", "Tipe", "Supplier"}
 
 	// Convert all IDs to values in one call
 	helpers.ConvertProductFields(&product, fieldsToConvert)
@@ -344,6 +353,8 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityProduct, product.No, models.AuditActionCreate, nil, product)
+
 	handlers.SendSuccess(c, http.StatusCreated, product)
 	log.Println("--------------------------------")
 }
@@ -494,19 +505,18 @@ func UpdateProduct(c *gin.Context) {
 
 	// Define string fields mapping and update in one loop
 	stringFields := map[string]*string{
-		"nama":          &productToUpdate.Nama,
-		"deskripsi":     &productToUpdate.Deskripsi,
-		"warna":         &productToUpdate.Warna,
-		"size":          &productToUpdate.Size,
-		"grup":          &productToUpdate.Grup,
-		"unit":          &productToUpdate.Unit,
-		"kat":           &productToUpdate.Kat,
-		"model":         &productToUpdate.Model,
-		"gender":        &productToUpdate.Gender,
-		"tipe":          &productToUpdate.Tipe,
-		"status":        &productToUpdate.Status,
-		"supplier":      &productToUpdate.Supplier,
-		"diupdate_oleh": &productToUpdate.DiupdateOleh,
+		"nama":      &productToUpdate.Nama,
+		"deskripsi": &productToUpdate.Deskripsi,
+		"warna":     &productToUpdate.Warna,
+		"size":      &productToUpdate.Size,
+		"grup":      &productToUpdate.Grup,
+		"unit":      &productToUpdate.Unit,
+		"kat":       &productToUpdate.Kat,
+		"model":     &productToUpdate.Model,
+		"gender":    &productToUpdate.Gender,
+		"tipe":      &productToUpdate.Tipe,
+		"status":    &productToUpdate.Status,
+		"supplier":  &productToUpdate.Supplier,
 	}
 
 	// Process all string fields
@@ -801,8 +811,9 @@ func UpdateProduct(c *gin.Context) {
 		}
 	}
 
-	// Update the tanggal_update field to now
+	// Update the tanggal_update field to now, by the user the token belongs to
 	productToUpdate.TanggalUpdate = time.Now()
+	_, productToUpdate.DiupdateOleh = helpers.CurrentUser(c)
 	log.Printf("UpdateProduct: Updated tanggal_update to: %v", productToUpdate.TanggalUpdate)
 	log.Println("")
 
@@ -816,7 +827,7 @@ func UpdateProduct(c *gin.Context) {
 	log.Println("UpdateProduct: Product validation successful")
 
 	// Define fields that need to be converted from IDs to values
-	fieldsToConvert := []string{"Grup", "Unit", "Kat", "Gender", "Tipe"}
+	fieldsToConvert := []string{"Grup", "Unit", "Kat", "Gender", "Tipe", "Supplier"}
 
 	// Convert all IDs to values in one call
 	log.Println("UpdateProduct: Converting product fields from IDs to values")
@@ -838,6 +849,7 @@ func UpdateProduct(c *gin.Context) {
 	}
 
 	log.Printf("UpdateProduct: Successfully updated product: %+v", updatedProduct)
+	recordAudit(c, models.AuditEntityProduct, productID, models.AuditActionUpdate, existingProduct, updatedProduct)
 	handlers.SendSuccess(c, http.StatusOK, updatedProduct)
 	log.Println("--------------------------------")
 }
@@ -852,6 +864,17 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	// Fetch the product first, the audit log keeps what was deleted
+	existingProduct, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
 	err = db.DeleteProduct(id)
 	if err != nil {
 		if err.Error() == "not_found" {
@@ -862,6 +885,8 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityProduct, id, models.AuditActionDelete, existingProduct, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
@@ -945,6 +970,14 @@ func RestoreProduct(c *gin.Context) {
 		return
 	}
 
+	restoredProduct, err := db.FetchProductByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored product", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityProduct, id, models.AuditActionRestore, product, restoredProduct)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Product restored successfully"})
 }
 
@@ -0,0 +1,307 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/master_supplier"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllSuppliers handles fetching all suppliers with pagination and search
+func GetAllSuppliers(c *gin.Context) {
+	// Read query params
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "id")
+	sortDirection := c.DefaultQuery("order", "asc")
+
+	limit, err1 := strconv.Atoi(limitStr)
+	offset, err2 := strconv.Atoi(offsetStr)
+
+	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid pagination parameters", nil)
+		return
+	}
+
+	// Get current page from offset
+	page := (offset / limit) + 1
+
+	// Fetch total count with search term applied
+	totalCount, err := db.CountAllSuppliers(queryStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count suppliers", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated suppliers with search term applied
+	suppliers, err := db.FetchAllSuppliers(limit, offset, queryStr, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch suppliers", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"suppliers":  suppliers,
+		"page":       page,
+		"total_page": totalPages,
+		"total":      totalCount,
+		"sort":       sortColumn,
+		"order":      sortDirection,
+	})
+}
+
+// GetSupplierByID handles fetching a single supplier by ID
+func GetSupplierByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	supplier, err := db.FetchSupplierByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Supplier not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch supplier", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, supplier)
+}
+
+// CreateSupplier handles creating a new supplier
+func CreateSupplier(c *gin.Context) {
+	var supplier models.Supplier
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Validate required fields
+	if validationErr := master_supplier.ValidateSupplier(&supplier, true); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	// Reject spellings of an existing supplier such as "pt abc" for "PT ABC"
+	if exists, err := db.SupplierNameExists(supplier.Nama, 0); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to check supplier name: "+err.Error(), nil)
+		return
+	} else if exists {
+		errorField := "nama"
+		handlers.SendError(c, http.StatusConflict, "A supplier with this name already exists", &errorField)
+		return
+	}
+
+	// Set update timestamp
+	supplier.TanggalUpdate = time.Now()
+
+	// Insert to database
+	err := db.InsertSupplier(&supplier)
+	if errors.Is(err, db.ErrSupplierNameTaken) {
+		errorField := "nama"
+		handlers.SendError(c, http.StatusConflict, "A supplier with this name already exists", &errorField)
+		return
+	}
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create supplier: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntitySupplier, supplier.ID, models.AuditActionCreate, nil, supplier)
+
+	handlers.SendSuccess(c, http.StatusCreated, supplier)
+}
+
+// UpdateSupplier handles updating an existing supplier
+func UpdateSupplier(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	// Fetch the existing supplier first to verify it exists
+	existingSupplier, err := db.FetchSupplierByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Supplier not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing supplier", nil)
+		}
+		return
+	}
+
+	// Parse request body
+	var supplierToUpdate models.Supplier
+	if err := c.ShouldBindJSON(&supplierToUpdate); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := master_supplier.ValidateSupplier(&supplierToUpdate, false); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	if supplierToUpdate.Nama != "" {
+		if exists, err := db.SupplierNameExists(supplierToUpdate.Nama, id); err != nil {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to check supplier name: "+err.Error(), nil)
+			return
+		} else if exists {
+			errorField := "nama"
+			handlers.SendError(c, http.StatusConflict, "A supplier with this name already exists", &errorField)
+			return
+		}
+	}
+
+	// Update the record
+	updatedSupplier, err := db.UpdateSupplier(id, &supplierToUpdate)
+	if errors.Is(err, db.ErrSupplierNameTaken) {
+		errorField := "nama"
+		handlers.SendError(c, http.StatusConflict, "A supplier with this name already exists", &errorField)
+		return
+	}
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to update supplier: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntitySupplier, id, models.AuditActionUpdate, existingSupplier, updatedSupplier)
+
+	handlers.SendSuccess(c, http.StatusOK, updatedSupplier)
+}
+
+// DeleteSupplier handles soft-deleting a supplier
+func DeleteSupplier(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	// Fetch the record first, the audit log keeps what was deleted
+	existingSupplier, err := db.FetchSupplierByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Supplier not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing supplier", nil)
+		}
+		return
+	}
+
+	err = db.DeleteSupplier(id)
+	if err != nil {
+		switch err.Error() {
+		case "not_found":
+			handlers.SendError(c, http.StatusNotFound, "Supplier not found", nil)
+		case "supplier_has_products":
+			handlers.SendError(c, http.StatusConflict, "Supplier is still set on active products; change their supplier first", nil)
+		case "supplier_has_open_orders":
+			handlers.SendError(c, http.StatusConflict, "Supplier still has open purchase orders; close or cancel them first", nil)
+		default:
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to delete supplier: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntitySupplier, id, models.AuditActionDelete, existingSupplier, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Supplier deleted successfully"})
+}
+
+// GetDeletedSuppliers retrieves all soft-deleted suppliers with pagination
+func GetDeletedSuppliers(c *gin.Context) {
+	// Read query params
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "tanggal_hapus")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	limit, err1 := strconv.Atoi(limitStr)
+	offset, err2 := strconv.Atoi(offsetStr)
+
+	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid pagination parameters", nil)
+		return
+	}
+
+	// Get current page from offset
+	page := (offset / limit) + 1
+
+	// Fetch total count with search term applied
+	totalCount, err := db.CountDeletedSuppliers(queryStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count deleted suppliers", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated deleted suppliers with search term applied
+	suppliers, err := db.FetchDeletedSuppliers(limit, offset, queryStr, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch deleted suppliers", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"suppliers":  suppliers,
+		"page":       page,
+		"total_page": totalPages,
+		"total":      totalCount,
+		"sort":       sortColumn,
+		"order":      sortDirection,
+	})
+}
+
+// RestoreSupplier handles restoring a soft-deleted supplier
+func RestoreSupplier(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	err = db.RestoreSupplier(id)
+	if errors.Is(err, db.ErrSupplierNameTaken) {
+		handlers.SendError(c, http.StatusConflict, "An active supplier already has this name", nil)
+		return
+	}
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to restore supplier: "+err.Error(), nil)
+		return
+	}
+
+	restoredSupplier, err := db.FetchSupplierByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored supplier", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntitySupplier, id, models.AuditActionRestore, nil, restoredSupplier)
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Supplier restored successfully"})
+}
@@ -106,6 +106,8 @@ func CreateTipe(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityTipe, tipe.ID, models.AuditActionCreate, nil, tipe)
+
 	handlers.SendSuccess(c, http.StatusCreated, tipe)
 }
 
@@ -119,7 +121,7 @@ func UpdateTipe(c *gin.Context) {
 	}
 
 	// Fetch the existing tipe value first to verify it exists
-	_, err = db.FetchTipeByID(id)
+	existingTipe, err := db.FetchTipeByID(id)
 	if err != nil {
 		if err.Error() == "not_found" {
 			handlers.SendError(c, http.StatusNotFound, "Tipe value not found", nil)
@@ -143,6 +145,8 @@ func UpdateTipe(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityTipe, id, models.AuditActionUpdate, existingTipe, updatedTipe)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedTipe)
 }
 
@@ -155,12 +159,25 @@ func DeleteTipe(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingTipe, err := db.FetchTipeByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Tipe value not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing tipe value", nil)
+		}
+		return
+	}
+
 	err = db.DeleteTipe(id)
 	if err != nil {
 		handlers.SendError(c, http.StatusInternalServerError, "Failed to delete tipe value: "+err.Error(), nil)
 		return
 	}
 
+	recordAudit(c, models.AuditEntityTipe, id, models.AuditActionDelete, existingTipe, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Tipe value deleted successfully"})
 }
 
@@ -227,5 +244,13 @@ func RestoreTipe(c *gin.Context) {
 		return
 	}
 
+	restoredTipe, err := db.FetchTipeByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored tipe", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityTipe, id, models.AuditActionRestore, nil, restoredTipe)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Tipe value restored successfully"})
 }
@@ -106,6 +106,8 @@ func CreateUnit(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityUnit, unit.ID, models.AuditActionCreate, nil, unit)
+
 	handlers.SendSuccess(c, http.StatusCreated, unit)
 }
 
@@ -119,7 +121,7 @@ func UpdateUnit(c *gin.Context) {
 	}
 
 	// Fetch the existing unit value first to verify it exists
-	_, err = db.FetchUnitByID(id)
+	existingUnit, err := db.FetchUnitByID(id)
 	if err != nil {
 		if err.Error() == "not_found" {
 			handlers.SendError(c, http.StatusNotFound, "Unit value not found", nil)
@@ -143,6 +145,8 @@ func UpdateUnit(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, models.AuditEntityUnit, id, models.AuditActionUpdate, existingUnit, updatedUnit)
+
 	handlers.SendSuccess(c, http.StatusOK, updatedUnit)
 }
 
@@ -155,12 +159,25 @@ func DeleteUnit(c *gin.Context) {
 		return
 	}
 
+	// Fetch the record first, the audit log keeps what was deleted
+	existingUnit, err := db.FetchUnitByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Unit value not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing unit value", nil)
+		}
+		return
+	}
+
 	err = db.DeleteUnit(id)
 	if err != nil {
 		handlers.SendError(c, http.StatusInternalServerError, "Failed to delete unit value: "+err.Error(), nil)
 		return
 	}
 
+	recordAudit(c, models.AuditEntityUnit, id, models.AuditActionDelete, existingUnit, nil)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Unit value deleted successfully"})
 }
 
@@ -227,5 +244,13 @@ func RestoreUnit(c *gin.Context) {
 		return
 	}
 
+	restoredUnit, err := db.FetchUnitByID(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch restored unit", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUnit, id, models.AuditActionRestore, nil, restoredUnit)
+
 	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Unit value restored successfully"})
 }
@@ -0,0 +1,248 @@
+package adminHandlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/labels"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits of a single label sheet request
+const (
+	maxLabelProducts = 200
+	maxLabelCopies   = 100
+	maxSheetLabels   = 2000
+)
+
+// GetProductBarcode handles rendering a barcode for a product. Code128 and QR codes encode the artikel;
+// EAN-13 barcodes belong to variants.
+func GetProductBarcode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	product, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	if c.DefaultQuery("format", labels.FormatCode128) == labels.FormatEAN13 {
+		errorField := "format"
+		handlers.SendError(c, http.StatusBadRequest, "EAN-13 barcodes belong to variants, use the variant barcode endpoint", &errorField)
+		return
+	}
+
+	sendBarcode(c, product.Artikel, "product-"+product.No)
+}
+
+// GetProductVariantBarcode handles rendering a barcode for a variant. Code128 and QR codes encode the
+// SKU, EAN-13 barcodes the barcode of the variant.
+func GetProductVariantBarcode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid variant ID", nil)
+		return
+	}
+
+	variant, err := db.FetchProductVariantByID(id, variantID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Variant not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch variant", nil)
+		}
+		return
+	}
+
+	content := variant.SKU
+	if c.DefaultQuery("format", labels.FormatCode128) == labels.FormatEAN13 {
+		content = variant.Barcode
+	}
+	sendBarcode(c, content, variant.SKU)
+}
+
+// sendBarcode encodes content in the format of the format query parameter (code128, ean13 or qr) and
+// responds with it as a PNG or SVG according to the output parameter, scale pixels per module
+func sendBarcode(c *gin.Context, content, filename string) {
+	format := c.DefaultQuery("format", labels.FormatCode128)
+	if !labels.IsValidFormat(format) {
+		errorField := "format"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid format, use code128, ean13 or qr", &errorField)
+		return
+	}
+
+	output := c.DefaultQuery("output", labels.OutputPNG)
+	if output != labels.OutputPNG && output != labels.OutputSVG {
+		errorField := "output"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid output, use png or svg", &errorField)
+		return
+	}
+
+	scale, err := strconv.Atoi(c.DefaultQuery("scale", "3"))
+	if err != nil || scale < 1 || scale > 20 {
+		errorField := "scale"
+		handlers.SendError(c, http.StatusBadRequest, "Scale must be between 1 and 20", &errorField)
+		return
+	}
+
+	bc, err := labels.Encode(format, content)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Cannot encode barcode: "+err.Error(), nil)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if output == labels.OutputSVG {
+		contentType = "image/svg+xml"
+		err = labels.WriteSVG(&buf, bc, scale)
+	} else {
+		err = labels.WritePNG(&buf, bc, scale)
+	}
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to render barcode", nil)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s-%s.%s"`, filename, format, output))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// CreateLabelSheet handles printing labels for a selection of products as an A4 PDF. Each label shows
+// the artikel, nama, color, size and price of a variant with its barcode.
+func CreateLabelSheet(c *gin.Context) {
+	var req models.LabelSheetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	if len(req.ProductNos) == 0 || len(req.ProductNos) > maxLabelProducts {
+		errorField := "product_nos"
+		handlers.SendError(c, http.StatusBadRequest, fmt.Sprintf("Select between 1 and %d products", maxLabelProducts), &errorField)
+		return
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+	if req.Copies < 1 || req.Copies > maxLabelCopies {
+		errorField := "copies"
+		handlers.SendError(c, http.StatusBadRequest, fmt.Sprintf("Copies must be between 1 and %d", maxLabelCopies), &errorField)
+		return
+	}
+	if req.Symbology == "" {
+		req.Symbology = labels.FormatCode128
+	}
+	if req.Symbology != labels.FormatCode128 && req.Symbology != labels.FormatEAN13 {
+		errorField := "symbology"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid symbology, use code128 or ean13", &errorField)
+		return
+	}
+
+	selectedVariants := make(map[int]bool)
+	for _, id := range req.VariantIDs {
+		selectedVariants[id] = true
+	}
+
+	sheet := []labels.Label{}
+	for _, productNo := range req.ProductNos {
+		product, err := db.FetchProductByID(productNo)
+		if err != nil {
+			if err.Error() == "not_found" {
+				errorField := "product_nos"
+				handlers.SendError(c, http.StatusNotFound, fmt.Sprintf("Product %d not found", productNo), &errorField)
+			} else {
+				handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+			}
+			return
+		}
+
+		for _, label := range productLabels(product, selectedVariants, req.Symbology) {
+			for i := 0; i < req.Copies; i++ {
+				sheet = append(sheet, label)
+			}
+		}
+		if len(sheet) > maxSheetLabels {
+			handlers.SendError(c, http.StatusBadRequest, fmt.Sprintf("A sheet holds at most %d labels", maxSheetLabels), nil)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := labels.WriteSheet(&buf, sheet); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Failed to render labels: "+err.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="labels.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// productLabels builds the labels of the active variants of a product, limited to the selected variants
+// when any are selected. A product without variants gets one label with its own sizes and colors.
+func productLabels(product models.Product, selectedVariants map[int]bool, symbology string) []labels.Label {
+	price := product.Harga
+	if product.HargaDiskon != nil {
+		price = *product.HargaDiskon
+	}
+
+	if len(product.Variants) == 0 {
+		colorNames := []string{}
+		for _, color := range product.Colors {
+			colorNames = append(colorNames, color.Name)
+		}
+		return []labels.Label{{
+			Artikel:   product.Artikel,
+			Nama:      product.Nama,
+			Warna:     strings.Join(colorNames, ", "),
+			Size:      product.Size,
+			Harga:     price,
+			Code:      product.Artikel,
+			Symbology: labels.FormatCode128,
+		}}
+	}
+
+	result := []labels.Label{}
+	for _, variant := range product.Variants {
+		if !variant.IsActive || (len(selectedVariants) > 0 && !selectedVariants[variant.ID]) {
+			continue
+		}
+
+		label := labels.Label{
+			Artikel:   product.Artikel,
+			Nama:      product.Nama,
+			Warna:     variant.ColorName,
+			Size:      variant.Size,
+			Harga:     price,
+			Code:      variant.SKU,
+			Symbology: labels.FormatCode128,
+		}
+		if variant.Harga != nil {
+			label.Harga = *variant.Harga
+		}
+		if symbology == labels.FormatEAN13 && variant.Barcode != "" {
+			label.Code, label.Symbology = variant.Barcode, labels.FormatEAN13
+		}
+		result = append(result, label)
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/product_lifecycle"
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateProductLifecycle handles moving a product through draft, in review, published and archived.
+// Submitting for review needs products:write; publishing, unpublishing and archiving need products:publish
+// when approval is required and products:write otherwise.
+func UpdateProductLifecycle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	var req models.ProductLifecycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if validationErr := product_lifecycle.ValidateStatus(&req.Status); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existingProduct, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	permissions, validationErr := product_lifecycle.ValidateTransition(existingProduct.LifecycleStatus, req.Status, settings.Current().Products.RequireApproval)
+	if validationErr != nil {
+		handlers.SendError(c, http.StatusConflict, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+	if !hasAnyPermission(c, permissions) {
+		handlers.SendError(c, http.StatusForbidden, "Insufficient permissions", nil)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	product, err := db.TransitionProductLifecycle(id, existingProduct.LifecycleStatus, req.Status, username)
+	if err != nil {
+		switch err.Error() {
+		case "not_found":
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		case "invalid_status":
+			handlers.SendError(c, http.StatusConflict, "Product status changed in the meantime, reload and try again", nil)
+		default:
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to update product status: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityProduct, id, models.AuditActionUpdate, existingProduct, product)
+	handlers.SendSuccess(c, http.StatusOK, product)
+}
+
+// UpdateProductPublishSchedule handles setting when a product is published and unpublished by the
+// publish scheduler. It needs the same permission as publishing.
+func UpdateProductPublishSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	if !auth.ContextHasPermission(c, product_lifecycle.PublishPermission(settings.Current().Products.RequireApproval)) {
+		handlers.SendError(c, http.StatusForbidden, "Insufficient permissions", nil)
+		return
+	}
+
+	var req models.ProductPublishScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if validationErr := product_lifecycle.ValidatePublishSchedule(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existingProduct, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+	if req.Publish != nil && existingProduct.LifecycleStatus != models.ProductLifecycleDraft && existingProduct.LifecycleStatus != models.ProductLifecycleInReview {
+		errorField := "publish_at"
+		handlers.SendError(c, http.StatusConflict, "Only draft and in review products can be scheduled for publishing", &errorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	product, err := db.UpdateProductPublishSchedule(id, req.Publish, req.Unpublish, username)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to update publish schedule: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityProduct, id, models.AuditActionUpdate, existingProduct, product)
+	handlers.SendSuccess(c, http.StatusOK, product)
+}
+
+// hasAnyPermission reports whether the authenticated user or API key holds any of the permissions
+func hasAnyPermission(c *gin.Context, permissions []string) bool {
+	for _, permission := range permissions {
+		if auth.ContextHasPermission(c, permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/product_price"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductPriceTimeline handles retrieving the price history and pending price changes of a product
+func GetProductPriceTimeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	if _, err := db.FetchProductByID(id); err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	timeline, err := db.FetchProductPriceTimeline(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch price history", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, timeline)
+}
+
+// CreateProductPriceSchedule handles scheduling a future price and discount for a product
+func CreateProductPriceSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	var req models.CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := product_price.ValidateCreateSchedule(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	if _, err := db.FetchProductByID(id); err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	schedule, err := db.InsertProductPriceSchedule(id, &req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to schedule price change: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPriceSchedule, schedule.ID, models.AuditActionCreate, nil, schedule)
+	handlers.SendSuccess(c, http.StatusCreated, schedule)
+}
+
+// CancelProductPriceSchedule handles cancelling a price change that has not been applied yet
+func CancelProductPriceSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+	scheduleID, err := strconv.Atoi(c.Param("scheduleId"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid schedule ID", nil)
+		return
+	}
+
+	existingSchedule, err := db.FetchProductPriceScheduleByID(id, scheduleID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Price schedule not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch price schedule", nil)
+		}
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	schedule, err := db.CancelProductPriceSchedule(id, scheduleID, username)
+	if err != nil {
+		switch err.Error() {
+		case "not_found":
+			handlers.SendError(c, http.StatusNotFound, "Price schedule not found", nil)
+		case "invalid_status":
+			handlers.SendError(c, http.StatusConflict, "Price schedule is no longer pending", nil)
+		default:
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to cancel price schedule: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPriceSchedule, schedule.ID, models.AuditActionUpdate, existingSchedule, schedule)
+	handlers.SendSuccess(c, http.StatusOK, schedule)
+}
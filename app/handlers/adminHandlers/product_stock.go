@@ -0,0 +1,113 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/common"
+	"github.com/everysoft/inventary-be/app/validation/product_stock"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductStock handles retrieving the per-variant stock of a product
+func GetProductStock(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	// An optional location narrows the stock to a single location
+	locationID := 0
+	if locationStr := c.Query("location_id"); locationStr != "" {
+		locationID, err = strconv.Atoi(locationStr)
+		if err != nil || locationID < 1 {
+			handlers.SendError(c, http.StatusBadRequest, "Invalid location ID", nil)
+			return
+		}
+	}
+
+	stocks, total, err := db.FetchProductStocks(id, locationID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product stock", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"product_no":  id,
+		"location_id": locationID,
+		"stocks":      stocks,
+		"total_stock": total,
+	})
+}
+
+// UpdateProductStock handles setting the on-hand quantity of one or more variants of a product
+func UpdateProductStock(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	product, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	var req models.SetStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := product_stock.ValidateSetStock(&product, req.Stocks); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	movements, err := db.SetProductStocks(id, locationID, req.Stocks, userID, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to update product stock: "+err.Error(), nil)
+		return
+	}
+
+	for _, movement := range movements {
+		recordAudit(c, models.AuditEntityStockMovement, movement.ID, models.AuditActionCreate, nil, movement)
+	}
+
+	stocks, total, err := db.FetchProductStocks(id, locationID)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product stock", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"product_no":  id,
+		"location_id": locationID,
+		"stocks":      stocks,
+		"total_stock": total,
+		"movements":   movements,
+	})
+}
@@ -0,0 +1,143 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// barcodePattern accepts the digits of EAN-8, UPC-A, EAN-13 and GTIN-14 barcodes
+var barcodePattern = regexp.MustCompile(`^[0-9]{8,14}$`)
+
+// GetProductVariants handles listing the color × size variants of a product
+func GetProductVariants(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	product, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, product.Variants)
+}
+
+// UpdateProductVariant handles changing the SKU, barcode, price override or active flag of a variant.
+// Only the fields present in the body change; a null harga removes the price override.
+func UpdateProductVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid variant ID", nil)
+		return
+	}
+
+	existingVariant, err := db.FetchProductVariantByID(id, variantID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Variant not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch variant", nil)
+		}
+		return
+	}
+
+	var requestBody map[string]interface{}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	variantToUpdate := existingVariant
+
+	if value, ok := requestBody["sku"]; ok {
+		sku, isString := value.(string)
+		sku = strings.ToUpper(strings.TrimSpace(sku))
+		if !isString || sku == "" {
+			errorField := "sku"
+			handlers.SendError(c, http.StatusBadRequest, "SKU cannot be empty", &errorField)
+			return
+		}
+		variantToUpdate.SKU = sku
+	}
+
+	if value, ok := requestBody["barcode"]; ok {
+		barcode, _ := value.(string)
+		barcode = strings.TrimSpace(barcode)
+		if barcode != "" && !barcodePattern.MatchString(barcode) {
+			errorField := "barcode"
+			handlers.SendError(c, http.StatusBadRequest, "Barcode must be 8 to 14 digits", &errorField)
+			return
+		}
+		variantToUpdate.Barcode = barcode
+	}
+
+	if value, ok := requestBody["harga"]; ok {
+		if value == nil {
+			variantToUpdate.Harga = nil
+		} else if harga, isNumber := value.(float64); isNumber && harga > 0 {
+			variantToUpdate.Harga = &harga
+		} else {
+			errorField := "harga"
+			handlers.SendError(c, http.StatusBadRequest, "Harga must be a positive number or null", &errorField)
+			return
+		}
+	}
+
+	if value, ok := requestBody["is_active"]; ok {
+		isActive, isBool := value.(bool)
+		if !isBool {
+			errorField := "is_active"
+			handlers.SendError(c, http.StatusBadRequest, "is_active must be true or false", &errorField)
+			return
+		}
+		variantToUpdate.IsActive = isActive
+	}
+
+	// SKUs and barcodes identify a single variant across all products
+	codes := map[string]string{"sku": variantToUpdate.SKU, "barcode": variantToUpdate.Barcode}
+	for field, value := range codes {
+		if value == "" {
+			continue
+		}
+		exists, err := db.ProductVariantCodeExists(field, value, variantID)
+		if err != nil {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to check variant "+field+": "+err.Error(), nil)
+			return
+		}
+		if exists {
+			errorField := field
+			handlers.SendError(c, http.StatusConflict, "Another variant already uses this "+field, &errorField)
+			return
+		}
+	}
+
+	updatedVariant, err := db.UpdateProductVariant(&variantToUpdate)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to update variant: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityProductVariant, variantID, models.AuditActionUpdate, existingVariant, updatedVariant)
+
+	handlers.SendSuccess(c, http.StatusOK, updatedVariant)
+}
@@ -0,0 +1,174 @@
+package adminHandlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/promotion"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllPromotions handles listing promotions with pagination, search and type and status filters
+func GetAllPromotions(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "starts_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+	filters := helpers.ExtractPromotionFilters(c)
+
+	totalCount, err := db.CountPromotions(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count promotions", nil)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	promotions, err := db.FetchPromotions(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch promotions", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      promotions,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetPromotionByID handles retrieving a single promotion
+func GetPromotionByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	promo, err := db.FetchPromotionByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Promotion not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch promotion", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, promo)
+}
+
+// CreatePromotion handles creating a promotion
+func CreatePromotion(c *gin.Context) {
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := promotion.ValidatePromotion(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	promo, err := db.InsertPromotion(&req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create promotion: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPromotion, promo.ID, models.AuditActionCreate, nil, promo)
+	handlers.SendSuccess(c, http.StatusCreated, promo)
+}
+
+// UpdatePromotion handles replacing a promotion; the body is the full promotion, as on create
+func UpdatePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existingPromotion, err := db.FetchPromotionByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Promotion not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing promotion", nil)
+		}
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := promotion.ValidatePromotion(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	promo, err := db.UpdatePromotion(id, &req, username)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Promotion not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to update promotion: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPromotion, id, models.AuditActionUpdate, existingPromotion, promo)
+	handlers.SendSuccess(c, http.StatusOK, promo)
+}
+
+// DeletePromotion handles soft-deleting a promotion, which stops it from applying
+func DeletePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	// Fetch the record first, the audit log keeps what was deleted
+	existingPromotion, err := db.FetchPromotionByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Promotion not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch existing promotion", nil)
+		}
+		return
+	}
+
+	if err := db.DeletePromotion(id); err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Promotion not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to delete promotion: "+err.Error(), nil)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPromotion, id, models.AuditActionDelete, existingPromotion, nil)
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
@@ -0,0 +1,300 @@
+package adminHandlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/purchase_order"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllPurchaseOrders handles retrieving purchase orders with pagination, search and filtering
+func GetAllPurchaseOrders(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractPurchaseOrderFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountPurchaseOrders(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count purchase orders", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated purchase orders with filters applied
+	orders, err := db.FetchPurchaseOrders(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch purchase orders", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      orders,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetOverduePurchaseOrders handles the report of sent or partially received purchase orders
+// whose expected arrival date is before as_of (default today)
+func GetOverduePurchaseOrders(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	asOfStr := strings.TrimSpace(c.DefaultQuery("as_of", time.Now().Format("2006-01-02")))
+	asOf, err := time.Parse("2006-01-02", asOfStr)
+	if err != nil {
+		errorField := "as_of"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid date format for as_of, use YYYY-MM-DD", &errorField)
+		return
+	}
+
+	// Oldest expected arrival first, the longest overdue orders need chasing first
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "tanggal_kedatangan")
+	sortDirection := c.DefaultQuery("order", "asc")
+
+	filters := helpers.ExtractPurchaseOrderFilters(c)
+	delete(filters, "status")
+	filters["overdue_as_of"] = asOfStr
+
+	totalCount, err := db.CountPurchaseOrders(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count overdue purchase orders", nil)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	orders, err := db.FetchPurchaseOrders(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch overdue purchase orders", nil)
+		return
+	}
+
+	overdue := make([]models.OverduePurchaseOrder, len(orders))
+	for i, po := range orders {
+		overdue[i] = models.OverduePurchaseOrder{
+			PurchaseOrder: po,
+			DaysOverdue:   int(asOf.Sub(po.TanggalKedatangan).Hours() / 24),
+		}
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      overdue,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetPurchaseOrderByID handles retrieving a single purchase order with its items and outstanding quantities
+func GetPurchaseOrderByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	order, err := db.FetchPurchaseOrderByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Purchase order not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch purchase order", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, order)
+}
+
+// CreatePurchaseOrder handles creating a draft purchase order
+func CreatePurchaseOrder(c *gin.Context) {
+	var req models.SavePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := purchase_order.ValidateSaveOrder(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	order, err := db.InsertPurchaseOrder(&req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create purchase order: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPurchaseOrder, order.ID, models.AuditActionCreate, nil, order)
+
+	handlers.SendSuccess(c, http.StatusCreated, order)
+}
+
+// UpdatePurchaseOrder handles editing a draft purchase order
+func UpdatePurchaseOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	var req models.SavePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := purchase_order.ValidateSaveOrder(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existing, err := db.FetchPurchaseOrderByID(id)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to fetch purchase order")
+		return
+	}
+
+	order, err := db.UpdatePurchaseOrder(id, &req)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to update purchase order")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPurchaseOrder, id, models.AuditActionUpdate, existing, order)
+
+	handlers.SendSuccess(c, http.StatusOK, order)
+}
+
+// SendPurchaseOrder handles marking a draft purchase order as sent to the supplier
+func SendPurchaseOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchPurchaseOrderByID(id)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to fetch purchase order")
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	order, err := db.SendPurchaseOrder(id, username)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to send purchase order")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPurchaseOrder, id, models.AuditActionUpdate, existing, order)
+
+	handlers.SendSuccess(c, http.StatusOK, order)
+}
+
+// ClosePurchaseOrder handles closing an open purchase order without waiting for the outstanding quantity
+func ClosePurchaseOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchPurchaseOrderByID(id)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to fetch purchase order")
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	order, err := db.ClosePurchaseOrder(id, username)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to close purchase order")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPurchaseOrder, id, models.AuditActionUpdate, existing, order)
+
+	handlers.SendSuccess(c, http.StatusOK, order)
+}
+
+// CancelPurchaseOrder handles cancelling a purchase order that has not received anything
+func CancelPurchaseOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchPurchaseOrderByID(id)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to fetch purchase order")
+		return
+	}
+
+	order, err := db.CancelPurchaseOrder(id)
+	if err != nil {
+		sendPurchaseOrderError(c, err, "Failed to cancel purchase order")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityPurchaseOrder, id, models.AuditActionUpdate, existing, order)
+
+	handlers.SendSuccess(c, http.StatusOK, order)
+}
+
+// sendPurchaseOrderError maps purchase order errors to the matching HTTP response
+func sendPurchaseOrderError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "not_found":
+		handlers.SendError(c, http.StatusNotFound, "Purchase order not found", nil)
+	case "invalid_status":
+		handlers.SendError(c, http.StatusConflict, "Purchase order is not in a status that allows this action", nil)
+	case "no_items":
+		handlers.SendError(c, http.StatusConflict, "Purchase order has no items", nil)
+	default:
+		handlers.SendError(c, http.StatusInternalServerError, message+": "+err.Error(), nil)
+	}
+}
@@ -0,0 +1,184 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/stock_movement"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllStockMovements handles retrieving the stock ledger with pagination, search and filtering
+func GetAllStockMovements(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractStockMovementFilters(c)
+	if !validateDateFilters(c, filters) {
+		return
+	}
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountStockMovements(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count stock movements", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated movements with filters applied
+	movements, err := db.FetchStockMovements(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock movements", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      movements,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// validateDateFilters sends a bad request and returns false when the date_from or date_to filter is not a YYYY-MM-DD date
+func validateDateFilters(c *gin.Context, filters map[string]string) bool {
+	for _, field := range []string{"date_from", "date_to"} {
+		if value, ok := filters[field]; ok {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				errorField := field
+				handlers.SendError(c, http.StatusBadRequest, "Invalid date format for "+field+", use YYYY-MM-DD", &errorField)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetStockMovementByID handles retrieving a single stock movement
+func GetStockMovementByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	movement, err := db.FetchStockMovementByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Stock movement not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock movement", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, movement)
+}
+
+// CreateStockMovement handles recording a stock movement such as a sale, return or damage write-off
+func CreateStockMovement(c *gin.Context) {
+	var req models.CreateStockMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_movement.ValidateCreateMovement(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	movement := models.StockMovement{
+		ProductNo:  req.ProductNo,
+		ColorID:    req.ColorID,
+		Size:       req.Size,
+		LocationID: req.LocationID,
+		Quantity:   req.Quantity,
+		Reason:     req.Reason,
+		Reference:  strings.TrimSpace(req.Reference),
+		Notes:      strings.TrimSpace(req.Notes),
+		UserID:     userID,
+		Username:   username,
+	}
+
+	if err := db.RecordStockMovements([]*models.StockMovement{&movement}); err != nil {
+		if errors.Is(err, db.ErrInsufficientStock) {
+			errorField := "quantity"
+			handlers.SendError(c, http.StatusConflict, "Insufficient stock: "+err.Error(), &errorField)
+			return
+		}
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to record stock movement: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockMovement, movement.ID, models.AuditActionCreate, nil, movement)
+
+	handlers.SendSuccess(c, http.StatusCreated, movement)
+}
+
+// ReconcileProductStock handles comparing a product's on-hand quantities with its ledger balance
+func ReconcileProductStock(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	if _, err := db.FetchProductByID(id); err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	variants, err := db.ReconcileProductStocks(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to reconcile product stock", nil)
+		return
+	}
+
+	// Report only the variants whose on-hand quantity drifted from the ledger
+	mismatches := []models.StockReconciliation{}
+	for _, v := range variants {
+		if v.Difference != 0 {
+			mismatches = append(mismatches, v)
+		}
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{
+		"product_no": id,
+		"variants":   variants,
+		"mismatches": mismatches,
+		"balanced":   len(mismatches) == 0,
+	})
+}
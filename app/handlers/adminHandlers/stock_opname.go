@@ -0,0 +1,283 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/stock_opname"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllStockOpnames handles retrieving count sessions with pagination, search and filtering
+func GetAllStockOpnames(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractStockOpnameFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountStockOpnames(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count stock opnames", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated sessions with filters applied
+	opnames, err := db.FetchStockOpnames(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock opnames", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      opnames,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetStockOpnameByID handles retrieving a count session with its items and variances.
+// Passing variance_only=true returns only counted items whose count differs from the book quantity.
+func GetStockOpnameByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	opname, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Stock opname not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock opname", nil)
+		}
+		return
+	}
+
+	if helpers.QueryBool(c, "variance_only") {
+		items := []models.StockOpnameItem{}
+		for _, item := range opname.Items {
+			if item.Variance != nil && *item.Variance != 0 {
+				items = append(items, item)
+			}
+		}
+		opname.Items = items
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// CreateStockOpname handles opening a count session for a location
+func CreateStockOpname(c *gin.Context) {
+	var req models.CreateStockOpnameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_opname.ValidateCreateOpname(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	opname, err := db.InsertStockOpname(&req, username)
+	if err != nil {
+		if err.Error() == "empty_scope" {
+			errorField := "filters"
+			handlers.SendError(c, http.StatusBadRequest, "No products match the given filters", &errorField)
+			return
+		}
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create stock opname: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, opname.ID, models.AuditActionCreate, nil, opname)
+
+	handlers.SendSuccess(c, http.StatusCreated, opname)
+}
+
+// SubmitStockOpnameCounts handles recording counted quantities for an open session
+func SubmitStockOpnameCounts(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	var req models.SubmitStockOpnameCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	existing, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to fetch stock opname")
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	opname, err := db.RecordStockOpnameCounts(id, req.Items, username)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to record counts")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, id, models.AuditActionUpdate, existing, opname)
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// SubmitStockOpname handles closing counting on a session so it can be approved
+func SubmitStockOpname(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to fetch stock opname")
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	opname, err := db.SubmitStockOpname(id, username)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to submit stock opname")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, id, models.AuditActionUpdate, existing, opname)
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// ApproveStockOpname handles posting the variances of a submitted session as adjustment movements
+func ApproveStockOpname(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to fetch stock opname")
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	opname, err := db.ApproveStockOpname(id, userID, username)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to approve stock opname")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, id, models.AuditActionUpdate, existing, opname)
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// ReopenStockOpname handles sending a submitted session back to counting
+func ReopenStockOpname(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to fetch stock opname")
+		return
+	}
+
+	opname, err := db.ReopenStockOpname(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to reopen stock opname")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, id, models.AuditActionUpdate, existing, opname)
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// CancelStockOpname handles abandoning a session without touching stock
+func CancelStockOpname(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockOpnameByID(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to fetch stock opname")
+		return
+	}
+
+	opname, err := db.CancelStockOpname(id)
+	if err != nil {
+		sendStockOpnameError(c, err, "Failed to cancel stock opname")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockOpname, id, models.AuditActionUpdate, existing, opname)
+
+	handlers.SendSuccess(c, http.StatusOK, opname)
+}
+
+// sendStockOpnameError maps stock opname errors to the matching HTTP response
+func sendStockOpnameError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "not_found":
+		handlers.SendError(c, http.StatusNotFound, "Stock opname not found", nil)
+	case err.Error() == "invalid_status":
+		handlers.SendError(c, http.StatusConflict, "Stock opname is not in a status that allows this action", nil)
+	case err.Error() == "nothing_counted":
+		handlers.SendError(c, http.StatusConflict, "No items have been counted yet", nil)
+	case errors.Is(err, db.ErrOpnameItemNotInScope):
+		errorField := "items"
+		handlers.SendError(c, http.StatusBadRequest, "Variant is not part of this stock opname: "+err.Error(), &errorField)
+	case errors.Is(err, db.ErrInsufficientStock):
+		errorField := "items"
+		handlers.SendError(c, http.StatusConflict, "Insufficient stock: "+err.Error(), &errorField)
+	default:
+		handlers.SendError(c, http.StatusInternalServerError, message+": "+err.Error(), nil)
+	}
+}
@@ -0,0 +1,177 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/stock_reservation"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllStockReservations handles retrieving stock reservations with pagination, search and filtering
+func GetAllStockReservations(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractStockReservationFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountStockReservations(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count stock reservations", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated reservations with filters applied
+	reservations, err := db.FetchStockReservations(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock reservations", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      reservations,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetStockReservationByID handles retrieving a single stock reservation
+func GetStockReservationByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	reservation, err := db.FetchStockReservationByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Stock reservation not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock reservation", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, reservation)
+}
+
+// CreateStockReservation handles holding stock of a variant for a customer order
+func CreateStockReservation(c *gin.Context) {
+	var req models.CreateStockReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_reservation.ValidateCreateReservation(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	reservation, err := db.InsertStockReservation(&req, username)
+	if err != nil {
+		sendStockReservationError(c, err, "Failed to create stock reservation")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockReservation, reservation.ID, models.AuditActionCreate, nil, reservation)
+
+	handlers.SendSuccess(c, http.StatusCreated, reservation)
+}
+
+// ReleaseStockReservation handles releasing an active reservation before it expires
+func ReleaseStockReservation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockReservationByID(id)
+	if err != nil {
+		sendStockReservationError(c, err, "Failed to fetch stock reservation")
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	reservation, err := db.ReleaseStockReservation(id, username)
+	if err != nil {
+		sendStockReservationError(c, err, "Failed to release stock reservation")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockReservation, id, models.AuditActionUpdate, existing, reservation)
+
+	handlers.SendSuccess(c, http.StatusOK, reservation)
+}
+
+// FulfillStockReservation handles shipping an active reservation as a sale
+func FulfillStockReservation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockReservationByID(id)
+	if err != nil {
+		sendStockReservationError(c, err, "Failed to fetch stock reservation")
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	reservation, err := db.FulfillStockReservation(id, userID, username)
+	if err != nil {
+		sendStockReservationError(c, err, "Failed to fulfill stock reservation")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockReservation, id, models.AuditActionUpdate, existing, reservation)
+
+	handlers.SendSuccess(c, http.StatusOK, reservation)
+}
+
+// sendStockReservationError maps stock reservation errors to the matching HTTP response
+func sendStockReservationError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "not_found":
+		handlers.SendError(c, http.StatusNotFound, "Stock reservation not found", nil)
+	case err.Error() == "invalid_status":
+		handlers.SendError(c, http.StatusConflict, "Stock reservation is no longer active", nil)
+	case errors.Is(err, db.ErrInsufficientStock):
+		errorField := "quantity"
+		handlers.SendError(c, http.StatusConflict, "Insufficient stock: "+err.Error(), &errorField)
+	default:
+		handlers.SendError(c, http.StatusInternalServerError, message+": "+err.Error(), nil)
+	}
+}
@@ -0,0 +1,172 @@
+package adminHandlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/stock_threshold"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductStockThresholds handles retrieving the minimum stock and reorder quantities of a product
+func GetProductStockThresholds(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	if _, err := db.FetchProductByID(id); err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	thresholds, err := db.FetchProductStockThresholds(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock thresholds", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, thresholds)
+}
+
+// UpdateProductStockThresholds handles replacing the product default and variant thresholds of a product
+func UpdateProductStockThresholds(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	product, err := db.FetchProductByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch product", nil)
+		}
+		return
+	}
+
+	var req models.SetStockThresholdsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_threshold.ValidateSetThresholds(&product, &req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existing, err := db.FetchProductStockThresholds(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock thresholds", nil)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	thresholds, err := db.SetProductStockThresholds(id, &req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to update stock thresholds: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockThreshold, id, models.AuditActionUpdate, existing, thresholds)
+
+	handlers.SendSuccess(c, http.StatusOK, thresholds)
+}
+
+// GetLowStockVariants handles listing every variant below its minimum stock, filterable by kat, grup and supplier
+func GetLowStockVariants(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Extract filter parameters
+	filters := helpers.ExtractLowStockFilters(c)
+
+	variants, err := db.FetchLowStockVariants(filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch low stock variants", nil)
+		return
+	}
+
+	// Variants are derived per product, so the page is cut from the full list
+	totalCount := len(variants)
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+	end := offset + limit
+	if offset > totalCount {
+		offset = totalCount
+	}
+	if end > totalCount {
+		end = totalCount
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      variants[offset:end],
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+	})
+}
+
+// GetAllStockAlerts handles retrieving the alerts raised by the low stock checker with pagination and filtering
+func GetAllStockAlerts(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractStockAlertFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountStockAlerts(filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count stock alerts", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated alerts with filters applied
+	alerts, err := db.FetchStockAlerts(limit, offset, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock alerts", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      alerts,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
@@ -0,0 +1,242 @@
+package adminHandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation/stock_transfer"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllStockTransfers handles retrieving stock transfers with pagination, search and filtering
+func GetAllStockTransfers(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Get query params
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "created_at")
+	sortDirection := c.DefaultQuery("order", "desc")
+
+	// Extract filter parameters
+	filters := helpers.ExtractStockTransferFilters(c)
+
+	// Fetch total count with filters applied
+	totalCount, err := db.CountStockTransfers(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count stock transfers", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	// Fetch paginated transfers with filters applied
+	transfers, err := db.FetchStockTransfers(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock transfers", nil)
+		return
+	}
+
+	// Respond with pagination metadata
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      transfers,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetStockTransferByID handles retrieving a single stock transfer with its items
+func GetStockTransferByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	transfer, err := db.FetchStockTransferByID(id)
+	if err != nil {
+		if err.Error() == "not_found" {
+			handlers.SendError(c, http.StatusNotFound, "Stock transfer not found", nil)
+		} else {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch stock transfer", nil)
+		}
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, transfer)
+}
+
+// CreateStockTransfer handles creating a draft stock transfer
+func CreateStockTransfer(c *gin.Context) {
+	var req models.SaveStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_transfer.ValidateSaveTransfer(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	_, username := helpers.CurrentUser(c)
+	transfer, err := db.InsertStockTransfer(&req, username)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create stock transfer: "+err.Error(), nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockTransfer, transfer.ID, models.AuditActionCreate, nil, transfer)
+
+	handlers.SendSuccess(c, http.StatusCreated, transfer)
+}
+
+// UpdateStockTransfer handles editing a draft stock transfer
+func UpdateStockTransfer(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	var req models.SaveStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if validationErr := stock_transfer.ValidateSaveTransfer(&req); validationErr != nil {
+		handlers.SendError(c, http.StatusBadRequest, validationErr.Error, &validationErr.ErrorField)
+		return
+	}
+
+	existing, err := db.FetchStockTransferByID(id)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to fetch stock transfer")
+		return
+	}
+
+	transfer, err := db.UpdateStockTransfer(id, &req)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to update stock transfer")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockTransfer, id, models.AuditActionUpdate, existing, transfer)
+
+	handlers.SendSuccess(c, http.StatusOK, transfer)
+}
+
+// ShipStockTransfer handles taking the items of a draft transfer out of the source location
+func ShipStockTransfer(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockTransferByID(id)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to fetch stock transfer")
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	transfer, err := db.ShipStockTransfer(id, userID, username)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to ship stock transfer")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockTransfer, id, models.AuditActionUpdate, existing, transfer)
+
+	handlers.SendSuccess(c, http.StatusOK, transfer)
+}
+
+// ReceiveStockTransfer handles putting the items of a shipped transfer into the destination location
+func ReceiveStockTransfer(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockTransferByID(id)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to fetch stock transfer")
+		return
+	}
+
+	userID, username := helpers.CurrentUser(c)
+	transfer, err := db.ReceiveStockTransfer(id, userID, username)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to receive stock transfer")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockTransfer, id, models.AuditActionUpdate, existing, transfer)
+
+	handlers.SendSuccess(c, http.StatusOK, transfer)
+}
+
+// CancelStockTransfer handles cancelling a draft stock transfer
+func CancelStockTransfer(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid ID format", nil)
+		return
+	}
+
+	existing, err := db.FetchStockTransferByID(id)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to fetch stock transfer")
+		return
+	}
+
+	transfer, err := db.CancelStockTransfer(id)
+	if err != nil {
+		sendStockTransferError(c, err, "Failed to cancel stock transfer")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityStockTransfer, id, models.AuditActionUpdate, existing, transfer)
+
+	handlers.SendSuccess(c, http.StatusOK, transfer)
+}
+
+// sendStockTransferError maps stock transfer errors to the matching HTTP response
+func sendStockTransferError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "not_found":
+		handlers.SendError(c, http.StatusNotFound, "Stock transfer not found", nil)
+	case err.Error() == "invalid_status":
+		handlers.SendError(c, http.StatusConflict, "Stock transfer is not in a status that allows this action", nil)
+	case err.Error() == "no_items":
+		handlers.SendError(c, http.StatusConflict, "Stock transfer has no items", nil)
+	case errors.Is(err, db.ErrInsufficientStock):
+		errorField := "items"
+		handlers.SendError(c, http.StatusConflict, "Insufficient stock: "+err.Error(), &errorField)
+	default:
+		handlers.SendError(c, http.StatusInternalServerError, message+": "+err.Error(), nil)
+	}
+}
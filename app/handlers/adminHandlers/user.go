@@ -0,0 +1,386 @@
+package adminHandlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetRoles handles listing the assignable roles and the permissions each one grants
+func GetRoles(c *gin.Context) {
+	roles := []models.Role{}
+	for _, role := range auth.Roles() {
+		roles = append(roles, models.Role{Role: role, Permissions: auth.RolePermissions(role)})
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, roles)
+}
+
+// UpdateUserRole handles assigning a role to a user. A changed role ends the user's sessions and
+// access tokens, so the new role applies from the next login.
+func UpdateUserRole(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	req.Role = strings.TrimSpace(req.Role)
+	if !auth.IsValidRole(req.Role) {
+		errorField := "role"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid role, use one of: "+strings.Join(auth.Roles(), ", "), &errorField)
+		return
+	}
+
+	// An admin demoting themselves could leave nobody able to assign roles
+	if userID, _ := helpers.CurrentUser(c); userID == id && req.Role != auth.RoleAdmin {
+		errorField := "role"
+		handlers.SendError(c, http.StatusBadRequest, "You cannot remove your own admin role", &errorField)
+		return
+	}
+
+	current, err := db.GetUserByID(id)
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+	if current.Role == auth.RoleAdmin && current.Active && req.Role != auth.RoleAdmin && !ensureAnotherAdmin(c) {
+		return
+	}
+
+	user, err := db.UpdateUserRole(id, req.Role)
+	if err != nil {
+		sendUserError(c, err, "Failed to update user role")
+		return
+	}
+
+	// Tokens issued before carry the old role and its permissions
+	if current.Role != req.Role {
+		if _, err := db.RevokeUserSessions(id); err != nil {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke user sessions", nil)
+			return
+		}
+	}
+
+	recordAudit(c, models.AuditEntityUser, id, models.AuditActionUpdate, current, user)
+
+	handlers.SendSuccess(c, http.StatusOK, user)
+}
+
+// GetAllUsers handles listing users with pagination, search on username and email, and role/active filters
+func GetAllUsers(c *gin.Context) {
+	// Parse pagination parameters
+	limit, offset, page, err := helpers.ParsePaginationParams(c)
+	if err != nil {
+		handlers.SendError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Extract search, sort and filter parameters
+	queryStr := c.DefaultQuery("q", "")
+	sortColumn := c.DefaultQuery("sort", "username")
+	sortDirection := c.DefaultQuery("order", "asc")
+	filters := helpers.ExtractUserFilters(c)
+
+	totalCount, err := db.CountUsers(queryStr, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count users", nil)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	users, err := db.FetchUsers(limit, offset, queryStr, filters, sortColumn, sortDirection)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch users", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
+		Items:      users,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Filters:    filters,
+		Sort:       sortColumn,
+		Order:      sortDirection,
+	})
+}
+
+// GetUserByID handles fetching a single user
+func GetUserByID(c *gin.Context) {
+	user, err := db.GetUserByID(c.Param("id"))
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, user)
+}
+
+// InviteUser handles an admin creating an account. The account gets a temporary password
+// that is returned once in the response.
+func InviteUser(c *gin.Context) {
+	var req models.InviteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.SendError(c, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	req.Role = strings.TrimSpace(req.Role)
+	if req.Username == "" {
+		errorField := "username"
+		handlers.SendError(c, http.StatusBadRequest, "Username is required", &errorField)
+		return
+	}
+	if !auth.IsValidRole(req.Role) {
+		errorField := "role"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid role, use one of: "+strings.Join(auth.Roles(), ", "), &errorField)
+		return
+	}
+
+	exists, err := db.UserExists(req.Username, req.Email)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to check existing users", nil)
+		return
+	}
+	if exists {
+		handlers.SendError(c, http.StatusConflict, "A user with this username or email already exists", nil)
+		return
+	}
+
+	password, err := auth.GenerateTemporaryPassword()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to generate password", nil)
+		return
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to hash password", nil)
+		return
+	}
+
+	user := auth.User{
+		ID:       uuid.New().String(),
+		Username: req.Username,
+		Email:    req.Email,
+		Password: hashedPassword,
+		Role:     req.Role,
+		Active:   true,
+	}
+	if err := db.CreateUser(user); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to create user", nil)
+		return
+	}
+
+	if err := helpers.SendEmailVerification(user); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to send verification email", nil)
+		return
+	}
+
+	created, err := db.GetUserByID(user.ID)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch created user", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUser, created.ID, models.AuditActionCreate, nil, created)
+
+	handlers.SendSuccess(c, http.StatusCreated, models.UserCredentials{User: created, TemporaryPassword: password})
+}
+
+// DeactivateUser handles blocking a user from logging in
+func DeactivateUser(c *gin.Context) {
+	setUserActive(c, false)
+}
+
+// ActivateUser handles allowing a deactivated user to log in again
+func ActivateUser(c *gin.Context) {
+	setUserActive(c, true)
+}
+
+// setUserActive activates or deactivates the user in the route, refusing to lock out the last admin
+func setUserActive(c *gin.Context, active bool) {
+	id := c.Param("id")
+
+	user, err := db.GetUserByID(id)
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	if !active {
+		if userID, _ := helpers.CurrentUser(c); userID == id {
+			handlers.SendError(c, http.StatusBadRequest, "You cannot deactivate your own account", nil)
+			return
+		}
+		if user.Role == auth.RoleAdmin && user.Active && !ensureAnotherAdmin(c) {
+			return
+		}
+	}
+
+	if err := db.SetUserActive(id, active); err != nil {
+		sendUserError(c, err, "Failed to update user")
+		return
+	}
+
+	// A deactivated user must not keep working with tokens issued before
+	if !active {
+		if _, err := db.RevokeUserSessions(id); err != nil {
+			handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke user sessions", nil)
+			return
+		}
+	}
+
+	before := user
+	user.Active = active
+	recordAudit(c, models.AuditEntityUser, id, models.AuditActionUpdate, before, user)
+
+	handlers.SendSuccess(c, http.StatusOK, user)
+}
+
+// ResetUserPassword handles replacing a user's password with a new temporary password,
+// returned once in the response
+func ResetUserPassword(c *gin.Context) {
+	id := c.Param("id")
+
+	user, err := db.GetUserByID(id)
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	password, err := auth.GenerateTemporaryPassword()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to generate password", nil)
+		return
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to hash password", nil)
+		return
+	}
+
+	if err := db.UpdateUserPassword(id, hashedPassword); err != nil {
+		sendUserError(c, err, "Failed to reset password")
+		return
+	}
+
+	// Sessions started with the old password end with it
+	if _, err := db.RevokeUserSessions(id); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke user sessions", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUser, id, models.AuditActionResetPassword, nil, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, models.UserCredentials{User: user, TemporaryPassword: password})
+}
+
+// RevokeUserSessions handles ending every session of a user. Their refresh tokens stop working
+// and access tokens issued with them are rejected until they expire.
+func RevokeUserSessions(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := db.GetUserByID(id); err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	sessions, err := db.RevokeUserSessions(id)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke user sessions", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUser, id, models.AuditActionRevoke, nil, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, gin.H{"revoked_sessions": sessions})
+}
+
+// UnlockUser handles clearing the failed login attempts of a user, lifting a login lockout
+func UnlockUser(c *gin.Context) {
+	user, err := db.GetUserByID(c.Param("id"))
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	if err := db.ClearLoginFailures(db.LoginThrottleUserKey(user.Username)); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to unlock user", nil)
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUser, user.ID, models.AuditActionUnlock, nil, nil)
+
+	handlers.SendSuccess(c, http.StatusOK, user)
+}
+
+// ensureAnotherAdmin sends a conflict and returns false when removing an admin would leave no active admin
+func ensureAnotherAdmin(c *gin.Context) bool {
+	admins, err := db.CountActiveAdmins()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to count admins", nil)
+		return false
+	}
+	if admins <= 1 {
+		handlers.SendError(c, http.StatusConflict, "At least one active admin is required", nil)
+		return false
+	}
+	return true
+}
+
+// sendUserError maps user lookup and update errors to responses
+func sendUserError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, sql.ErrNoRows) || err.Error() == "not_found" {
+		handlers.SendError(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	handlers.SendError(c, http.StatusInternalServerError, fallback, nil)
+}
+
+// ResetUserTwoFactor handles turning off two-factor authentication for a user who lost their
+// authenticator and recovery codes. Their sessions end and they enroll again after logging in.
+func ResetUserTwoFactor(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := db.GetUserByID(id)
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	if err := db.DisableTOTP(id); err != nil {
+		sendUserError(c, err, "Failed to reset two-factor authentication")
+		return
+	}
+
+	if _, err := db.RevokeUserSessions(id); err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to revoke user sessions", nil)
+		return
+	}
+
+	user, err := db.GetUserByID(id)
+	if err != nil {
+		sendUserError(c, err, "Failed to fetch user")
+		return
+	}
+
+	recordAudit(c, models.AuditEntityUser, id, models.AuditActionUpdate, existing, user)
+
+	handlers.SendSuccess(c, http.StatusOK, user)
+}
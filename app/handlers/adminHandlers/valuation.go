@@ -0,0 +1,53 @@
+package adminHandlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/handlers"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/gin-gonic/gin"
+)
+
+// GetInventoryValuation handles the inventory valuation report at the end of as_of (default today),
+// grouped by product, kat or location. The method defaults to the configured valuation method.
+func GetInventoryValuation(c *gin.Context) {
+	asOf := strings.TrimSpace(c.DefaultQuery("as_of", time.Now().Format("2006-01-02")))
+	if _, err := time.Parse("2006-01-02", asOf); err != nil {
+		errorField := "as_of"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid date format for as_of, use YYYY-MM-DD", &errorField)
+		return
+	}
+
+	method := settings.Current().Valuation.Method
+	if method == "" {
+		method = models.ValuationMethodFIFO
+	}
+	method = strings.ToLower(strings.TrimSpace(c.DefaultQuery("method", method)))
+	if method != models.ValuationMethodFIFO && method != models.ValuationMethodAverage {
+		errorField := "method"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid method, use fifo or average", &errorField)
+		return
+	}
+
+	groupBy := strings.ToLower(strings.TrimSpace(c.DefaultQuery("group_by", models.ValuationGroupProduct)))
+	if groupBy != models.ValuationGroupProduct && groupBy != models.ValuationGroupKat && groupBy != models.ValuationGroupLocation {
+		errorField := "group_by"
+		handlers.SendError(c, http.StatusBadRequest, "Invalid group_by, use product, kat or location", &errorField)
+		return
+	}
+
+	filters := helpers.ExtractValuationFilters(c)
+
+	valuation, err := db.FetchInventoryValuation(asOf, method, groupBy, filters)
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to compute inventory valuation", nil)
+		return
+	}
+
+	handlers.SendSuccess(c, http.StatusOK, valuation)
+}
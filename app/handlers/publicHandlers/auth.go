@@ -1,18 +1,33 @@
 package publicHandlers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
 	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // RegisterHandler converts to Gin handler
 func RegisterHandler(c *gin.Context) {
+	// Accounts are created by admins unless self-registration is enabled in the config
+	if !settings.Current().Auth.AllowRegistration {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Registration is disabled",
+		})
+		return
+	}
+
 	var registerRequest struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -68,7 +83,8 @@ func RegisterHandler(c *gin.Context) {
 		Username: registerRequest.Username,
 		Password: hashedPassword,
 		Email:    registerRequest.Email,
-		Role:     "user", // Default role
+		Role:     auth.RoleUser, // Default role
+		Active:   true,
 	}
 
 	if err := db.CreateUser(user); err != nil {
@@ -79,8 +95,12 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, expiresAt, err := auth.GenerateToken(user)
+	if err := helpers.SendEmailVerification(user); err != nil {
+		log.Printf("Error sending verification email: %v", err)
+	}
+
+	// Generate tokens
+	session, err := startSession(c, user, "", false)
 	if err != nil {
 		log.Printf("Error generating token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -89,11 +109,7 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"token":      token,
-		"expires_at": expiresAt,
-		"user":       user,
-	})
+	c.JSON(http.StatusCreated, session)
 }
 
 // LoginHandler converts to Gin handler
@@ -119,10 +135,32 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// Throttled usernames and IPs are turned away before any password hashing
+	lockedUntil, err := db.FetchLoginLockout([]string{db.LoginThrottleUserKey(loginRequest.Username), db.LoginThrottleIPKey(c.ClientIP())})
+	if err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	if !lockedUntil.IsZero() {
+		retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+		recordLoginEvent(c, loginRequest.Username, nil, models.LoginResultLocked)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed login attempts, try again later",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
 	// Get user from database
 	user, err := db.GetUserByUsername(loginRequest.Username)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
+		recordLoginFailure(c, loginRequest.Username)
+		recordLoginEvent(c, loginRequest.Username, nil, models.LoginResultInvalidCredentials)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
@@ -131,14 +169,43 @@ func LoginHandler(c *gin.Context) {
 
 	// Verify password
 	if !auth.VerifyPassword(user.Password, loginRequest.Password) {
+		recordLoginFailure(c, loginRequest.Username)
+		recordLoginEvent(c, loginRequest.Username, &user.ID, models.LoginResultInvalidCredentials)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
 		return
 	}
 
-	// Generate token
-	token, expiresAt, err := auth.GenerateToken(user)
+	if !user.Active {
+		recordLoginEvent(c, loginRequest.Username, &user.ID, models.LoginResultInactive)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Account is deactivated",
+		})
+		return
+	}
+
+	// Accounts with two-factor authentication get their tokens from the second step
+	if user.TwoFactor {
+		challenge, err := startTwoFactorChallenge(user)
+		if err != nil {
+			log.Printf("Error creating two-factor challenge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	completeLogin(c, user, loginRequest.Username, false)
+}
+
+// completeLogin starts a session once every required factor has been checked, clears the
+// username throttle and records the successful login
+func completeLogin(c *gin.Context, user auth.User, username string, twoFactor bool) {
+	session, err := startSession(c, user, "", twoFactor)
 	if err != nil {
 		log.Printf("Error generating token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -147,41 +214,139 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":      token,
-		"expires_at": expiresAt,
-		"user":       user,
-	})
+	// The IP keeps its failures, otherwise one valid account would let an attacker reset the IP backoff
+	if err := db.ClearLoginFailures(db.LoginThrottleUserKey(username)); err != nil {
+		log.Printf("Error clearing login failures: %v", err)
+	}
+	recordLoginEvent(c, username, &user.ID, models.LoginResultSuccess)
+
+	c.JSON(http.StatusOK, session)
 }
 
-// Optional: Helper middleware for authentication
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "No token provided",
+// RefreshHandler exchanges a refresh token for a new access token and a new refresh token.
+// The presented refresh token stops working; presenting it again ends the session.
+func RefreshHandler(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Refresh token is required",
+		})
+		return
+	}
+
+	tokenHash := auth.HashToken(strings.TrimSpace(req.RefreshToken))
+	current, err := db.FetchRefreshTokenByHash(tokenHash)
+	if err != nil {
+		if !errors.Is(err, db.ErrRefreshTokenInvalid) {
+			log.Printf("Error fetching refresh token: %v", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	user, err := db.GetUserByID(current.UserID)
+	if err != nil || !user.Active {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	session, err := startSession(c, user, tokenHash, current.TwoFactor)
+	if err != nil {
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			log.Printf("Refresh token reuse detected for user %s, session revoked", user.Username)
+		} else if !errors.Is(err, db.ErrRefreshTokenInvalid) {
+			log.Printf("Error refreshing token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to refresh token",
 			})
 			return
 		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
 
-		// Remove 'Bearer ' prefix if present
-		token = strings.TrimPrefix(token, "Bearer ")
+// LogoutHandler ends the session of the refresh token in the body and revokes the access token
+// the request was made with, if any
+func LogoutHandler(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	_ = c.ShouldBindJSON(&req)
 
-		claims, err := auth.ValidateToken(token)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
+	if refreshToken := strings.TrimSpace(req.RefreshToken); refreshToken != "" {
+		if err := db.RevokeRefreshTokenFamily(auth.HashToken(refreshToken)); err != nil {
+			log.Printf("Error revoking refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to log out",
 			})
 			return
 		}
+	}
+
+	if token := auth.ExtractTokenFromGinContext(c); token != "" {
+		if claims, err := auth.ValidateToken(token); err == nil {
+			if err := db.RevokeAccessToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+				log.Printf("Error revoking access token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to log out",
+				})
+				return
+			}
+		}
+	}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out",
+	})
+}
 
-		c.Next()
+// startSession issues an access token and a refresh token for the user. With previousHash it
+// rotates that refresh token within its session, otherwise it starts a new session. twoFactor
+// records that the session was verified with a second factor.
+func startSession(c *gin.Context, user auth.User, previousHash string, twoFactor bool) (auth.TokenResponse, error) {
+	token, claims, err := auth.GenerateToken(user, twoFactor)
+	if err != nil {
+		return auth.TokenResponse{}, err
 	}
+
+	refreshToken, refreshExpiresAt, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return auth.TokenResponse{}, err
+	}
+
+	rt := models.RefreshToken{
+		ID:              uuid.New().String(),
+		UserID:          user.ID,
+		FamilyID:        uuid.New().String(),
+		TokenHash:       auth.HashToken(refreshToken),
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       refreshExpiresAt,
+		UserAgent:       c.Request.UserAgent(),
+		IPAddress:       c.ClientIP(),
+		TwoFactor:       twoFactor,
+	}
+	if previousHash == "" {
+		err = db.InsertRefreshToken(rt)
+	} else {
+		err = db.RotateRefreshToken(previousHash, rt)
+	}
+	if err != nil {
+		return auth.TokenResponse{}, err
+	}
+
+	return auth.TokenResponse{
+		Token:            token,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
 }
@@ -0,0 +1,114 @@
+package publicHandlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults for the login throttle settings
+const (
+	defaultLoginUserAttempts = 5
+	defaultLoginIPAttempts   = 20
+	defaultLoginMaxLockout   = 15 * time.Minute
+)
+
+// loginThrottleSettings returns the free attempts per username and per IP, and the longest lockout
+func loginThrottleSettings() (userAttempts, ipAttempts int, maxLockout time.Duration) {
+	config := settings.Current().Auth
+
+	userAttempts, ipAttempts, maxLockout = config.LoginUserAttempts, config.LoginIPAttempts, defaultLoginMaxLockout
+	if userAttempts <= 0 {
+		userAttempts = defaultLoginUserAttempts
+	}
+	if ipAttempts <= 0 {
+		ipAttempts = defaultLoginIPAttempts
+	}
+	if config.LoginMaxLockout != "" {
+		if parsed, err := time.ParseDuration(config.LoginMaxLockout); err == nil && parsed > 0 {
+			maxLockout = parsed
+		} else {
+			log.Printf("Invalid auth login-max-lockout %q, using %s", config.LoginMaxLockout, defaultLoginMaxLockout)
+		}
+	}
+	return userAttempts, ipAttempts, maxLockout
+}
+
+// recordLoginFailure counts a failed login against both the username and the client IP
+func recordLoginFailure(c *gin.Context, username string) {
+	userAttempts, ipAttempts, maxLockout := loginThrottleSettings()
+	if err := db.RecordLoginFailure(db.LoginThrottleUserKey(username), userAttempts, maxLockout); err != nil {
+		log.Printf("Error recording login failure: %v", err)
+	}
+	if err := db.RecordLoginFailure(db.LoginThrottleIPKey(c.ClientIP()), ipAttempts, maxLockout); err != nil {
+		log.Printf("Error recording login failure: %v", err)
+	}
+}
+
+// accountThrottleKeys returns the throttle keys of the client IP and, when given, the email address
+func accountThrottleKeys(c *gin.Context, email string) []string {
+	keys := []string{db.LoginThrottleIPKey(c.ClientIP())}
+	if strings.TrimSpace(email) != "" {
+		keys = append(keys, db.LoginThrottleEmailKey(email))
+	}
+	return keys
+}
+
+// checkAccountThrottle responds and returns false when the client IP or email is locked out, so
+// password reset and verification requests cannot be used to flood inboxes or guess tokens
+func checkAccountThrottle(c *gin.Context, email string) bool {
+	lockedUntil, err := db.FetchLoginLockout(accountThrottleKeys(c, email))
+	if err != nil {
+		log.Printf("Error checking account request throttle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return false
+	}
+	if !lockedUntil.IsZero() {
+		retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many requests, try again later",
+			"retry_after": retryAfter,
+		})
+		return false
+	}
+	return true
+}
+
+// recordAccountRequest counts a password reset or verification request against the client IP and,
+// when given, the email address, with the same free attempts and backoff as failed logins
+func recordAccountRequest(c *gin.Context, email string) {
+	userAttempts, ipAttempts, maxLockout := loginThrottleSettings()
+	if err := db.RecordLoginFailure(db.LoginThrottleIPKey(c.ClientIP()), ipAttempts, maxLockout); err != nil {
+		log.Printf("Error recording account request: %v", err)
+	}
+	if strings.TrimSpace(email) != "" {
+		if err := db.RecordLoginFailure(db.LoginThrottleEmailKey(email), userAttempts, maxLockout); err != nil {
+			log.Printf("Error recording account request: %v", err)
+		}
+	}
+}
+
+// recordLoginEvent writes the login audit record of the request
+func recordLoginEvent(c *gin.Context, username string, userID *string, result string) {
+	if err := db.InsertLoginEvent(models.LoginEvent{
+		Username:  strings.TrimSpace(username),
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Success:   result == models.LoginResultSuccess,
+		Result:    result,
+	}); err != nil {
+		log.Printf("Error recording login event: %v", err)
+	}
+}
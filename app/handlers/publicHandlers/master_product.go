@@ -7,6 +7,8 @@ import (
 
 	"github.com/everysoft/inventary-be/app/handlers"
 	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/promotions"
 	"github.com/everysoft/inventary-be/db"
 	"github.com/gin-gonic/gin"
 )
@@ -25,8 +27,9 @@ func GetAllProducts(c *gin.Context) {
 	sortColumn := c.DefaultQuery("sort", "no")
 	sortDirection := c.DefaultQuery("order", "asc")
 
-	// Extract filter parameters
+	// Extract filter parameters, only published products are public
 	filters := helpers.ExtractFilters(c)
+	filters["lifecycle_status"] = models.ProductLifecyclePublished
 
 	// Extract marketplace and offline filters using helper that understands multiple truthy values
 	isMarketplaceFilter := helpers.QueryBool(c, "online")
@@ -49,6 +52,14 @@ func GetAllProducts(c *gin.Context) {
 		return
 	}
 
+	// Work out the effective prices from the running promotions
+	running, err := db.FetchRunningPromotions()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch promotions", nil)
+		return
+	}
+	promotions.ApplyAll(products, running)
+
 	// Respond with pagination metadata
 	handlers.SendSuccess(c, http.StatusOK, handlers.PaginatedData{
 		Items:      products,
@@ -82,5 +93,18 @@ func GetProductByID(c *gin.Context) {
 		return
 	}
 
+	// Unpublished products are not public
+	if product.LifecycleStatus != models.ProductLifecyclePublished {
+		handlers.SendError(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	running, err := db.FetchRunningPromotions()
+	if err != nil {
+		handlers.SendError(c, http.StatusInternalServerError, "Failed to fetch promotions", nil)
+		return
+	}
+	promotions.Apply(&product, running)
+
 	handlers.SendSuccess(c, http.StatusOK, product)
 }
@@ -0,0 +1,146 @@
+package publicHandlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordHandler emails a password reset link to an active user. The response is the same
+// whether or not the address belongs to a user, so it cannot be used to find accounts.
+func ForgotPasswordHandler(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A valid email is required",
+		})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if !checkAccountThrottle(c, email) {
+		return
+	}
+	recordAccountRequest(c, email)
+
+	user, err := db.GetUserByEmail(email)
+	if err == nil && user.Active {
+		if err := helpers.SendPasswordResetEmail(user); err != nil {
+			log.Printf("Error sending password reset email: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If the email belongs to an account, a password reset link has been sent",
+	})
+}
+
+// ResetPasswordHandler sets a new password with a reset token. The token works once, and every
+// existing session of the user is ended.
+func ResetPasswordHandler(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token and a password of at least 8 characters are required",
+		})
+		return
+	}
+
+	// Reset tokens carry no email, so invalid tokens are throttled by client IP
+	if !checkAccountThrottle(c, "") {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	if _, err := db.ResetPasswordWithToken(auth.HashToken(strings.TrimSpace(req.Token)), hashedPassword); err != nil {
+		if errors.Is(err, db.ErrUserTokenInvalid) {
+			recordAccountRequest(c, "")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid or expired reset token",
+			})
+			return
+		}
+		log.Printf("Error resetting password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password has been reset, please log in again",
+	})
+}
+
+// VerifyEmailHandler confirms the email address of the user a verification token was sent to
+func VerifyEmailHandler(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token is required",
+		})
+		return
+	}
+
+	if _, err := db.VerifyEmailWithToken(auth.HashToken(strings.TrimSpace(req.Token))); err != nil {
+		if errors.Is(err, db.ErrUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid or expired verification token",
+			})
+			return
+		}
+		log.Printf("Error verifying email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email address verified",
+	})
+}
+
+// ResendVerificationHandler emails a new verification link to an unverified user, with the same
+// response whether or not the address belongs to a user
+func ResendVerificationHandler(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A valid email is required",
+		})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if !checkAccountThrottle(c, email) {
+		return
+	}
+	recordAccountRequest(c, email)
+
+	user, err := db.GetUserByEmail(email)
+	if err == nil && user.Active && user.EmailVerifiedAt == nil {
+		if err := helpers.SendEmailVerification(user); err != nil {
+			log.Printf("Error sending verification email: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If the email belongs to an unverified account, a verification link has been sent",
+	})
+}
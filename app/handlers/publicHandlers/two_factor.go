@@ -0,0 +1,354 @@
+package publicHandlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// mfaChallengeLifetime is how long the second login step can be completed after the password step
+const mfaChallengeLifetime = 5 * time.Minute
+
+// recoveryCodeCount is how many recovery codes a user gets when enabling two-factor authentication
+const recoveryCodeCount = 10
+
+// startTwoFactorChallenge creates the login challenge the second step exchanges for tokens
+func startTwoFactorChallenge(user auth.User) (models.TwoFactorChallenge, error) {
+	token, err := auth.GenerateRandomToken()
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+
+	expiresAt := time.Now().Add(mfaChallengeLifetime)
+	if err := db.CreateMFAChallenge(user.ID, auth.HashToken(token), expiresAt); err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+
+	return models.TwoFactorChallenge{TwoFactorRequired: true, MFAToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// verifySecondFactor checks a TOTP code, or an unused recovery code when allowRecovery is set.
+// Codes are used up by a successful check.
+func verifySecondFactor(userID, code string, allowRecovery bool) (bool, error) {
+	secret, enabled, err := db.FetchUserTOTP(userID)
+	if err != nil || secret == "" {
+		return false, err
+	}
+
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now()); ok {
+		return db.UseTOTPStep(userID, step)
+	}
+
+	if allowRecovery && enabled {
+		return db.UseRecoveryCode(userID, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+	}
+	return false, nil
+}
+
+// newRecoveryCodes generates recovery codes and the hashes they are stored under
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// LoginTwoFactorHandler completes a login with the challenge token from the password step and a
+// TOTP or recovery code. Failed codes count towards the login throttle of the user.
+func LoginTwoFactorHandler(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "MFA token and code are required",
+		})
+		return
+	}
+
+	challengeHash := auth.HashToken(strings.TrimSpace(req.MFAToken))
+	userID, err := db.AttemptMFAChallenge(challengeHash)
+	if err != nil {
+		if !errors.Is(err, db.ErrMFAChallengeInvalid) {
+			log.Printf("Error checking two-factor challenge: %v", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired MFA token, please log in again",
+		})
+		return
+	}
+
+	user, err := db.GetUserByID(userID)
+	if err != nil || !user.Active {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired MFA token, please log in again",
+		})
+		return
+	}
+
+	ok, err := verifySecondFactor(user.ID, req.Code, true)
+	if err != nil {
+		log.Printf("Error verifying two-factor code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	if !ok {
+		recordLoginFailure(c, user.Username)
+		recordLoginEvent(c, user.Username, &user.ID, models.LoginResultInvalidCode)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid two-factor code",
+		})
+		return
+	}
+
+	if err := db.DeleteMFAChallenge(challengeHash); err != nil {
+		log.Printf("Error deleting two-factor challenge: %v", err)
+	}
+
+	completeLogin(c, user, user.Username, true)
+}
+
+// EnrollTwoFactorHandler starts two-factor enrollment for the authenticated user, returning the
+// secret and the otpauth URI for an authenticator app. It takes effect once confirmed with a code.
+func EnrollTwoFactorHandler(c *gin.Context) {
+	user, err := currentAccount(c)
+	if err != nil {
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	if err := db.SetPendingTOTPSecret(user.ID, secret); err != nil {
+		if errors.Is(err, db.ErrTwoFactorEnabled) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Two-factor authentication is already enabled",
+			})
+			return
+		}
+		log.Printf("Error storing TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(secret, user.Username),
+	})
+}
+
+// EnableTwoFactorHandler confirms enrollment with a code from the authenticator app and returns the
+// recovery codes, which are only shown this once. The next login asks for a code.
+func EnableTwoFactorHandler(c *gin.Context) {
+	user, err := currentAccount(c)
+	if err != nil {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is required",
+		})
+		return
+	}
+
+	secret, enabled, err := db.FetchUserTOTP(user.ID)
+	if err != nil {
+		log.Printf("Error fetching TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+	if secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Start enrollment first",
+		})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid two-factor code",
+		})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	if err := db.EnableTOTP(user.ID, step, hashes); err != nil {
+		log.Printf("Error enabling two-factor authentication: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to enable two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled, log in again to use it",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactorHandler turns off two-factor authentication after checking a current code.
+// Users whose role requires two-factor authentication cannot turn it off.
+func DisableTwoFactorHandler(c *gin.Context) {
+	user, err := currentAccount(c)
+	if err != nil {
+		return
+	}
+
+	if auth.RequiresTwoFactor(user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Two-factor authentication is required for your role",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is required",
+		})
+		return
+	}
+
+	if !checkCurrentSecondFactor(c, user, req.Code) {
+		return
+	}
+
+	if err := db.DisableTOTP(user.ID); err != nil {
+		log.Printf("Error disabling two-factor authentication: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to disable two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodesHandler replaces the recovery codes of the user after checking a current code
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	user, err := currentAccount(c)
+	if err != nil {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is required",
+		})
+		return
+	}
+
+	if !checkCurrentSecondFactor(c, user, req.Code) {
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	if err := db.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		log.Printf("Error storing recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to regenerate recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// checkCurrentSecondFactor verifies a TOTP or recovery code of a user with two-factor authentication
+// enabled, sending the error response and returning false when it fails
+func checkCurrentSecondFactor(c *gin.Context, user auth.User, code string) bool {
+	if !user.TwoFactor {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+		return false
+	}
+
+	ok, err := verifySecondFactor(user.ID, code, true)
+	if err != nil {
+		log.Printf("Error verifying two-factor code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid two-factor code",
+		})
+		return false
+	}
+	return true
+}
+
+// currentAccount loads the user who logged in, sending the error response when it cannot.
+// API keys act on behalf of a user but cannot change their account.
+func currentAccount(c *gin.Context) (auth.User, error) {
+	if _, isAPIKey := c.Get(auth.APIKeyScopesContextKey); isAPIKey {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "API keys cannot manage accounts",
+		})
+		return auth.User{}, errors.New("api key")
+	}
+
+	userID, _ := helpers.CurrentUser(c)
+	user, err := db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+		return auth.User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/mailer"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// Lifetimes of the links sent by email
+const (
+	passwordResetTokenLifetime     = time.Hour
+	emailVerificationTokenLifetime = 48 * time.Hour
+)
+
+// SendPasswordResetEmail creates a single-use password reset token for the user and emails the link
+func SendPasswordResetEmail(user auth.User) error {
+	token, err := createUserToken(user.ID, models.UserTokenPurposePasswordReset, passwordResetTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	sendAccountEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to set a new password. It expires in 1 hour and works once.\n\n%s\n\n"+
+			"If you did not ask for a password reset you can ignore this email.\n",
+			user.Username, mailer.Link("/reset-password?token="+url.QueryEscape(token))),
+	})
+	return nil
+}
+
+// SendEmailVerification creates a single-use verification token for the user and emails the link
+func SendEmailVerification(user auth.User) error {
+	token, err := createUserToken(user.ID, models.UserTokenPurposeEmailVerification, emailVerificationTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	sendAccountEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address with the link below. It expires in 48 hours.\n\n%s\n",
+			user.Username, mailer.Link("/verify-email?token="+url.QueryEscape(token))),
+	})
+	return nil
+}
+
+// createUserToken generates a token, stores its hash and returns the token for the link
+func createUserToken(userID, purpose string, lifetime time.Duration) (string, error) {
+	token, err := auth.GenerateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := db.CreateUserToken(userID, purpose, auth.HashToken(token), time.Now().Add(lifetime)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendAccountEmail sends the message in the background, so responses take the same time whether
+// or not an email was sent and a slow mail server does not hold up the request
+func sendAccountEmail(msg mailer.Message) {
+	go func() {
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("Failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	"github.com/gin-gonic/gin"
+)
+
+// RecordAudit logs a change to an entity by the authenticated user. before and after are the
+// entity as returned by the API, nil for the side that does not exist; only the fields that
+// differ are stored. Updates that change nothing are not recorded.
+func RecordAudit(c *gin.Context, entityType string, entityID interface{}, action string, before, after interface{}) error {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return fmt.Errorf("building audit log for %s %v: %w", entityType, entityID, err)
+	}
+	if action == models.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("building audit log for %s %v: %w", entityType, entityID, err)
+	}
+
+	entry := models.AuditLog{
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Action:     action,
+		Changes:    changesJSON,
+		IPAddress:  c.ClientIP(),
+	}
+	userID, username := CurrentUser(c)
+	if userID != "" {
+		entry.UserID = &userID
+	}
+	entry.Username = username
+
+	if err := db.InsertAuditLog(entry); err != nil {
+		return fmt.Errorf("recording audit log for %s %v: %w", entityType, entityID, err)
+	}
+	return nil
+}
+
+// auditChanges compares the JSON fields of before and after
+func auditChanges(before, after interface{}) (map[string]models.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = models.AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = models.AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields decodes an entity into its JSON fields
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if entity == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,16 @@
+package helpers
+
+import (
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentUser returns the ID and username of the authenticated user, or empty strings
+// when the request carries no user information
+func CurrentUser(c *gin.Context) (userID string, username string) {
+	user, err := auth.GetUserFromContext(c)
+	if err != nil {
+		return "", ""
+	}
+	return user.ID, user.Username
+}
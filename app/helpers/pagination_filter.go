@@ -26,16 +26,21 @@ func ParsePaginationParams(c *gin.Context) (limit int, offset int, page int, err
 	return
 }
 
+// ProductFilterFields lists the product columns that can be filtered on by exact value
+var ProductFilterFields = []string{"warna", "size", "grup", "unit", "kat", "model", "gender", "tipe", "status", "supplier"}
+
 // ExtractFilters gets filter parameters from the request
 func ExtractFilters(c *gin.Context) map[string]string {
 	filters := make(map[string]string)
-	validFilterFields := []string{"warna", "size", "grup", "unit", "kat", "model", "gender", "tipe", "status", "supplier"}
 
-	for _, field := range validFilterFields {
+	for _, field := range ProductFilterFields {
 		if value := c.Query(field); value != "" {
 			filters[field] = value
 		}
 	}
+	if value := c.Query("lifecycle_status"); value != "" {
+		filters["lifecycle_status"] = value
+	}
 	return filters
 }
 
@@ -56,3 +61,172 @@ func QueryBool(c *gin.Context, key string) bool {
 		return false
 	}
 }
+
+// ExtractStockMovementFilters gets stock movement filter parameters from the request
+func ExtractStockMovementFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"product_no", "color_id", "size", "location_id", "reason", "reference", "user_id", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractStockTransferFilters gets stock transfer filter parameters from the request
+func ExtractStockTransferFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "from_location_id", "to_location_id", "location_id", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractStockOpnameFilters gets stock opname filter parameters from the request
+func ExtractStockOpnameFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "location_id", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractGoodsReceiptFilters gets goods receipt filter parameters from the request
+func ExtractGoodsReceiptFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "supplier_id", "location_id", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractPurchaseOrderFilters gets purchase order filter parameters from the request
+func ExtractPurchaseOrderFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "supplier_id", "location_id", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractLowStockFilters gets low stock filter parameters from the request
+func ExtractLowStockFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"kat", "grup", "supplier", "product_no"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractStockAlertFilters gets stock alert filter parameters from the request
+func ExtractStockAlertFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "product_no", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractStockReservationFilters gets stock reservation filter parameters from the request
+func ExtractStockReservationFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"status", "product_no", "color_id", "size", "location_id", "reference"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractValuationFilters gets inventory valuation filter parameters from the request
+func ExtractValuationFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"kat", "location_id", "product_no"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractUserFilters gets user filter parameters from the request
+func ExtractUserFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"role", "active"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractLoginEventFilters gets login event filter parameters from the request
+func ExtractLoginEventFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"username", "user_id", "ip_address", "success", "result", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractAuditLogFilters gets audit log filter parameters from the request
+func ExtractAuditLogFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"user_id", "username", "entity_type", "entity_id", "action", "field", "date_from", "date_to"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
+
+// ExtractPromotionFilters gets promotion filter parameters from the request
+func ExtractPromotionFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	validFilterFields := []string{"type", "status"}
+
+	for _, field := range validFilterFields {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	return filters
+}
@@ -86,6 +86,10 @@ func ConvertProductFields(product interface{}, fieldNames []string) {
 			tipe, err := db.FetchTipeByID(id)
 			return ValueWrapper{Value: tipe.Value}, err
 		},
+		"Supplier": func(id int) (ValueGetter, error) {
+			supplier, err := db.FetchSupplierByID(id)
+			return ValueWrapper{Value: supplier.Nama}, err
+		},
 	}
 
 	// Get reflect value of product
@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+)
+
+// defaultLowStockInterval is used when the configuration does not set a check interval
+const defaultLowStockInterval = time.Hour
+
+// lowStockWebhookPayload is the JSON body POSTed to the webhook
+type lowStockWebhookPayload struct {
+	Event  string              `json:"event"`
+	SentAt time.Time           `json:"sent_at"`
+	Alerts []models.StockAlert `json:"alerts"`
+}
+
+// StartLowStockChecker runs the low stock check once at start-up and then on every interval until ctx is cancelled.
+// Each run raises alerts for variants that dropped below their minimum stock, resolves alerts of restocked
+// variants and delivers alerts that have not been delivered yet to the webhook.
+func StartLowStockChecker(ctx context.Context, config settings.LowStockConfig) {
+	interval := defaultLowStockInterval
+	if config.CheckInterval != "" {
+		parsed, err := time.ParseDuration(config.CheckInterval)
+		if err != nil {
+			log.Printf("Invalid low-stock check-interval %q, using %s: %v", config.CheckInterval, defaultLowStockInterval, err)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval <= 0 {
+		log.Println("Low stock checker disabled")
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Low stock checker running every %s", interval)
+	for {
+		runLowStockCheck(ctx, client, config.WebhookURL)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLowStockCheck performs a single check and logs instead of failing, so the next run can retry
+func runLowStockCheck(ctx context.Context, client *http.Client, webhookURL string) {
+	opened, resolved, err := db.SyncStockAlerts()
+	if err != nil {
+		log.Printf("Low stock check failed: %v", err)
+		return
+	}
+	if opened > 0 || resolved > 0 {
+		log.Printf("Low stock check: %d alerts opened, %d resolved", opened, resolved)
+	}
+
+	if webhookURL == "" {
+		return
+	}
+
+	alerts, err := db.FetchUnnotifiedStockAlerts()
+	if err != nil {
+		log.Printf("Failed to fetch undelivered stock alerts: %v", err)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	if err := postLowStockWebhook(ctx, client, webhookURL, alerts); err != nil {
+		log.Printf("Failed to deliver %d stock alerts to webhook: %v", len(alerts), err)
+		return
+	}
+
+	ids := make([]int, len(alerts))
+	for i, a := range alerts {
+		ids[i] = a.ID
+	}
+	if err := db.MarkStockAlertsNotified(ids); err != nil {
+		log.Printf("Failed to mark stock alerts as delivered: %v", err)
+	}
+}
+
+// postLowStockWebhook POSTs the alerts to the webhook and treats any non-2xx response as a failure
+func postLowStockWebhook(ctx context.Context, client *http.Client, webhookURL string, alerts []models.StockAlert) error {
+	body, err := json.Marshal(lowStockWebhookPayload{
+		Event:  "low_stock",
+		SentAt: time.Now(),
+		Alerts: alerts,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+)
+
+// defaultPriceApplyInterval is used when the configuration does not set an apply interval
+const defaultPriceApplyInterval = time.Minute
+
+// StartPriceScheduler applies due price schedules on every interval until ctx is cancelled.
+// A schedule takes effect on the first run after its effective time.
+func StartPriceScheduler(ctx context.Context, config settings.PricesConfig) {
+	interval := defaultPriceApplyInterval
+	if config.ApplyInterval != "" {
+		parsed, err := time.ParseDuration(config.ApplyInterval)
+		if err != nil {
+			log.Printf("Invalid prices apply-interval %q, using %s: %v", config.ApplyInterval, defaultPriceApplyInterval, err)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval <= 0 {
+		log.Println("Price scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := db.ApplyDuePriceSchedules()
+		if err != nil {
+			log.Printf("Price schedule run failed: %v", err)
+		} else if applied > 0 {
+			log.Printf("Price schedule run: %d price changes applied", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
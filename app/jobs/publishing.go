@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+)
+
+// defaultPublishInterval is used when the configuration does not set a publish interval
+const defaultPublishInterval = time.Minute
+
+// StartPublishScheduler publishes and archives products whose publish or unpublish time has passed on every
+// interval until ctx is cancelled. Products are public from their publish time on, the scheduler only records it.
+func StartPublishScheduler(ctx context.Context, config settings.ProductsConfig) {
+	interval := defaultPublishInterval
+	if config.PublishInterval != "" {
+		parsed, err := time.ParseDuration(config.PublishInterval)
+		if err != nil {
+			log.Printf("Invalid products publish-interval %q, using %s: %v", config.PublishInterval, defaultPublishInterval, err)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval <= 0 {
+		log.Println("Publish scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, archived, err := db.ApplyProductPublishSchedules()
+		if err != nil {
+			log.Printf("Publish schedule run failed: %v", err)
+		} else if published > 0 || archived > 0 {
+			log.Printf("Publish schedule run: %d products published, %d archived", published, archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
+)
+
+// defaultReservationSweepInterval is used when the configuration does not set a sweep interval
+const defaultReservationSweepInterval = time.Minute
+
+// StartReservationSweeper marks expired reservations on every interval until ctx is cancelled.
+// Expired reservations stop holding stock as soon as they expire, the sweeper only records it.
+func StartReservationSweeper(ctx context.Context, config settings.ReservationsConfig) {
+	interval := defaultReservationSweepInterval
+	if config.SweepInterval != "" {
+		parsed, err := time.ParseDuration(config.SweepInterval)
+		if err != nil {
+			log.Printf("Invalid reservations sweep-interval %q, using %s: %v", config.SweepInterval, defaultReservationSweepInterval, err)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval <= 0 {
+		log.Println("Reservation sweeper disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := db.ExpireStockReservations()
+		if err != nil {
+			log.Printf("Reservation sweep failed: %v", err)
+		} else if expired > 0 {
+			log.Printf("Reservation sweep: %d reservations expired", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
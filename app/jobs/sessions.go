@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/db"
+)
+
+// tokenPurgeInterval is how often expired refresh tokens, revoked token IDs and stale login throttles are deleted
+const tokenPurgeInterval = time.Hour
+
+// StartTokenPurger deletes expired refresh tokens, revoked access token IDs and stale login throttle keys
+// on every interval until ctx is cancelled. Expired entries are already ignored, purging only keeps the tables small.
+func StartTokenPurger(ctx context.Context) {
+	ticker := time.NewTicker(tokenPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := db.PurgeExpiredTokens()
+		if err != nil {
+			log.Printf("Token purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Token purge: %d expired tokens deleted", purged)
+		}
+
+		stale, err := db.PurgeStaleLoginThrottles()
+		if err != nil {
+			log.Printf("Login throttle purge failed: %v", err)
+		} else if stale > 0 {
+			log.Printf("Login throttle purge: %d stale keys deleted", stale)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
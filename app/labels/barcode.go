@@ -0,0 +1,179 @@
+// Package labels renders barcodes and printable label sheets for products and their variants
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Supported symbologies
+const (
+	FormatCode128 = "code128"
+	FormatEAN13   = "ean13"
+	FormatQR      = "qr"
+)
+
+// Supported image outputs
+const (
+	OutputPNG = "png"
+	OutputSVG = "svg"
+)
+
+// barHeightModules is the height of linear barcodes in modules
+const barHeightModules = 30
+
+// Quiet zones in modules, the blank margin scanners need around a code
+const (
+	linearQuietZone = 10
+	qrQuietZone     = 4
+)
+
+// ErrUnsupportedFormat is returned for symbologies other than code128, ean13 and qr
+var ErrUnsupportedFormat = errors.New("unsupported barcode format")
+
+// IsValidFormat reports whether format is a supported symbology
+func IsValidFormat(format string) bool {
+	return format == FormatCode128 || format == FormatEAN13 || format == FormatQR
+}
+
+// Encode encodes content in the given symbology. EAN-13 takes 12 digits, or 13 with a valid check digit.
+func Encode(format, content string) (barcode.Barcode, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("nothing to encode")
+	}
+
+	switch format {
+	case FormatCode128:
+		return code128.Encode(content)
+	case FormatEAN13:
+		if len(content) != 12 && len(content) != 13 {
+			return nil, fmt.Errorf("EAN-13 needs 12 or 13 digits, got %q", content)
+		}
+		return ean.Encode(content)
+	case FormatQR:
+		return qr.Encode(content, qr.M, qr.Auto)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// modules returns the dark modules of an encoded barcode by row, with the quiet zone around them.
+// Linear barcodes have a single row.
+func modules(bc barcode.Barcode) [][]bool {
+	quiet := linearQuietZone
+	if bc.Metadata().Dimensions == 2 {
+		quiet = qrQuietZone
+	}
+
+	bounds := bc.Bounds()
+	height := 1
+	if bc.Metadata().Dimensions == 2 {
+		height = bounds.Dy() + 2*quiet
+	}
+	width := bounds.Dx() + 2*quiet
+
+	grid := make([][]bool, height)
+	for y := range grid {
+		grid[y] = make([]bool, width)
+	}
+	for x := 0; x < bounds.Dx(); x++ {
+		if bc.Metadata().Dimensions == 2 {
+			for y := 0; y < bounds.Dy(); y++ {
+				grid[y+quiet][x+quiet] = isDark(bc.At(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		} else {
+			grid[0][x+quiet] = isDark(bc.At(bounds.Min.X+x, bounds.Min.Y))
+		}
+	}
+	return grid
+}
+
+// isDark reports whether a barcode pixel is a bar rather than a space
+func isDark(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r+g+b < 3*0x8000
+}
+
+// imageSize returns the size of a rendered barcode in modules
+func imageSize(grid [][]bool) (int, int) {
+	if len(grid) == 1 {
+		return len(grid[0]), barHeightModules
+	}
+	return len(grid[0]), len(grid)
+}
+
+// WritePNG renders a barcode as a PNG with moduleSize pixels per module
+func WritePNG(w io.Writer, bc barcode.Barcode, moduleSize int) error {
+	grid := modules(bc)
+	width, height := imageSize(grid)
+
+	img := image.NewGray(image.Rect(0, 0, width*moduleSize, height*moduleSize))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for py := 0; py < height*moduleSize; py++ {
+		row := grid[0]
+		if len(grid) > 1 {
+			row = grid[py/moduleSize]
+		}
+		for px := 0; px < width*moduleSize; px++ {
+			if row[px/moduleSize] {
+				img.SetGray(px, py, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// WriteSVG renders a barcode as an SVG with moduleSize pixels per module. Runs of dark modules
+// are drawn as single rectangles to keep the document small.
+func WriteSVG(w io.Writer, bc barcode.Barcode, moduleSize int) error {
+	grid := modules(bc)
+	width, height := imageSize(grid)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		width*moduleSize, height*moduleSize, width, height)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#fff"/><g fill="#000">`, width, height)
+	for y, row := range grid {
+		rowHeight := 1
+		if len(grid) == 1 {
+			rowHeight = height
+		}
+		for _, run := range darkRuns(row) {
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d"/>`, run[0], y, run[1], rowHeight)
+		}
+	}
+	sb.WriteString(`</g></svg>`)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// darkRuns returns the start and length of every run of dark modules in a row
+func darkRuns(row []bool) [][2]int {
+	runs := [][2]int{}
+	for x := 0; x < len(row); {
+		if !row[x] {
+			x++
+			continue
+		}
+		start := x
+		for x < len(row) && row[x] {
+			x++
+		}
+		runs = append(runs, [2]int{start, x - start})
+	}
+	return runs
+}
@@ -0,0 +1,218 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Label is the content of one label on a sheet
+type Label struct {
+	Artikel   string
+	Nama      string
+	Warna     string
+	Size      string
+	Harga     float64
+	Code      string // Encoded in the barcode and printed under it
+	Symbology string // FormatCode128 or FormatEAN13, Code128 when empty
+}
+
+// Sheet layout in points: A4 with 3 × 7 labels of 63.5 × 38.1 mm, the layout of common
+// self-adhesive label sheets
+const (
+	mm          = 72 / 25.4
+	pageWidth   = 210 * mm
+	pageHeight  = 297 * mm
+	labelWidth  = 63.5 * mm
+	labelHeight = 38.1 * mm
+	marginLeft  = 7.25 * mm
+	marginTop   = 15.15 * mm
+	columnPitch = 66.04 * mm
+	columns     = 3
+	rows        = 7
+	padding     = 6.0
+)
+
+// WriteSheet lays the labels out on A4 pages and writes them as a PDF. The text uses the standard
+// Helvetica fonts every PDF reader provides, barcodes are drawn as vector bars.
+func WriteSheet(w io.Writer, labels []Label) error {
+	doc := &pdfDocument{}
+
+	var page *bytes.Buffer
+	for i, label := range labels {
+		slot := i % (columns * rows)
+		if slot == 0 {
+			page = doc.addPage()
+		}
+		x := marginLeft + float64(slot%columns)*columnPitch
+		y := pageHeight - marginTop - float64(slot/columns+1)*labelHeight
+		if err := drawLabel(page, label, x, y); err != nil {
+			return fmt.Errorf("label %d: %w", i+1, err)
+		}
+	}
+	if len(labels) == 0 {
+		doc.addPage()
+	}
+
+	return doc.write(w)
+}
+
+// drawLabel draws a label with its bottom left corner at x, y
+func drawLabel(page *bytes.Buffer, label Label, x, y float64) error {
+	textWidth := labelWidth - 2*padding
+	top := y + labelHeight
+
+	pdfText(page, "F2", 9, x+padding, top-15, fitText(label.Artikel, 9, textWidth))
+	pdfText(page, "F1", 7, x+padding, top-25, fitText(label.Nama, 7, textWidth))
+
+	details := []string{}
+	if label.Warna != "" {
+		details = append(details, label.Warna)
+	}
+	if label.Size != "" {
+		details = append(details, "Size "+label.Size)
+	}
+	pdfText(page, "F1", 7, x+padding, top-34, fitText(strings.Join(details, " / "), 7, textWidth))
+	pdfText(page, "F2", 10, x+padding, top-46, FormatRupiah(label.Harga))
+
+	if label.Code == "" {
+		return nil
+	}
+	symbology := label.Symbology
+	if symbology == "" {
+		symbology = FormatCode128
+	}
+	bc, err := Encode(symbology, label.Code)
+	if err != nil {
+		return err
+	}
+
+	// Bars as wide as the label allows, at most one point per module
+	row := modules(bc)[0]
+	moduleWidth := math.Min(1, textWidth/float64(len(row)))
+	barX := x + (labelWidth-moduleWidth*float64(len(row)))/2
+	barY, barHeight := y+13, 36.0
+	page.WriteString("0 g\n")
+	for _, run := range darkRuns(row) {
+		fmt.Fprintf(page, "%.3f %.3f %.3f %.3f re f\n", barX+float64(run[0])*moduleWidth, barY, float64(run[1])*moduleWidth, barHeight)
+	}
+
+	code := bc.Content()
+	pdfText(page, "F1", 6, x+(labelWidth-textWidthEstimate(code, 6))/2, y+5, code)
+	return nil
+}
+
+// FormatRupiah formats a price as rupiah with dots between thousands, such as Rp 1.250.000
+func FormatRupiah(amount float64) string {
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(amount))), 10)
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteRune(d)
+	}
+	if amount < 0 {
+		return "Rp -" + sb.String()
+	}
+	return "Rp " + sb.String()
+}
+
+// textWidthEstimate approximates the width of Helvetica text, whose characters average just over half the font size
+func textWidthEstimate(text string, size float64) float64 {
+	return float64(len([]rune(text))) * size * 0.55
+}
+
+// fitText shortens text with an ellipsis until it fits width
+func fitText(text string, size, width float64) string {
+	runes := []rune(strings.TrimSpace(text))
+	if textWidthEstimate(string(runes), size) <= width {
+		return string(runes)
+	}
+	for len(runes) > 0 && textWidthEstimate(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// pdfText writes a line of text with its baseline starting at x, y
+func pdfText(page *bytes.Buffer, font string, size, x, y float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(page, "BT /%s %.1f Tf %.3f %.3f Td (%s) Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// pdfString escapes text for a PDF string literal in WinAnsiEncoding. Characters outside Latin-1
+// cannot be shown with the standard fonts and become question marks.
+func pdfString(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			sb.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// pdfDocument collects page content streams and writes them as a minimal PDF
+type pdfDocument struct {
+	pages []*bytes.Buffer
+}
+
+// addPage starts a new page and returns its content stream
+func (d *pdfDocument) addPage() *bytes.Buffer {
+	page := &bytes.Buffer{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// write writes the document: catalog, page tree and the two fonts, then a page and a content
+// stream object per page, followed by the cross-reference table
+func (d *pdfDocument) write(w io.Writer) error {
+	var out bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	const firstPageObject = 5
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPageObject+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	settings "github.com/everysoft/inventary-be/settings"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email. SMTPMailer delivers it, LogMailer writes it to the log or to files for local development.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// current is the mailer used by Send, a LogMailer until Configure is called
+var current Mailer = LogMailer{}
+
+// linkBaseURL is the frontend URL that links in emails point to
+var linkBaseURL = ""
+
+// Configure selects the mailer from the loaded configuration
+func Configure(config settings.MailConfig) error {
+	linkBaseURL = strings.TrimRight(config.LinkBaseURL, "/")
+
+	switch config.Driver {
+	case "", "log":
+		current = LogMailer{}
+	case "file":
+		if config.Dir == "" {
+			return fmt.Errorf("mail dir is required for the file driver")
+		}
+		current = LogMailer{Dir: config.Dir}
+	case "smtp":
+		if config.Host == "" || config.From == "" {
+			return fmt.Errorf("mail host and from are required for the smtp driver")
+		}
+		current = SMTPMailer{
+			Host:     config.Host,
+			Port:     config.Port,
+			Username: config.Username,
+			Password: config.Password,
+			From:     config.From,
+		}
+	default:
+		return fmt.Errorf("unknown mail driver %q, use smtp, file or log", config.Driver)
+	}
+	return nil
+}
+
+// Send sends a message with the configured mailer
+func Send(msg Message) error {
+	return current.Send(msg)
+}
+
+// Link builds a frontend link for a path such as "/reset-password?token=..."
+func Link(path string) string {
+	return linkBaseURL + path
+}
+
+// SMTPMailer sends email through an SMTP server, with PLAIN auth when a username is set
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers the message to the SMTP server
+func (m SMTPMailer) Send(msg Message) error {
+	port := m.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+strconv.Itoa(port), auth, m.From, []string{msg.To}, formatMessage(m.From, msg))
+}
+
+// LogMailer writes messages to the log, or as .eml files into Dir when it is set
+type LogMailer struct {
+	Dir string
+}
+
+// Send logs the message or writes it to a file
+func (m LogMailer) Send(msg Message) error {
+	if m.Dir == "" {
+		log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), formatMessage("noreply@localhost", msg), 0o644)
+}
+
+// formatMessage renders the message with the headers SMTP servers expect
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeFileName keeps letters, digits, dots, dashes and @ from an address for use in a file name
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '@' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditActionCreate        = "create"
+	AuditActionUpdate        = "update"
+	AuditActionDelete        = "delete"
+	AuditActionRestore       = "restore"
+	AuditActionRevoke        = "revoke"
+	AuditActionUnlock        = "unlock"
+	AuditActionResetPassword = "reset_password"
+)
+
+// Audited entity types
+const (
+	AuditEntityProduct          = "product"
+	AuditEntityProductVariant   = "product_variant"
+	AuditEntityPriceSchedule    = "product_price_schedule"
+	AuditEntityPromotion        = "promotion"
+	AuditEntityBanner           = "banner"
+	AuditEntityNewsletter       = "newsletter"
+	AuditEntityColor            = "color"
+	AuditEntityGender           = "gender"
+	AuditEntityGrup             = "grup"
+	AuditEntityKat              = "kat"
+	AuditEntityLocation         = "location"
+	AuditEntitySupplier         = "supplier"
+	AuditEntityTipe             = "tipe"
+	AuditEntityUnit             = "unit"
+	AuditEntityStockMovement    = "stock_movement"
+	AuditEntityStockTransfer    = "stock_transfer"
+	AuditEntityStockOpname      = "stock_opname"
+	AuditEntityStockReservation = "stock_reservation"
+	AuditEntityStockThreshold   = "stock_threshold"
+	AuditEntityGoodsReceipt     = "goods_receipt"
+	AuditEntityPurchaseOrder    = "purchase_order"
+	AuditEntityUser             = "user"
+	AuditEntityAPIKey           = "api_key"
+)
+
+// AuditChange is the value of one field before and after a change. Before is null for
+// created records and after is null for deleted ones.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLog records a change an authenticated user made to an entity through the admin API
+type AuditLog struct {
+	ID         int             `json:"id"`
+	UserID     *string         `json:"user_id"`
+	Username   string          `json:"username"` // From the token, api-key:<name> for API keys
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	Changes    json.RawMessage `json:"changes"` // Changed fields mapped to an AuditChange
+	IPAddress  string          `json:"ip_address"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
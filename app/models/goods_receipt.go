@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// Goods receipt statuses
+const (
+	GoodsReceiptStatusDraft     = "draft"     // Being entered, stock has not changed yet
+	GoodsReceiptStatusPosted    = "posted"    // Stock increased and batches recorded
+	GoodsReceiptStatusCancelled = "cancelled" // Abandoned before it was posted
+)
+
+// GoodsReceipt is a penerimaan barang document recording goods delivered by a supplier
+type GoodsReceipt struct {
+	ID            int                `json:"id"`
+	Nomor         string             `json:"nomor"` // Document number, e.g. GRN-20240101-000001
+	SupplierID    int                `json:"supplier_id"`
+	Supplier      string             `json:"supplier"` // Supplier name at the time of the receipt
+	LocationID    int                `json:"location_id"`
+	LocationName  string             `json:"location_name,omitempty"`
+	TanggalTerima time.Time          `json:"tanggal_terima"` // Date the goods arrived; becomes the batch date of every line
+	Reference     string             `json:"reference"`      // Supplier delivery note (surat jalan) or invoice number
+	Status        string             `json:"status"`
+	Notes         string             `json:"notes"`
+	Items         []GoodsReceiptItem `json:"items"`
+	TotalQuantity int                `json:"total_quantity"`
+	TotalValue    float64            `json:"total_value"` // Sum of quantity × unit cost of all lines
+	CreatedBy     string             `json:"created_by"`
+	CreatedAt     time.Time          `json:"created_at"`
+	PostedBy      string             `json:"posted_by,omitempty"`
+	PostedAt      *time.Time         `json:"posted_at,omitempty"`
+	TanggalUpdate time.Time          `json:"tanggal_update"`
+}
+
+// GoodsReceiptItem is a single variant line of a goods receipt. Once posted, each line is a stock batch.
+type GoodsReceiptItem struct {
+	ID                  int     `json:"id"`
+	ProductNo           int     `json:"product_no"`
+	Artikel             string  `json:"artikel,omitempty"`
+	ColorID             int     `json:"color_id"`
+	ColorName           string  `json:"color_name,omitempty"`
+	Size                string  `json:"size"`
+	Quantity            int     `json:"quantity"`
+	UnitCost            float64 `json:"unit_cost"`                        // Purchase cost per unit, the cost of the batch for valuation
+	PurchaseOrderItemID int     `json:"purchase_order_item_id,omitempty"` // Purchase order line delivered by this line, if any
+	PurchaseOrderNomor  string  `json:"purchase_order_nomor,omitempty"`
+}
+
+// GoodsReceiptItemInput is a single variant line sent when creating or editing a receipt
+type GoodsReceiptItemInput struct {
+	ProductNo           int     `json:"product_no" binding:"required"`
+	ColorID             int     `json:"color_id" binding:"required"`
+	Size                string  `json:"size" binding:"required"`
+	Quantity            int     `json:"quantity" binding:"required,gt=0"`
+	UnitCost            float64 `json:"unit_cost" binding:"gte=0"` // Defaults to the unit cost of the purchase order line
+	PurchaseOrderItemID int     `json:"purchase_order_item_id"`    // Optional, the purchase order line being delivered
+}
+
+// SaveGoodsReceiptRequest is the request body for creating or editing a draft receipt
+type SaveGoodsReceiptRequest struct {
+	SupplierID    int                     `json:"supplier_id" binding:"required"`
+	LocationID    int                     `json:"location_id"`                       // Optional, defaults to the default location
+	TanggalTerima string                  `json:"tanggal_terima" binding:"required"` // YYYY-MM-DD
+	Reference     string                  `json:"reference"`
+	Notes         string                  `json:"notes"`
+	Items         []GoodsReceiptItemInput `json:"items" binding:"required,min=1,dive"`
+
+	SupplierName string    `json:"-"` // Resolved from SupplierID during validation
+	ReceivedDate time.Time `json:"-"` // Parsed from TanggalTerima during validation
+}
+
+// StockBatch is a posted receipt line with the quantity of it still assumed on hand.
+// Outgoing stock is taken from the oldest batches first.
+type StockBatch struct {
+	ReceiptItemID int       `json:"receipt_item_id"`
+	ReceiptID     int       `json:"receipt_id"`
+	Nomor         string    `json:"nomor"`
+	Supplier      string    `json:"supplier"`
+	TanggalTerima time.Time `json:"tanggal_terima"`
+	ColorID       int       `json:"color_id"`
+	ColorName     string    `json:"color_name,omitempty"`
+	Size          string    `json:"size"`
+	Quantity      int       `json:"quantity"` // Quantity received in the batch
+	UnitCost      float64   `json:"unit_cost"`
+	Remaining     int       `json:"remaining"` // Quantity of the batch still on hand
+	Usia          string    `json:"usia"`      // Fresh, Normal or Aging, same buckets as the product
+}
@@ -0,0 +1,10 @@
+package models
+
+// LabelSheetRequest is the request body for printing a label sheet. Every active variant of the
+// selected products gets a label, or only the listed variants when VariantIDs is set.
+type LabelSheetRequest struct {
+	ProductNos []int  `json:"product_nos" binding:"required"`
+	VariantIDs []int  `json:"variant_ids"`
+	Copies     int    `json:"copies"`    // Labels per variant, defaults to 1
+	Symbology  string `json:"symbology"` // code128 (default, encodes the SKU) or ean13 (encodes the barcode)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Location types
+const (
+	LocationTypeWarehouse   = "warehouse"
+	LocationTypeShop        = "shop"
+	LocationTypeConsignment = "consignment"
+)
+
+// Location represents a place where stock is held: a warehouse, a shop or a consignment partner
+type Location struct {
+	ID            int        `json:"id"`
+	Kode          string     `json:"kode"`
+	Nama          string     `json:"nama"`
+	Tipe          string     `json:"tipe"`
+	Alamat        string     `json:"alamat"`
+	URL           string     `json:"url"`
+	IsDefault     bool       `json:"is_default"` // Default location used when a request does not specify one
+	IsActive      *bool      `json:"is_active"`
+	TanggalUpdate time.Time  `json:"tanggal_update"`
+	TanggalHapus  *time.Time `json:"tanggal_hapus,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Login event outcomes
+const (
+	LoginResultSuccess            = "success"
+	LoginResultInvalidCredentials = "invalid_credentials"
+	LoginResultLocked             = "locked"   // Rejected without checking the password while throttled
+	LoginResultInactive           = "inactive" // Correct password for a deactivated account
+	LoginResultInvalidCode        = "invalid_two_factor_code"
+)
+
+// LoginEvent is an audit record of a login attempt
+type LoginEvent struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"` // As typed by the client
+	UserID    *string   `json:"user_id"`  // Null when the username matched no user
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -63,35 +63,46 @@ type CreateProductRequest struct {
 	TanggalTerima string `form:"tanggal_terima"`
 	Status        string `form:"status" binding:"required"`
 	Supplier      string `form:"supplier" binding:"required"`
-	DiupdateOleh  string `form:"diupdate_oleh" binding:"required"`
+	DiupdateOleh  string `form:"diupdate_oleh"` // Ignored, set from the token
 }
 
 type Product struct {
-	Artikel       string          `json:"artikel"`          // ARTIKEL = PRODUCT_NAME
-	Nama          string          `json:"nama"`             // NAMA
-	Deskripsi     string          `json:"deskripsi"`        // DESKRIPSI
-	Rating        ProductRating   `json:"rating"`           // RATING - Admin-set specifications
-	No            string          `json:"no"`               // NO
-	Warna         string          `json:"warna"`            // WARNA - Now stores comma-separated IDs
-	Size          string          `json:"size"`             // SIZE
-	Grup          string          `json:"grup"`             // GRUP
-	Unit          string          `json:"unit"`             // UNIT
-	Kat           string          `json:"kat"`              // KAT
-	Model         string          `json:"model"`            // MODEL
-	Gender        string          `json:"gender"`           // GENDER
-	Tipe          string          `json:"tipe"`             // TIPE
-	Harga         float64         `json:"harga"`            // HARGA
-	HargaDiskon   *float64        `json:"harga_diskon"`     // HARGA DISKON
-	Marketplace   MarketplaceInfo `json:"marketplace"`      // MARKETPLACE
-	Offline       OfflineStores   `json:"offline"`          // OFFLINE - Array of offline store info
-	Gambar        []string        `json:"gambar"`           // GAMBAR
-	TanggalProduk time.Time       `json:"tanggal_produk"`   // TANGGAL PRODUK
-	TanggalTerima time.Time       `json:"tanggal_terima"`   // TANGGAL TERIMA
-	Usia          string          `json:"usia,omitempty"`   // Calculated dynamically: "Fresh" under 1 year, "Normal" under 2 years, "Aging" over 2 years
-	Status        string          `json:"status"`           // STATUS
-	Supplier      string          `json:"supplier"`         // SUPPLIER
-	DiupdateOleh  string          `json:"diupdate_oleh"`    // DIUPDATE OLEH
-	TanggalUpdate time.Time       `json:"tanggal_update"`   // TANGGAL UPDATE
-	TanggalHapus  *time.Time      `json:"tanggal_hapus"`    // TANGGAL HAPUS - Null if product is active, contains timestamp when soft-deleted
-	Colors        []ColorInfo     `json:"colors,omitempty"` // Additional color information
+	Artikel         string                `json:"artikel"`                  // ARTIKEL = PRODUCT_NAME
+	Nama            string                `json:"nama"`                     // NAMA
+	Deskripsi       string                `json:"deskripsi"`                // DESKRIPSI
+	Rating          ProductRating         `json:"rating"`                   // RATING - Admin-set specifications
+	No              string                `json:"no"`                       // NO
+	Warna           string                `json:"warna"`                    // WARNA - Now stores comma-separated IDs
+	Size            string                `json:"size"`                     // SIZE
+	Grup            string                `json:"grup"`                     // GRUP
+	Unit            string                `json:"unit"`                     // UNIT
+	Kat             string                `json:"kat"`                      // KAT
+	Model           string                `json:"model"`                    // MODEL
+	Gender          string                `json:"gender"`                   // GENDER
+	Tipe            string                `json:"tipe"`                     // TIPE
+	Harga           float64               `json:"harga"`                    // HARGA
+	HargaDiskon     *float64              `json:"harga_diskon"`             // HARGA DISKON
+	Marketplace     MarketplaceInfo       `json:"marketplace"`              // MARKETPLACE
+	Offline         OfflineStores         `json:"offline"`                  // OFFLINE - Array of offline store info
+	Gambar          []string              `json:"gambar"`                   // GAMBAR
+	TanggalProduk   time.Time             `json:"tanggal_produk"`           // TANGGAL PRODUK
+	TanggalTerima   time.Time             `json:"tanggal_terima"`           // TANGGAL TERIMA
+	Usia            string                `json:"usia,omitempty"`           // Calculated dynamically: "Fresh" under 1 year, "Normal" under 2 years, "Aging" over 2 years
+	Status          string                `json:"status"`                   // STATUS
+	LifecycleStatus string                `json:"lifecycle_status"`         // Draft, in review, published or archived; a passed publish or unpublish time applies right away
+	PublishAt       *time.Time            `json:"publish_at"`               // Scheduled publish time
+	UnpublishAt     *time.Time            `json:"unpublish_at"`             // Scheduled unpublish time
+	Supplier        string                `json:"supplier"`                 // SUPPLIER
+	DiupdateOleh    string                `json:"diupdate_oleh"`            // DIUPDATE OLEH
+	TanggalUpdate   time.Time             `json:"tanggal_update"`           // TANGGAL UPDATE
+	TanggalHapus    *time.Time            `json:"tanggal_hapus"`            // TANGGAL HAPUS - Null if product is active, contains timestamp when soft-deleted
+	Colors          []ColorInfo           `json:"colors,omitempty"`         // Additional color information
+	Stocks          []ProductStock        `json:"stocks"`                   // On-hand quantity per color × size variant
+	Variants        []ProductVariant      `json:"variants"`                 // Sellable color × size variants with their SKU and barcode
+	TotalStock      int                   `json:"total_stock"`              // Sum of all variant on-hand quantities
+	TotalAvailable  int                   `json:"total_available"`          // Sum of all variant available quantities
+	PriceTimeline   *ProductPriceTimeline `json:"price_timeline,omitempty"` // Price history and pending price changes, on the admin product detail
+	HargaEfektif    *float64              `json:"harga_efektif,omitempty"`  // Price after running promotions, in the public product responses
+	Promotions      []AppliedPromotion    `json:"promotions,omitempty"`     // Running promotions applied to the product, in the public product responses
+	CartOffers      []AppliedPromotion    `json:"cart_offers,omitempty"`    // Running buy X get Y promotions, applied to the cart rather than the unit price
 }
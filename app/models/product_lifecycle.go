@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Product lifecycle statuses. Only published products are returned by the public product endpoints.
+const (
+	ProductLifecycleDraft     = "draft"
+	ProductLifecycleInReview  = "in_review"
+	ProductLifecyclePublished = "published"
+	ProductLifecycleArchived  = "archived"
+)
+
+// ProductLifecycleRequest is the request body for moving a product to another lifecycle status
+type ProductLifecycleRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// ProductPublishScheduleRequest is the request body for scheduling when a product is published and
+// unpublished. Empty times clear the schedule.
+type ProductPublishScheduleRequest struct {
+	PublishAt   string `json:"publish_at"`   // RFC 3339, publishes a draft or in-review product
+	UnpublishAt string `json:"unpublish_at"` // RFC 3339, archives the product once published
+
+	Publish   *time.Time `json:"-"` // Parsed from PublishAt during validation
+	Unpublish *time.Time `json:"-"` // Parsed from UnpublishAt during validation
+}
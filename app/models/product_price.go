@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// Sources of a price change
+const (
+	PriceChangeSourceInitial   = "initial"   // Price the product had when history started
+	PriceChangeSourceManual    = "manual"    // Changed through a product update
+	PriceChangeSourceScheduled = "scheduled" // Applied from a price schedule
+)
+
+// Price schedule statuses
+const (
+	PriceScheduleStatusPending   = "pending"   // Waiting for effective_at
+	PriceScheduleStatusApplied   = "applied"   // Applied to the product by the scheduler
+	PriceScheduleStatusSkipped   = "skipped"   // Due together with a later schedule of the same product, which was applied instead
+	PriceScheduleStatusCancelled = "cancelled" // Cancelled by hand before it was applied
+)
+
+// ProductPriceChange is one entry of the price history of a product, the price and discount it had
+// from EffectiveFrom until EffectiveTo. The current price has no EffectiveTo.
+type ProductPriceChange struct {
+	ID            int        `json:"id"`
+	ProductNo     int        `json:"product_no"`
+	Harga         float64    `json:"harga"`
+	HargaDiskon   *float64   `json:"harga_diskon"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	ChangedBy     string     `json:"changed_by"`
+	Source        string     `json:"source"`
+	ScheduleID    *int       `json:"schedule_id,omitempty"` // Schedule that made the change, for scheduled changes
+}
+
+// ProductPriceSchedule is a price and discount a product takes on at EffectiveAt
+type ProductPriceSchedule struct {
+	ID          int        `json:"id"`
+	ProductNo   int        `json:"product_no"`
+	Harga       float64    `json:"harga"`
+	HargaDiskon *float64   `json:"harga_diskon"` // Null removes the discount
+	EffectiveAt time.Time  `json:"effective_at"`
+	Notes       string     `json:"notes"`
+	Status      string     `json:"status"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClosedBy    string     `json:"closed_by,omitempty"` // Who cancelled the schedule
+	ClosedAt    *time.Time `json:"closed_at,omitempty"` // When the schedule was applied, skipped or cancelled
+}
+
+// ProductPriceTimeline is the past, current and planned prices of a product
+type ProductPriceTimeline struct {
+	History   []ProductPriceChange   `json:"history"`   // Newest first
+	Scheduled []ProductPriceSchedule `json:"scheduled"` // Pending schedules, soonest first
+}
+
+// CreatePriceScheduleRequest is the request body for scheduling a price change
+type CreatePriceScheduleRequest struct {
+	Harga       float64  `json:"harga" binding:"required,gt=0"`
+	HargaDiskon *float64 `json:"harga_diskon"`                    // Optional, null or missing removes the discount
+	EffectiveAt string   `json:"effective_at" binding:"required"` // RFC 3339, must be in the future
+	Notes       string   `json:"notes"`
+
+	Effective time.Time `json:"-"` // Parsed from EffectiveAt during validation
+}
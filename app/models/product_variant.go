@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductVariant is a sellable color × size variant of a product. Variants follow the warna and size
+// of their product: new combinations are added, dropped ones are soft-deleted and come back with
+// their codes when the combination is listed again.
+type ProductVariant struct {
+	ID            int        `json:"id"`
+	ProductNo     int        `json:"product_no"`
+	ColorID       int        `json:"color_id"`
+	ColorName     string     `json:"color_name,omitempty"`
+	Size          string     `json:"size"`
+	SKU           string     `json:"sku"`
+	Barcode       string     `json:"barcode"`                 // EAN-13, generated in the in-store 20 prefix unless set
+	Harga         *float64   `json:"harga"`                   // Overrides the product price when set
+	HargaEfektif  *float64   `json:"harga_efektif,omitempty"` // Price after running promotions, in the public product responses
+	IsActive      bool       `json:"is_active"`
+	TanggalUpdate time.Time  `json:"tanggal_update"`
+	TanggalHapus  *time.Time `json:"tanggal_hapus,omitempty"`
+}
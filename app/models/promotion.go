@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// Promotion types
+const (
+	PromotionTypePercentage = "percentage"  // Value percent off the price
+	PromotionTypeAmount     = "amount"      // Value rupiah off the price
+	PromotionTypeFixedPrice = "fixed_price" // Sold at Value when that is lower than the price
+	PromotionTypeBuyXGetY   = "buy_x_get_y" // Buy BuyQuantity and get GetQuantity free; the unit price does not change
+)
+
+// Promotion statuses, derived from IsActive and the date window
+const (
+	PromotionStatusRunning   = "running"
+	PromotionStatusScheduled = "scheduled"
+	PromotionStatusEnded     = "ended"
+	PromotionStatusInactive  = "inactive"
+)
+
+// Promotion is a discount rule applied to the matching products between StartsAt and EndsAt.
+// Matching promotions apply from the highest priority down: the first always applies, a promotion
+// that is not stackable applies only on its own, and stackable ones apply on top of each other.
+type Promotion struct {
+	ID            int                 `json:"id"`
+	Nama          string              `json:"nama"`
+	Deskripsi     string              `json:"deskripsi"`
+	Type          string              `json:"type"`
+	Value         float64             `json:"value"`
+	BuyQuantity   int                 `json:"buy_quantity,omitempty"`
+	GetQuantity   int                 `json:"get_quantity,omitempty"`
+	Targets       map[string][]string `json:"targets"`     // Product filter fields to the values they match; every field must match, none matches all products
+	ProductNos    []int               `json:"product_nos"` // Limits the promotion to these products when not empty
+	StartsAt      time.Time           `json:"starts_at"`
+	EndsAt        *time.Time          `json:"ends_at"` // Null runs until the promotion is deactivated
+	Priority      int                 `json:"priority"`
+	Stackable     bool                `json:"stackable"`
+	IsActive      bool                `json:"is_active"`
+	Status        string              `json:"status"`
+	CreatedBy     string              `json:"created_by"`
+	DiupdateOleh  string              `json:"diupdate_oleh"`
+	TanggalUpdate time.Time           `json:"tanggal_update"`
+	TanggalHapus  *time.Time          `json:"tanggal_hapus,omitempty"`
+}
+
+// PromotionRequest is the request body for creating or replacing a promotion
+type PromotionRequest struct {
+	Nama        string              `json:"nama" binding:"required"`
+	Deskripsi   string              `json:"deskripsi"`
+	Type        string              `json:"type" binding:"required"`
+	Value       float64             `json:"value"` // Unused by buy_x_get_y
+	BuyQuantity int                 `json:"buy_quantity"`
+	GetQuantity int                 `json:"get_quantity"`
+	Targets     map[string][]string `json:"targets"`
+	ProductNos  []int               `json:"product_nos"`
+	StartsAt    string              `json:"starts_at" binding:"required"` // RFC 3339
+	EndsAt      string              `json:"ends_at"`                      // RFC 3339, optional
+	Priority    int                 `json:"priority"`
+	Stackable   bool                `json:"stackable"`
+	IsActive    *bool               `json:"is_active"` // Defaults to true
+
+	Start time.Time  `json:"-"` // Parsed from StartsAt during validation
+	End   *time.Time `json:"-"` // Parsed from EndsAt during validation
+}
+
+// AppliedPromotion is a promotion applied to the price of a product, or offered on it at cart level, in the public product responses
+type AppliedPromotion struct {
+	ID          int        `json:"id"`
+	Nama        string     `json:"nama"`
+	Type        string     `json:"type"`
+	Value       float64    `json:"value"`
+	BuyQuantity int        `json:"buy_quantity,omitempty"`
+	GetQuantity int        `json:"get_quantity,omitempty"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Potongan    float64    `json:"potongan"` // Amount this promotion took off the unit price, 0 for cart offers
+}
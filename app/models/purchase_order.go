@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+)
+
+// Purchase order statuses
+const (
+	PurchaseOrderStatusDraft             = "draft"              // Being prepared, not yet sent to the supplier
+	PurchaseOrderStatusSent              = "sent"               // Sent to the supplier, nothing received yet
+	PurchaseOrderStatusPartiallyReceived = "partially_received" // Some lines have been received through goods receipts
+	PurchaseOrderStatusClosed            = "closed"             // Fully received or closed short by hand
+	PurchaseOrderStatusCancelled         = "cancelled"          // Abandoned before anything was received
+)
+
+// PurchaseOrder is an order placed with a supplier for goods that are expected to arrive at a location
+type PurchaseOrder struct {
+	ID                int                 `json:"id"`
+	Nomor             string              `json:"nomor"` // Document number, e.g. PO-20240101-000001
+	SupplierID        int                 `json:"supplier_id"`
+	Supplier          string              `json:"supplier"`
+	LocationID        int                 `json:"location_id"` // Location the goods are delivered to
+	LocationName      string              `json:"location_name,omitempty"`
+	TanggalOrder      time.Time           `json:"tanggal_order"`
+	TanggalKedatangan time.Time           `json:"tanggal_kedatangan"` // Expected arrival date; open orders past it are overdue
+	Status            string              `json:"status"`
+	Notes             string              `json:"notes"`
+	Items             []PurchaseOrderItem `json:"items"`
+	TotalQuantity     int                 `json:"total_quantity"`
+	TotalReceived     int                 `json:"total_received"`
+	TotalOutstanding  int                 `json:"total_outstanding"`
+	TotalAmount       float64             `json:"total_amount"` // Sum of quantity × unit cost of all lines
+	CreatedBy         string              `json:"created_by"`
+	CreatedAt         time.Time           `json:"created_at"`
+	SentBy            string              `json:"sent_by,omitempty"`
+	SentAt            *time.Time          `json:"sent_at,omitempty"`
+	ClosedBy          string              `json:"closed_by,omitempty"`
+	ClosedAt          *time.Time          `json:"closed_at,omitempty"`
+	TanggalUpdate     time.Time           `json:"tanggal_update"`
+}
+
+// PurchaseOrderItem is a single variant line of a purchase order. Received quantities come from posted goods receipts.
+type PurchaseOrderItem struct {
+	ID                  int     `json:"id"`
+	ProductNo           int     `json:"product_no"`
+	Artikel             string  `json:"artikel,omitempty"`
+	ColorID             int     `json:"color_id"`
+	ColorName           string  `json:"color_name,omitempty"`
+	Size                string  `json:"size"`
+	Quantity            int     `json:"quantity"`
+	UnitCost            float64 `json:"unit_cost"`
+	ReceivedQuantity    int     `json:"received_quantity"`
+	OutstandingQuantity int     `json:"outstanding_quantity"`
+}
+
+// PurchaseOrderItemInput is a single variant line sent when creating or editing a purchase order
+type PurchaseOrderItemInput struct {
+	ProductNo int     `json:"product_no" binding:"required"`
+	ColorID   int     `json:"color_id" binding:"required"`
+	Size      string  `json:"size" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitCost  float64 `json:"unit_cost" binding:"gte=0"`
+}
+
+// SavePurchaseOrderRequest is the request body for creating or editing a draft purchase order
+type SavePurchaseOrderRequest struct {
+	SupplierID        int                      `json:"supplier_id" binding:"required"`
+	LocationID        int                      `json:"location_id"`                           // Optional, defaults to the default location
+	TanggalOrder      string                   `json:"tanggal_order"`                         // YYYY-MM-DD, defaults to today
+	TanggalKedatangan string                   `json:"tanggal_kedatangan" binding:"required"` // YYYY-MM-DD
+	Notes             string                   `json:"notes"`
+	Items             []PurchaseOrderItemInput `json:"items" binding:"required,min=1,dive"`
+
+	SupplierName string    `json:"-"` // Resolved from SupplierID during validation
+	OrderDate    time.Time `json:"-"` // Parsed from TanggalOrder during validation
+	ExpectedDate time.Time `json:"-"` // Parsed from TanggalKedatangan during validation
+}
+
+// OverduePurchaseOrder is an open purchase order whose expected arrival date has passed
+type OverduePurchaseOrder struct {
+	PurchaseOrder
+	DaysOverdue int `json:"days_overdue"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is a stored refresh token. Every refresh replaces the token with a new one in the
+// same family, so a family is one login session; presenting a replaced token revokes the family.
+type RefreshToken struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	FamilyID        string     `json:"family_id"`
+	TokenHash       string     `json:"-"`
+	AccessJTI       string     `json:"-"` // ID of the access token issued with this refresh token
+	AccessExpiresAt time.Time  `json:"-"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	UserAgent       string     `json:"user_agent"`
+	IPAddress       string     `json:"ip_address"`
+	TwoFactor       bool       `json:"two_factor"` // The session was verified with a second factor
+	CreatedAt       time.Time  `json:"created_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshTokenRequest is the request body for refreshing a session or logging out
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TwoFactorCodeRequest is the request body of two-factor actions that need a current code.
+// Code is a TOTP code or, where allowed, a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest is the request body of the second login step
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnrollment is returned when a user starts two-factor enrollment
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorChallenge is returned by the first login step when the account uses two-factor authentication
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	MFAToken          string    `json:"mfa_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
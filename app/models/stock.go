@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductStock represents the on-hand quantity of a single product variant (color × size)
+type ProductStock struct {
+	ColorID       int             `json:"color_id"`
+	ColorName     string          `json:"color_name,omitempty"`
+	Size          string          `json:"size"`
+	Quantity      int             `json:"quantity"`                 // On-hand total across all locations
+	Reserved      int             `json:"reserved"`                 // Held by active reservations
+	Available     int             `json:"available"`                // On hand minus reserved, what can still be sold
+	Locations     []LocationStock `json:"locations,omitempty"`      // Breakdown per location holding the variant
+	TanggalUpdate *time.Time      `json:"tanggal_update,omitempty"` // Null when the variant has never been stocked
+}
+
+// LocationStock is the on-hand quantity of a variant at one location
+type LocationStock struct {
+	LocationID   int    `json:"location_id"`
+	LocationName string `json:"location_name"`
+	Quantity     int    `json:"quantity"` // On hand
+	Reserved     int    `json:"reserved"`
+	Available    int    `json:"available"`
+}
+
+// StockQuantityInput is a single variant quantity sent by the admin stock endpoint
+type StockQuantityInput struct {
+	ColorID  int    `json:"color_id" binding:"required"`
+	Size     string `json:"size" binding:"required"`
+	Quantity int    `json:"quantity"`
+}
+
+// SetStockRequest is the request body for setting variant quantities of a product
+type SetStockRequest struct {
+	LocationID int                  `json:"location_id"` // Optional, defaults to the default location
+	Stocks     []StockQuantityInput `json:"stocks" binding:"required,dive"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock movement reason codes
+const (
+	StockReasonOpening    = "opening"    // Balance that existed before the ledger was introduced
+	StockReasonReceipt    = "receipt"    // Goods received from a supplier
+	StockReasonSale       = "sale"       // Goods sold to a customer
+	StockReasonReturn     = "return"     // Goods returned by a customer
+	StockReasonAdjustment = "adjustment" // Manual correction of the on-hand quantity
+	StockReasonDamage     = "damage"     // Goods written off as damaged or lost
+	StockReasonTransfer   = "transfer"   // Goods moved between locations
+)
+
+// StockMovement represents an immutable row of the stock ledger
+type StockMovement struct {
+	ID           int       `json:"id"`
+	ProductNo    int       `json:"product_no"`
+	Artikel      string    `json:"artikel,omitempty"`
+	ColorID      int       `json:"color_id"`
+	ColorName    string    `json:"color_name,omitempty"`
+	Size         string    `json:"size"`
+	LocationID   int       `json:"location_id"`
+	LocationName string    `json:"location_name,omitempty"`
+	Quantity     int       `json:"quantity"`      // Signed delta applied to the on-hand quantity
+	BalanceAfter int       `json:"balance_after"` // Running on-hand balance of the variant after this movement
+	Reason       string    `json:"reason"`
+	Reference    string    `json:"reference"` // Reference document, e.g. invoice or delivery note number
+	Notes        string    `json:"notes"`
+	UserID       string    `json:"user_id"`
+	Username     string    `json:"username"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateStockMovementRequest is the request body for recording a manual stock movement
+type CreateStockMovementRequest struct {
+	ProductNo  int    `json:"product_no" binding:"required"`
+	ColorID    int    `json:"color_id" binding:"required"`
+	Size       string `json:"size" binding:"required"`
+	LocationID int    `json:"location_id"` // Optional, defaults to the default location
+	Quantity   int    `json:"quantity" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	Reference  string `json:"reference"`
+	Notes      string `json:"notes"`
+}
+
+// StockReconciliation compares the stored on-hand quantity of a variant with its ledger balance
+type StockReconciliation struct {
+	ColorID       int    `json:"color_id"`
+	Size          string `json:"size"`
+	LocationID    int    `json:"location_id"`
+	OnHand        int    `json:"on_hand"`
+	LedgerBalance int    `json:"ledger_balance"`
+	Difference    int    `json:"difference"`
+}
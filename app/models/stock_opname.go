@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock opname statuses
+const (
+	StockOpnameStatusOpen      = "open"      // Counting in progress
+	StockOpnameStatusSubmitted = "submitted" // Counting finished, waiting for approval
+	StockOpnameStatusApproved  = "approved"  // Variances posted to the ledger as adjustments
+	StockOpnameStatusCancelled = "cancelled" // Abandoned without touching stock
+)
+
+// StockOpname is a physical count session for one location, optionally limited to a product filter
+type StockOpname struct {
+	ID            int               `json:"id"`
+	Nomor         string            `json:"nomor"` // Document number, e.g. OPN-20240101-000001
+	LocationID    int               `json:"location_id"`
+	LocationName  string            `json:"location_name,omitempty"`
+	Filters       map[string]string `json:"filters"` // Product filters that defined the scope, same keys as the product list
+	Status        string            `json:"status"`
+	Notes         string            `json:"notes"`
+	Items         []StockOpnameItem `json:"items,omitempty"`
+	TotalItems    int               `json:"total_items"`
+	CountedItems  int               `json:"counted_items"`
+	TotalVariance int               `json:"total_variance"` // Sum of the variances of counted items
+	CreatedBy     string            `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	SubmittedBy   string            `json:"submitted_by,omitempty"`
+	SubmittedAt   *time.Time        `json:"submitted_at,omitempty"`
+	ApprovedBy    string            `json:"approved_by,omitempty"`
+	ApprovedAt    *time.Time        `json:"approved_at,omitempty"`
+	TanggalUpdate time.Time         `json:"tanggal_update"`
+}
+
+// StockOpnameItem is the book and counted quantity of one variant in a count session
+type StockOpnameItem struct {
+	ID              int        `json:"id"`
+	ProductNo       int        `json:"product_no"`
+	Artikel         string     `json:"artikel,omitempty"`
+	ColorID         int        `json:"color_id"`
+	ColorName       string     `json:"color_name,omitempty"`
+	Size            string     `json:"size"`
+	BookQuantity    int        `json:"book_quantity"`    // On-hand quantity when the session was opened
+	CountedQuantity *int       `json:"counted_quantity"` // Null until the variant has been counted
+	Variance        *int       `json:"variance"`         // Counted minus book, null until counted
+	CountedBy       string     `json:"counted_by,omitempty"`
+	CountedAt       *time.Time `json:"counted_at,omitempty"`
+}
+
+// CreateStockOpnameRequest is the request body for opening a count session
+type CreateStockOpnameRequest struct {
+	LocationID int               `json:"location_id"` // Optional, defaults to the default location
+	Filters    map[string]string `json:"filters"`     // Optional, e.g. {"kat": "3", "supplier": "ABC"}
+	Notes      string            `json:"notes"`
+}
+
+// StockOpnameCountInput is a counted quantity for one variant
+type StockOpnameCountInput struct {
+	ProductNo       int    `json:"product_no" binding:"required"`
+	ColorID         int    `json:"color_id" binding:"required"`
+	Size            string `json:"size" binding:"required"`
+	CountedQuantity *int   `json:"counted_quantity" binding:"required,min=0"`
+}
+
+// SubmitStockOpnameCountsRequest is the request body for recording counted quantities
+type SubmitStockOpnameCountsRequest struct {
+	Items []StockOpnameCountInput `json:"items" binding:"required,min=1,dive"`
+}
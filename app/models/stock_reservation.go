@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock reservation statuses
+const (
+	StockReservationStatusActive    = "active"    // Holding stock until it expires
+	StockReservationStatusFulfilled = "fulfilled" // Converted into a sale movement
+	StockReservationStatusReleased  = "released"  // Released by hand before it expired
+	StockReservationStatusExpired   = "expired"   // Released by the sweeper after expires_at
+)
+
+// StockReservation holds a quantity of a variant at a location for a customer order that has not shipped yet.
+// Active reservations reduce the available quantity but not the on-hand quantity.
+type StockReservation struct {
+	ID            int        `json:"id"`
+	ProductNo     int        `json:"product_no"`
+	Artikel       string     `json:"artikel,omitempty"`
+	ColorID       int        `json:"color_id"`
+	ColorName     string     `json:"color_name,omitempty"`
+	Size          string     `json:"size"`
+	LocationID    int        `json:"location_id"`
+	LocationName  string     `json:"location_name,omitempty"`
+	Quantity      int        `json:"quantity"`
+	Reference     string     `json:"reference"` // Customer order reference, e.g. WhatsApp order or shop receipt number
+	Notes         string     `json:"notes"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ClosedBy      string     `json:"closed_by,omitempty"` // Who fulfilled or released the reservation
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	TanggalUpdate time.Time  `json:"tanggal_update"`
+}
+
+// CreateStockReservationRequest is the request body for holding stock
+type CreateStockReservationRequest struct {
+	ProductNo  int    `json:"product_no" binding:"required"`
+	ColorID    int    `json:"color_id" binding:"required"`
+	Size       string `json:"size" binding:"required"`
+	LocationID int    `json:"location_id"` // Optional, defaults to the default location
+	Quantity   int    `json:"quantity" binding:"required,gt=0"`
+	Reference  string `json:"reference" binding:"required"`
+	Notes      string `json:"notes"`
+	ExpiresAt  string `json:"expires_at"` // RFC 3339, defaults to 24 hours from now
+
+	Expiry time.Time `json:"-"` // Parsed from ExpiresAt during validation
+}
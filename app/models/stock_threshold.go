@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock alert statuses
+const (
+	StockAlertStatusOpen     = "open"     // Variant is below its minimum stock
+	StockAlertStatusResolved = "resolved" // Variant went back to or above its minimum stock
+)
+
+// StockThreshold is the minimum stock and reorder quantity of a product variant.
+// A threshold with color 0 and an empty size is the default for every variant of the product.
+type StockThreshold struct {
+	ColorID         int       `json:"color_id"`
+	ColorName       string    `json:"color_name,omitempty"`
+	Size            string    `json:"size"`
+	MinStock        int       `json:"min_stock"`        // Variants with less on hand than this are low on stock
+	ReorderQuantity int       `json:"reorder_quantity"` // Quantity to order when the variant is low
+	UpdatedBy       string    `json:"updated_by"`
+	TanggalUpdate   time.Time `json:"tanggal_update"`
+}
+
+// ProductStockThresholds holds the product default threshold and the variant overrides of a product
+type ProductStockThresholds struct {
+	ProductNo int              `json:"product_no"`
+	Default   *StockThreshold  `json:"default"` // Null when the product has no default threshold
+	Variants  []StockThreshold `json:"variants"`
+}
+
+// StockThresholdValues is the minimum stock and reorder quantity sent for a product default
+type StockThresholdValues struct {
+	MinStock        int `json:"min_stock" binding:"gte=0"`
+	ReorderQuantity int `json:"reorder_quantity" binding:"gte=0"`
+}
+
+// VariantStockThresholdInput is the threshold of a single variant sent by the admin threshold endpoint
+type VariantStockThresholdInput struct {
+	ColorID         int    `json:"color_id" binding:"required"`
+	Size            string `json:"size" binding:"required"`
+	MinStock        int    `json:"min_stock" binding:"gte=0"`
+	ReorderQuantity int    `json:"reorder_quantity" binding:"gte=0"`
+}
+
+// SetStockThresholdsRequest is the request body for replacing all thresholds of a product
+type SetStockThresholdsRequest struct {
+	Default  *StockThresholdValues        `json:"default"` // Optional, null removes the product default
+	Variants []VariantStockThresholdInput `json:"variants" binding:"dive"`
+}
+
+// LowStockVariant is a product variant whose total on-hand quantity is below its minimum stock
+type LowStockVariant struct {
+	ProductNo       int    `json:"product_no"`
+	Artikel         string `json:"artikel"`
+	Nama            string `json:"nama"`
+	Kat             string `json:"kat"`
+	Grup            string `json:"grup"`
+	Supplier        string `json:"supplier"`
+	ColorID         int    `json:"color_id"`
+	ColorName       string `json:"color_name,omitempty"`
+	Size            string `json:"size"`
+	Quantity        int    `json:"quantity"` // Total across all locations
+	MinStock        int    `json:"min_stock"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	Incoming        int    `json:"incoming"` // Outstanding quantity on open purchase orders
+}
+
+// StockAlert records a variant dropping below its minimum stock, as found by the background checker
+type StockAlert struct {
+	ID              int        `json:"id"`
+	ProductNo       int        `json:"product_no"`
+	Artikel         string     `json:"artikel,omitempty"`
+	ColorID         int        `json:"color_id"`
+	ColorName       string     `json:"color_name,omitempty"`
+	Size            string     `json:"size"`
+	Quantity        int        `json:"quantity"` // On-hand quantity when the alert was raised
+	MinStock        int        `json:"min_stock"`
+	ReorderQuantity int        `json:"reorder_quantity"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	NotifiedAt      *time.Time `json:"notified_at,omitempty"` // When the alert was delivered to the webhook
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// Stock transfer statuses
+const (
+	StockTransferStatusDraft     = "draft"     // Being prepared, stock has not moved yet
+	StockTransferStatusShipped   = "shipped"   // Left the source location and is in transit
+	StockTransferStatusReceived  = "received"  // Arrived at the destination location
+	StockTransferStatusCancelled = "cancelled" // Abandoned before it was shipped
+)
+
+// StockTransfer is a document moving stock from one location to another
+type StockTransfer struct {
+	ID               int                 `json:"id"`
+	Nomor            string              `json:"nomor"` // Document number, e.g. TRF-20240101-000001
+	FromLocationID   int                 `json:"from_location_id"`
+	FromLocationName string              `json:"from_location_name,omitempty"`
+	ToLocationID     int                 `json:"to_location_id"`
+	ToLocationName   string              `json:"to_location_name,omitempty"`
+	Status           string              `json:"status"`
+	Notes            string              `json:"notes"`
+	Items            []StockTransferItem `json:"items"`
+	TotalQuantity    int                 `json:"total_quantity"`
+	CreatedBy        string              `json:"created_by"`
+	CreatedAt        time.Time           `json:"created_at"`
+	ShippedBy        string              `json:"shipped_by,omitempty"`
+	ShippedAt        *time.Time          `json:"shipped_at,omitempty"`
+	ReceivedBy       string              `json:"received_by,omitempty"`
+	ReceivedAt       *time.Time          `json:"received_at,omitempty"`
+	TanggalUpdate    time.Time           `json:"tanggal_update"`
+}
+
+// StockTransferItem is a single variant line of a stock transfer
+type StockTransferItem struct {
+	ID        int    `json:"id"`
+	ProductNo int    `json:"product_no"`
+	Artikel   string `json:"artikel,omitempty"`
+	ColorID   int    `json:"color_id"`
+	ColorName string `json:"color_name,omitempty"`
+	Size      string `json:"size"`
+	Quantity  int    `json:"quantity"`
+}
+
+// StockTransferItemInput is a single variant line sent when creating or editing a transfer
+type StockTransferItemInput struct {
+	ProductNo int    `json:"product_no" binding:"required"`
+	ColorID   int    `json:"color_id" binding:"required"`
+	Size      string `json:"size" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// SaveStockTransferRequest is the request body for creating or editing a draft transfer
+type SaveStockTransferRequest struct {
+	FromLocationID int                      `json:"from_location_id" binding:"required"`
+	ToLocationID   int                      `json:"to_location_id" binding:"required"`
+	Notes          string                   `json:"notes"`
+	Items          []StockTransferItemInput `json:"items" binding:"required,min=1,dive"`
+}
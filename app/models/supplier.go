@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Supplier represents a supplier record in the database
+type Supplier struct {
+	ID               int        `json:"id"`
+	Nama             string     `json:"nama"`
+	Kontak           string     `json:"kontak"` // Contact person
+	Telepon          string     `json:"telepon"`
+	Email            string     `json:"email"`
+	Alamat           string     `json:"alamat"`
+	TerminPembayaran *int       `json:"termin_pembayaran"` // Payment terms in days, e.g. 30 for NET 30
+	Catatan          string     `json:"catatan"`
+	TanggalUpdate    time.Time  `json:"tanggal_update"`
+	TanggalHapus     *time.Time `json:"tanggal_hapus,omitempty"`
+}
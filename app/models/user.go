@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/everysoft/inventary-be/app/auth"
+)
+
+// Role is an assignable role and the permissions it grants
+type Role struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateUserRoleRequest is the request body for assigning a role to a user
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// InviteUserRequest is the request body for an admin creating a user account
+type InviteUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UserCredentials is returned when an admin creates a user or resets a password.
+// The temporary password is only shown in this response and must be handed to the user.
+type UserCredentials struct {
+	User              auth.User `json:"user"`
+	TemporaryPassword string    `json:"temporary_password"`
+}
+
+// Purposes of single-use tokens sent to users by email
+const (
+	UserTokenPurposePasswordReset     = "password_reset"
+	UserTokenPurposeEmailVerification = "email_verification"
+)
+
+// ForgotPasswordRequest is the request body for requesting a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest is the request body for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// VerifyEmailRequest is the request body for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// Inventory valuation methods
+const (
+	ValuationMethodFIFO    = "fifo"    // Stock leaves oldest cost layer first
+	ValuationMethodAverage = "average" // Moving-average cost, recomputed on every costed receipt
+)
+
+// Inventory valuation groupings
+const (
+	ValuationGroupProduct  = "product"
+	ValuationGroupKat      = "kat"
+	ValuationGroupLocation = "location"
+)
+
+// InventoryValuation is the value of the stock on hand at the end of a given date
+type InventoryValuation struct {
+	AsOf          string                  `json:"as_of"` // YYYY-MM-DD, movements up to the end of this day are included
+	Method        string                  `json:"method"`
+	GroupBy       string                  `json:"group_by"`
+	Rows          []InventoryValuationRow `json:"rows"`
+	TotalQuantity int                     `json:"total_quantity"`
+	TotalValue    float64                 `json:"total_value"`
+}
+
+// InventoryValuationRow is the quantity and value of one product, kat or location.
+// Stock shipped by a transfer but not yet received is reported under location 0, "In transit".
+type InventoryValuationRow struct {
+	ProductNo    int     `json:"product_no,omitempty"`
+	Artikel      string  `json:"artikel,omitempty"`
+	Nama         string  `json:"nama,omitempty"`
+	Kat          string  `json:"kat,omitempty"`
+	LocationID   *int    `json:"location_id,omitempty"`
+	LocationName string  `json:"location_name,omitempty"`
+	Quantity     int     `json:"quantity"`
+	Value        float64 `json:"value"`
+	UnitCost     float64 `json:"unit_cost"` // Value divided by quantity
+}
@@ -0,0 +1,188 @@
+// Package promotions works out the effective price of products from the running promotions.
+package promotions
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// productFieldValue returns the value of a product filter field of a product
+func productFieldValue(product models.Product, field string) string {
+	switch field {
+	case "warna":
+		return product.Warna
+	case "size":
+		return product.Size
+	case "grup":
+		return product.Grup
+	case "unit":
+		return product.Unit
+	case "kat":
+		return product.Kat
+	case "model":
+		return product.Model
+	case "gender":
+		return product.Gender
+	case "tipe":
+		return product.Tipe
+	case "status":
+		return product.Status
+	case "supplier":
+		return product.Supplier
+	}
+	return ""
+}
+
+// Matches reports whether a promotion targets a product. Every target field must match one of its values,
+// compared case-insensitively; warna matches when the product comes in any of the listed color IDs.
+func Matches(promotion models.Promotion, product models.Product) bool {
+	if len(promotion.ProductNos) > 0 {
+		listed := false
+		for _, no := range promotion.ProductNos {
+			if strconv.Itoa(no) == product.No {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			return false
+		}
+	}
+
+	for field, values := range promotion.Targets {
+		if len(values) == 0 {
+			continue
+		}
+
+		matched := false
+		if field == "warna" {
+			colors := make(map[int]bool)
+			for _, id := range db.ParseColorIDs(product.Warna) {
+				colors[id] = true
+			}
+			for _, value := range values {
+				if id, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && colors[id] {
+					matched = true
+					break
+				}
+			}
+		} else {
+			productValue := strings.TrimSpace(productFieldValue(product, field))
+			for _, value := range values {
+				if strings.EqualFold(strings.TrimSpace(value), productValue) {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// appliedPromotion describes a promotion in the public product responses
+func appliedPromotion(promotion models.Promotion, potongan float64) models.AppliedPromotion {
+	return models.AppliedPromotion{
+		ID:          promotion.ID,
+		Nama:        promotion.Nama,
+		Type:        promotion.Type,
+		Value:       promotion.Value,
+		BuyQuantity: promotion.BuyQuantity,
+		GetQuantity: promotion.GetQuantity,
+		EndsAt:      promotion.EndsAt,
+		Potongan:    potongan,
+	}
+}
+
+// EffectivePrice applies the matching unit price promotions, highest priority first, to a base price and returns
+// the resulting price with the promotions that were applied. Buy X get Y promotions depend on the quantity in the
+// cart and are skipped. The first promotion always applies; if it is not stackable it applies alone, otherwise the
+// later stackable promotions apply on top of it.
+func EffectivePrice(base float64, matching []models.Promotion) (float64, []models.AppliedPromotion) {
+	price := base
+	applied := []models.AppliedPromotion{}
+	firstStackable := false
+	for _, promotion := range matching {
+		if promotion.Type == models.PromotionTypeBuyXGetY {
+			continue
+		}
+		if len(applied) > 0 && (!firstStackable || !promotion.Stackable) {
+			continue
+		}
+		if len(applied) == 0 {
+			firstStackable = promotion.Stackable
+		}
+
+		before := price
+		switch promotion.Type {
+		case models.PromotionTypePercentage:
+			price -= price * promotion.Value / 100
+		case models.PromotionTypeAmount:
+			price -= promotion.Value
+		case models.PromotionTypeFixedPrice:
+			price = math.Min(price, promotion.Value)
+		}
+		price = math.Max(0, math.Round(price*100)/100)
+
+		applied = append(applied, appliedPromotion(promotion, math.Round((before-price)*100)/100))
+	}
+	return price, applied
+}
+
+// Apply sets the effective price of a product and its variants from the running promotions, which must be
+// ordered by priority, and lists the matching buy X get Y promotions as cart offers. Without a matching unit
+// price promotion the effective price is the manual harga_diskon, if any.
+func Apply(product *models.Product, running []models.Promotion) {
+	matching := []models.Promotion{}
+	product.CartOffers = nil
+	for _, promotion := range running {
+		if !Matches(promotion, *product) {
+			continue
+		}
+		if promotion.Type == models.PromotionTypeBuyXGetY {
+			product.CartOffers = append(product.CartOffers, appliedPromotion(promotion, 0))
+			continue
+		}
+		matching = append(matching, promotion)
+	}
+
+	if len(matching) == 0 {
+		price := product.Harga
+		if product.HargaDiskon != nil {
+			price = *product.HargaDiskon
+		}
+		product.HargaEfektif = &price
+		for i := range product.Variants {
+			variantPrice := price
+			if product.Variants[i].Harga != nil {
+				variantPrice = *product.Variants[i].Harga
+			}
+			product.Variants[i].HargaEfektif = &variantPrice
+		}
+		return
+	}
+
+	price, applied := EffectivePrice(product.Harga, matching)
+	product.HargaEfektif = &price
+	product.Promotions = applied
+	for i := range product.Variants {
+		variantPrice := price
+		if product.Variants[i].Harga != nil {
+			variantPrice, _ = EffectivePrice(*product.Variants[i].Harga, matching)
+		}
+		product.Variants[i].HargaEfektif = &variantPrice
+	}
+}
+
+// ApplyAll sets the effective price of every product from the running promotions
+func ApplyAll(products []models.Product, running []models.Promotion) {
+	for i := range products {
+		Apply(&products[i], running)
+	}
+}
@@ -0,0 +1,28 @@
+package common
+
+import (
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// ResolveLocationID checks that a location exists and returns its ID.
+// A locationID of 0 resolves to the default location.
+func ResolveLocationID(fieldName string, locationID int) (int, *validation.ValidationError) {
+	if locationID == 0 {
+		id, err := db.FetchDefaultLocationID()
+		if err != nil {
+			return 0, &validation.ValidationError{
+				Error:      "No default location configured",
+				ErrorField: fieldName,
+			}
+		}
+		return id, nil
+	}
+
+	if validationErr := ValidateMasterDataID("master_locations", fieldName, strconv.Itoa(locationID)); validationErr != nil {
+		return 0, validationErr
+	}
+	return locationID, nil
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// ValidateProductVariant checks that a product exists and that the color × size variant is listed on it
+// or still holds stock. Fetched products are cached in products to avoid repeated lookups for multi-line documents.
+func ValidateProductVariant(products map[int]*models.Product, productNo, colorID int, size string, fieldPrefix string) *validation.ValidationError {
+	size = strings.TrimSpace(size)
+
+	product, ok := products[productNo]
+	if !ok {
+		p, err := db.FetchProductByID(productNo)
+		if err != nil {
+			if err.Error() == "not_found" {
+				return &validation.ValidationError{
+					Error:      fmt.Sprintf("Product %d not found", productNo),
+					ErrorField: fieldPrefix + "product_no",
+				}
+			}
+			return &validation.ValidationError{
+				Error:      "Error checking product: " + err.Error(),
+				ErrorField: fieldPrefix + "product_no",
+			}
+		}
+		product = &p
+		products[productNo] = product
+	}
+
+	for _, s := range product.Stocks {
+		if s.ColorID == colorID && s.Size == size {
+			return nil
+		}
+	}
+
+	return &validation.ValidationError{
+		Error:      fmt.Sprintf("Variant (color %d, size %s) does not exist for product %d", colorID, size, productNo),
+		ErrorField: fieldPrefix + "color_id",
+	}
+}
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// ResolveSupplier checks that a supplier exists and returns it, so documents can store its current name
+func ResolveSupplier(fieldName string, supplierID int) (models.Supplier, *validation.ValidationError) {
+	supplier, err := db.FetchSupplierByID(supplierID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			return supplier, &validation.ValidationError{
+				Error:      fieldName + " does not exist in master data",
+				ErrorField: fieldName,
+			}
+		}
+		return supplier, &validation.ValidationError{
+			Error:      "Error checking " + fieldName + ": " + err.Error(),
+			ErrorField: fieldName,
+		}
+	}
+	return supplier, nil
+}
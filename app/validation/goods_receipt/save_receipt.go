@@ -0,0 +1,123 @@
+package goods_receipt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// ValidateSaveReceipt checks the header and item lines of a goods receipt
+func ValidateSaveReceipt(req *models.SaveGoodsReceiptRequest) *validation.ValidationError {
+	supplier, validationErr := common.ResolveSupplier("supplier_id", req.SupplierID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.SupplierName = supplier.Nama
+
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.LocationID = locationID
+
+	receivedDate, err := time.Parse("2006-01-02", strings.TrimSpace(req.TanggalTerima))
+	if err != nil {
+		return &validation.ValidationError{
+			Error:      "Invalid date format for tanggal_terima, use YYYY-MM-DD",
+			ErrorField: "tanggal_terima",
+		}
+	}
+	if receivedDate.After(time.Now()) {
+		return &validation.ValidationError{
+			Error:      "Tanggal terima cannot be in the future",
+			ErrorField: "tanggal_terima",
+		}
+	}
+	req.ReceivedDate = receivedDate
+
+	products := make(map[int]*models.Product)
+	seen := make(map[string]bool)
+	for i := range req.Items {
+		item := &req.Items[i]
+		item.Size = strings.TrimSpace(item.Size)
+
+		if validationErr := common.ValidateProductVariant(products, item.ProductNo, item.ColorID, item.Size, "items."); validationErr != nil {
+			validationErr.Error = fmt.Sprintf("%s at index %d", validationErr.Error, i)
+			return validationErr
+		}
+
+		// The same variant may arrive twice only when the lines deliver different purchase order lines
+		key := fmt.Sprintf("%d|%d|%s|%d", item.ProductNo, item.ColorID, item.Size, item.PurchaseOrderItemID)
+		if seen[key] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Duplicate variant (product %d, color %d, size %s) at index %d", item.ProductNo, item.ColorID, item.Size, i),
+				ErrorField: "items",
+			}
+		}
+		seen[key] = true
+
+		if item.PurchaseOrderItemID != 0 {
+			if validationErr := validatePurchaseOrderLine(req.SupplierID, item); validationErr != nil {
+				validationErr.Error = fmt.Sprintf("%s at index %d", validationErr.Error, i)
+				return validationErr
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePurchaseOrderLine checks that a receipt line delivers an open purchase order line of the same supplier
+// and variant without exceeding its outstanding quantity. The outstanding quantity is checked again when posting.
+func validatePurchaseOrderLine(supplierID int, item *models.GoodsReceiptItemInput) *validation.ValidationError {
+	order, line, err := db.FetchPurchaseOrderLine(item.PurchaseOrderItemID)
+	if err != nil {
+		if err.Error() == "not_found" {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Purchase order line %d not found", item.PurchaseOrderItemID),
+				ErrorField: "items.purchase_order_item_id",
+			}
+		}
+		return &validation.ValidationError{
+			Error:      "Error checking purchase order line: " + err.Error(),
+			ErrorField: "items.purchase_order_item_id",
+		}
+	}
+
+	if order.Status != models.PurchaseOrderStatusSent && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return &validation.ValidationError{
+			Error:      fmt.Sprintf("Purchase order %s is %s and cannot be received against", order.Nomor, order.Status),
+			ErrorField: "items.purchase_order_item_id",
+		}
+	}
+	if order.SupplierID != supplierID {
+		return &validation.ValidationError{
+			Error:      fmt.Sprintf("Purchase order %s belongs to another supplier", order.Nomor),
+			ErrorField: "items.purchase_order_item_id",
+		}
+	}
+	if line.ProductNo != item.ProductNo || line.ColorID != item.ColorID || line.Size != item.Size {
+		return &validation.ValidationError{
+			Error:      fmt.Sprintf("Variant does not match purchase order line %d", line.ID),
+			ErrorField: "items.purchase_order_item_id",
+		}
+	}
+	if item.Quantity > line.OutstandingQuantity {
+		return &validation.ValidationError{
+			Error:      fmt.Sprintf("Quantity %d exceeds the %d outstanding on purchase order line %d", item.Quantity, line.OutstandingQuantity, line.ID),
+			ErrorField: "items.quantity",
+		}
+	}
+
+	// Lines without a cost of their own are costed at the ordered price
+	if item.UnitCost == 0 {
+		item.UnitCost = line.UnitCost
+	}
+
+	return nil
+}
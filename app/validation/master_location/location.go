@@ -0,0 +1,45 @@
+package master_location
+
+import (
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+)
+
+var validTypes = map[string]bool{
+	models.LocationTypeWarehouse:   true,
+	models.LocationTypeShop:        true,
+	models.LocationTypeConsignment: true,
+}
+
+// ValidateLocation normalizes and validates a location. On create, kode, nama and tipe are required;
+// on update only the fields that are present are checked.
+func ValidateLocation(l *models.Location, isCreate bool) *validation.ValidationError {
+	l.Kode = strings.ToUpper(strings.TrimSpace(l.Kode))
+	l.Nama = strings.TrimSpace(l.Nama)
+	l.Tipe = strings.ToLower(strings.TrimSpace(l.Tipe))
+	l.Alamat = strings.TrimSpace(l.Alamat)
+	l.URL = strings.TrimSpace(l.URL)
+
+	if isCreate {
+		if l.Kode == "" {
+			return &validation.ValidationError{Error: "Kode is required", ErrorField: "kode"}
+		}
+		if l.Nama == "" {
+			return &validation.ValidationError{Error: "Nama is required", ErrorField: "nama"}
+		}
+		if l.Tipe == "" {
+			l.Tipe = models.LocationTypeWarehouse
+		}
+	}
+
+	if l.Tipe != "" && !validTypes[l.Tipe] {
+		return &validation.ValidationError{
+			Error:      "Tipe must be one of 'warehouse', 'shop' or 'consignment'",
+			ErrorField: "tipe",
+		}
+	}
+
+	return nil
+}
@@ -452,6 +452,12 @@ func ValidateProduct(p *models.Product, schema ValidationSchema) *validation.Val
 		}
 	}
 
+	if strings.TrimSpace(p.Supplier) != "" {
+		if validationError := common.ValidateMasterDataID("master_suppliers", "supplier", p.Supplier); validationError != nil {
+			return validationError
+		}
+	}
+
 	// Validate diupdate_oleh (required)
 	if schema.DiupdateOlehRequired && strings.TrimSpace(p.DiupdateOleh) == "" {
 		return &validation.ValidationError{
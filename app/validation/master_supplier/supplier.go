@@ -0,0 +1,35 @@
+package master_supplier
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+)
+
+// ValidateSupplier trims the supplier fields and checks them. Nama is only required on create.
+func ValidateSupplier(s *models.Supplier, isCreate bool) *validation.ValidationError {
+	s.Nama = strings.TrimSpace(s.Nama)
+	s.Kontak = strings.TrimSpace(s.Kontak)
+	s.Telepon = strings.TrimSpace(s.Telepon)
+	s.Email = strings.TrimSpace(s.Email)
+	s.Alamat = strings.TrimSpace(s.Alamat)
+	s.Catatan = strings.TrimSpace(s.Catatan)
+
+	if isCreate && s.Nama == "" {
+		return &validation.ValidationError{Error: "Supplier name is required", ErrorField: "nama"}
+	}
+
+	if s.Email != "" {
+		if _, err := mail.ParseAddress(s.Email); err != nil {
+			return &validation.ValidationError{Error: "Invalid email address", ErrorField: "email"}
+		}
+	}
+
+	if s.TerminPembayaran != nil && *s.TerminPembayaran < 0 {
+		return &validation.ValidationError{Error: "Payment terms cannot be negative", ErrorField: "termin_pembayaran"}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package product_lifecycle
+
+import (
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+)
+
+// transitions lists the lifecycle statuses a product can move to from each status
+var transitions = map[string][]string{
+	models.ProductLifecycleDraft:     {models.ProductLifecycleInReview, models.ProductLifecyclePublished},
+	models.ProductLifecycleInReview:  {models.ProductLifecycleDraft, models.ProductLifecyclePublished},
+	models.ProductLifecyclePublished: {models.ProductLifecycleDraft, models.ProductLifecycleArchived},
+	models.ProductLifecycleArchived:  {models.ProductLifecycleDraft},
+}
+
+// PublishPermission returns the permission needed to publish, unpublish or archive a product and to schedule
+// it. With approval required only approvers may, otherwise anyone who can edit products.
+func PublishPermission(requireApproval bool) string {
+	if requireApproval {
+		return auth.PermissionProductsPublish
+	}
+	return auth.PermissionProductsWrite
+}
+
+// ValidateTransition checks that a product can move from one lifecycle status to another and returns the
+// permissions that allow it, any one of which is enough. With approval required a draft must be reviewed
+// before it is published.
+func ValidateTransition(from, to string, requireApproval bool) ([]string, *validation.ValidationError) {
+	allowed := false
+	for _, status := range transitions[from] {
+		if status == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, &validation.ValidationError{
+			Error:      "A " + from + " product cannot move to " + to,
+			ErrorField: "status",
+		}
+	}
+
+	switch {
+	case from == models.ProductLifecycleDraft && to == models.ProductLifecyclePublished && requireApproval:
+		return nil, &validation.ValidationError{
+			Error:      "Products must be submitted for review before they are published",
+			ErrorField: "status",
+		}
+	case to == models.ProductLifecycleInReview, from == models.ProductLifecycleArchived:
+		return []string{auth.PermissionProductsWrite}, nil
+	case from == models.ProductLifecycleInReview && to == models.ProductLifecycleDraft:
+		// Withdrawn by an editor or sent back by an approver
+		return []string{auth.PermissionProductsWrite, PublishPermission(requireApproval)}, nil
+	default:
+		return []string{PublishPermission(requireApproval)}, nil
+	}
+}
+
+// ValidateStatus normalizes a requested lifecycle status and checks that it exists
+func ValidateStatus(status *string) *validation.ValidationError {
+	*status = strings.ToLower(strings.TrimSpace(*status))
+	if _, ok := transitions[*status]; !ok {
+		return &validation.ValidationError{
+			Error:      "Status must be one of 'draft', 'in_review', 'published' or 'archived'",
+			ErrorField: "status",
+		}
+	}
+	return nil
+}
+
+// ValidatePublishSchedule parses the publish and unpublish times, which must be in the future with the
+// unpublish time after the publish time
+func ValidatePublishSchedule(req *models.ProductPublishScheduleRequest) *validation.ValidationError {
+	req.Publish, req.Unpublish = nil, nil
+
+	if value := strings.TrimSpace(req.PublishAt); value != "" {
+		publishAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &validation.ValidationError{
+				Error:      "Invalid date format for publish_at, use RFC 3339 (e.g. 2024-12-12T00:00:00+07:00)",
+				ErrorField: "publish_at",
+			}
+		}
+		if !publishAt.After(time.Now()) {
+			return &validation.ValidationError{Error: "Publish at must be in the future", ErrorField: "publish_at"}
+		}
+		req.Publish = &publishAt
+	}
+
+	if value := strings.TrimSpace(req.UnpublishAt); value != "" {
+		unpublishAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &validation.ValidationError{
+				Error:      "Invalid date format for unpublish_at, use RFC 3339 (e.g. 2024-12-31T23:59:59+07:00)",
+				ErrorField: "unpublish_at",
+			}
+		}
+		if !unpublishAt.After(time.Now()) {
+			return &validation.ValidationError{Error: "Unpublish at must be in the future", ErrorField: "unpublish_at"}
+		}
+		if req.Publish != nil && !unpublishAt.After(*req.Publish) {
+			return &validation.ValidationError{Error: "Unpublish at must be after publish at", ErrorField: "unpublish_at"}
+		}
+		req.Unpublish = &unpublishAt
+	}
+
+	return nil
+}
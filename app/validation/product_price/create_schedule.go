@@ -0,0 +1,37 @@
+package product_price
+
+import (
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+)
+
+// ValidateCreateSchedule checks the effective time and discount of a price schedule
+func ValidateCreateSchedule(req *models.CreatePriceScheduleRequest) *validation.ValidationError {
+	effective, err := time.Parse(time.RFC3339, strings.TrimSpace(req.EffectiveAt))
+	if err != nil {
+		return &validation.ValidationError{
+			Error:      "Invalid date format for effective_at, use RFC 3339 (e.g. 2024-01-31T00:00:00+07:00)",
+			ErrorField: "effective_at",
+		}
+	}
+	if !effective.After(time.Now()) {
+		return &validation.ValidationError{
+			Error:      "Effective at must be in the future",
+			ErrorField: "effective_at",
+		}
+	}
+	req.Effective = effective
+
+	if req.HargaDiskon != nil && (*req.HargaDiskon <= 0 || *req.HargaDiskon >= req.Harga) {
+		return &validation.ValidationError{
+			Error:      "Harga diskon must be greater than 0 and lower than harga",
+			ErrorField: "harga_diskon",
+		}
+	}
+
+	req.Notes = strings.TrimSpace(req.Notes)
+	return nil
+}
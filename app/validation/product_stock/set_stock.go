@@ -0,0 +1,68 @@
+package product_stock
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/db"
+)
+
+// ValidateSetStock checks that every item targets an existing variant of the product
+// and that quantities are not negative
+func ValidateSetStock(p *models.Product, items []models.StockQuantityInput) *validation.ValidationError {
+	if len(items) == 0 {
+		return &validation.ValidationError{
+			Error:      "At least one stock entry is required",
+			ErrorField: "stocks",
+		}
+	}
+
+	// Build the set of valid colors and sizes for this product
+	validColors := make(map[int]bool)
+	for _, id := range db.ParseColorIDs(p.Warna) {
+		validColors[id] = true
+	}
+	validSizes := make(map[string]bool)
+	for _, size := range db.ExpandSizes(p.Size) {
+		validSizes[size] = true
+	}
+
+	seen := make(map[string]bool)
+	for i, item := range items {
+		size := strings.TrimSpace(item.Size)
+
+		if !validColors[item.ColorID] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Color ID %d is not a color of this product at index %d", item.ColorID, i),
+				ErrorField: "stocks.color_id",
+			}
+		}
+
+		if !validSizes[size] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Size '%s' is not a size of this product at index %d", size, i),
+				ErrorField: "stocks.size",
+			}
+		}
+
+		if item.Quantity < 0 {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Quantity cannot be negative at index %d", i),
+				ErrorField: "stocks.quantity",
+			}
+		}
+
+		key := fmt.Sprintf("%d|%s", item.ColorID, size)
+		if seen[key] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Duplicate variant (color %d, size %s) at index %d", item.ColorID, size, i),
+				ErrorField: "stocks",
+			}
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package promotion
+
+import (
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+)
+
+var validTypes = map[string]bool{
+	models.PromotionTypePercentage: true,
+	models.PromotionTypeAmount:     true,
+	models.PromotionTypeFixedPrice: true,
+	models.PromotionTypeBuyXGetY:   true,
+}
+
+// ValidatePromotion normalizes and validates a promotion: its type and value, the target fields,
+// which must be product filter fields, and the date window
+func ValidatePromotion(req *models.PromotionRequest) *validation.ValidationError {
+	req.Nama = strings.TrimSpace(req.Nama)
+	req.Deskripsi = strings.TrimSpace(req.Deskripsi)
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+
+	if req.Nama == "" {
+		return &validation.ValidationError{Error: "Nama is required", ErrorField: "nama"}
+	}
+	if !validTypes[req.Type] {
+		return &validation.ValidationError{
+			Error:      "Type must be one of 'percentage', 'amount', 'fixed_price' or 'buy_x_get_y'",
+			ErrorField: "type",
+		}
+	}
+
+	switch req.Type {
+	case models.PromotionTypePercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			return &validation.ValidationError{Error: "Value must be between 0 and 100 percent", ErrorField: "value"}
+		}
+	case models.PromotionTypeAmount, models.PromotionTypeFixedPrice:
+		if req.Value <= 0 {
+			return &validation.ValidationError{Error: "Value must be greater than 0", ErrorField: "value"}
+		}
+	case models.PromotionTypeBuyXGetY:
+		if req.BuyQuantity < 1 {
+			return &validation.ValidationError{Error: "Buy quantity must be at least 1", ErrorField: "buy_quantity"}
+		}
+		if req.GetQuantity < 1 {
+			return &validation.ValidationError{Error: "Get quantity must be at least 1", ErrorField: "get_quantity"}
+		}
+		req.Value = 0
+	}
+	if req.Type != models.PromotionTypeBuyXGetY {
+		req.BuyQuantity, req.GetQuantity = 0, 0
+	}
+
+	validFields := make(map[string]bool)
+	for _, field := range helpers.ProductFilterFields {
+		validFields[field] = true
+	}
+	targets := make(map[string][]string)
+	for field, values := range req.Targets {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !validFields[field] {
+			return &validation.ValidationError{
+				Error:      "Unknown target field '" + field + "', use one of " + strings.Join(helpers.ProductFilterFields, ", "),
+				ErrorField: "targets",
+			}
+		}
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				targets[field] = append(targets[field], value)
+			}
+		}
+	}
+	req.Targets = targets
+
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(req.StartsAt))
+	if err != nil {
+		return &validation.ValidationError{
+			Error:      "Invalid date format for starts_at, use RFC 3339 (e.g. 2024-12-12T00:00:00+07:00)",
+			ErrorField: "starts_at",
+		}
+	}
+	req.Start = start
+
+	req.End = nil
+	if value := strings.TrimSpace(req.EndsAt); value != "" {
+		end, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &validation.ValidationError{
+				Error:      "Invalid date format for ends_at, use RFC 3339 (e.g. 2024-12-12T23:59:59+07:00)",
+				ErrorField: "ends_at",
+			}
+		}
+		if !end.After(start) {
+			return &validation.ValidationError{Error: "Ends at must be after starts at", ErrorField: "ends_at"}
+		}
+		req.End = &end
+	}
+
+	return nil
+}
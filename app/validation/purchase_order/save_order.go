@@ -0,0 +1,78 @@
+package purchase_order
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// ValidateSaveOrder checks the header and item lines of a purchase order
+func ValidateSaveOrder(req *models.SavePurchaseOrderRequest) *validation.ValidationError {
+	supplier, validationErr := common.ResolveSupplier("supplier_id", req.SupplierID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.SupplierName = supplier.Nama
+
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.LocationID = locationID
+
+	// The order date defaults to today
+	tanggalOrder := strings.TrimSpace(req.TanggalOrder)
+	if tanggalOrder == "" {
+		tanggalOrder = time.Now().Format("2006-01-02")
+	}
+	orderDate, err := time.Parse("2006-01-02", tanggalOrder)
+	if err != nil {
+		return &validation.ValidationError{
+			Error:      "Invalid date format for tanggal_order, use YYYY-MM-DD",
+			ErrorField: "tanggal_order",
+		}
+	}
+	req.OrderDate = orderDate
+
+	expectedDate, err := time.Parse("2006-01-02", strings.TrimSpace(req.TanggalKedatangan))
+	if err != nil {
+		return &validation.ValidationError{
+			Error:      "Invalid date format for tanggal_kedatangan, use YYYY-MM-DD",
+			ErrorField: "tanggal_kedatangan",
+		}
+	}
+	if expectedDate.Before(orderDate) {
+		return &validation.ValidationError{
+			Error:      "Tanggal kedatangan cannot be before tanggal order",
+			ErrorField: "tanggal_kedatangan",
+		}
+	}
+	req.ExpectedDate = expectedDate
+
+	products := make(map[int]*models.Product)
+	seen := make(map[string]bool)
+	for i := range req.Items {
+		item := &req.Items[i]
+		item.Size = strings.TrimSpace(item.Size)
+
+		if validationErr := common.ValidateProductVariant(products, item.ProductNo, item.ColorID, item.Size, "items."); validationErr != nil {
+			validationErr.Error = fmt.Sprintf("%s at index %d", validationErr.Error, i)
+			return validationErr
+		}
+
+		key := fmt.Sprintf("%d|%d|%s", item.ProductNo, item.ColorID, item.Size)
+		if seen[key] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Duplicate variant (product %d, color %d, size %s) at index %d", item.ProductNo, item.ColorID, item.Size, i),
+				ErrorField: "items",
+			}
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
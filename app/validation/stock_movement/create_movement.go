@@ -0,0 +1,63 @@
+package stock_movement
+
+import (
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// reasonDirections lists the reasons that can be recorded manually and the sign their quantity must have.
+// 1 means the quantity must be positive, -1 negative and 0 either. Receipts and transfers only move
+// stock through goods receipts and stock transfers, which keep their documents and batches in step.
+var reasonDirections = map[string]int{
+	models.StockReasonSale:       -1,
+	models.StockReasonReturn:     1,
+	models.StockReasonAdjustment: 0,
+	models.StockReasonDamage:     -1,
+}
+
+// ValidateCreateMovement checks a manual stock movement against its reason and the product's variants
+func ValidateCreateMovement(req *models.CreateStockMovementRequest) *validation.ValidationError {
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	req.Size = strings.TrimSpace(req.Size)
+
+	direction, ok := reasonDirections[req.Reason]
+	if !ok {
+		return &validation.ValidationError{
+			Error:      "Reason must be one of 'sale', 'return', 'adjustment' or 'damage', receipts and transfers are recorded with goods receipts and stock transfers",
+			ErrorField: "reason",
+		}
+	}
+
+	if req.Quantity == 0 {
+		return &validation.ValidationError{
+			Error:      "Quantity cannot be zero",
+			ErrorField: "quantity",
+		}
+	}
+
+	if direction > 0 && req.Quantity < 0 {
+		return &validation.ValidationError{
+			Error:      "Quantity must be positive for reason '" + req.Reason + "'",
+			ErrorField: "quantity",
+		}
+	}
+
+	if direction < 0 && req.Quantity > 0 {
+		return &validation.ValidationError{
+			Error:      "Quantity must be negative for reason '" + req.Reason + "'",
+			ErrorField: "quantity",
+		}
+	}
+
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.LocationID = locationID
+
+	// Stock can only move for variants that are listed on the product or still hold stock
+	return common.ValidateProductVariant(map[int]*models.Product{}, req.ProductNo, req.ColorID, req.Size, "")
+}
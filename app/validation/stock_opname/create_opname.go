@@ -0,0 +1,40 @@
+package stock_opname
+
+import (
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/helpers"
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// ValidateCreateOpname resolves the location of a new count session and checks its product filters
+func ValidateCreateOpname(req *models.CreateStockOpnameRequest) *validation.ValidationError {
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.LocationID = locationID
+
+	validFields := make(map[string]bool)
+	for _, field := range helpers.ProductFilterFields {
+		validFields[field] = true
+	}
+
+	filters := make(map[string]string)
+	for field, value := range req.Filters {
+		if !validFields[field] {
+			return &validation.ValidationError{
+				Error:      "Unknown filter '" + field + "', allowed filters are " + strings.Join(helpers.ProductFilterFields, ", "),
+				ErrorField: "filters",
+			}
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			filters[field] = value
+		}
+	}
+	req.Filters = filters
+
+	return nil
+}
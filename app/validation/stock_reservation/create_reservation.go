@@ -0,0 +1,51 @@
+package stock_reservation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// defaultHold is how long a reservation holds stock when no expiry is given
+const defaultHold = 24 * time.Hour
+
+// ValidateCreateReservation checks the variant, location and expiry of a reservation
+func ValidateCreateReservation(req *models.CreateStockReservationRequest) *validation.ValidationError {
+	req.Size = strings.TrimSpace(req.Size)
+
+	if strings.TrimSpace(req.Reference) == "" {
+		return &validation.ValidationError{
+			Error:      "Reference is required",
+			ErrorField: "reference",
+		}
+	}
+
+	req.Expiry = time.Now().Add(defaultHold)
+	if value := strings.TrimSpace(req.ExpiresAt); value != "" {
+		expiry, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &validation.ValidationError{
+				Error:      "Invalid date format for expires_at, use RFC 3339 (e.g. 2024-01-31T17:00:00+07:00)",
+				ErrorField: "expires_at",
+			}
+		}
+		if !expiry.After(time.Now()) {
+			return &validation.ValidationError{
+				Error:      "Expires at must be in the future",
+				ErrorField: "expires_at",
+			}
+		}
+		req.Expiry = expiry
+	}
+
+	locationID, validationErr := common.ResolveLocationID("location_id", req.LocationID)
+	if validationErr != nil {
+		return validationErr
+	}
+	req.LocationID = locationID
+
+	return common.ValidateProductVariant(map[int]*models.Product{}, req.ProductNo, req.ColorID, req.Size, "")
+}
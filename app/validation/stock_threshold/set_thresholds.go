@@ -0,0 +1,38 @@
+package stock_threshold
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// ValidateSetThresholds checks that every variant threshold refers to a variant of the product and appears only once
+func ValidateSetThresholds(product *models.Product, req *models.SetStockThresholdsRequest) *validation.ValidationError {
+	productNo, _ := strconv.Atoi(product.No)
+	products := map[int]*models.Product{productNo: product}
+	seen := make(map[string]bool)
+	for i := range req.Variants {
+		v := &req.Variants[i]
+		v.Size = strings.TrimSpace(v.Size)
+
+		if validationErr := common.ValidateProductVariant(products, productNo, v.ColorID, v.Size, "variants."); validationErr != nil {
+			validationErr.Error = fmt.Sprintf("%s at index %d", validationErr.Error, i)
+			return validationErr
+		}
+
+		key := fmt.Sprintf("%d|%s", v.ColorID, v.Size)
+		if seen[key] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Duplicate variant (color %d, size %s) at index %d", v.ColorID, v.Size, i),
+				ErrorField: "variants",
+			}
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
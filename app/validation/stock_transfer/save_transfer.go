@@ -0,0 +1,49 @@
+package stock_transfer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/everysoft/inventary-be/app/validation"
+	"github.com/everysoft/inventary-be/app/validation/common"
+)
+
+// ValidateSaveTransfer checks the locations and item lines of a transfer
+func ValidateSaveTransfer(req *models.SaveStockTransferRequest) *validation.ValidationError {
+	if _, validationErr := common.ResolveLocationID("from_location_id", req.FromLocationID); validationErr != nil {
+		return validationErr
+	}
+	if _, validationErr := common.ResolveLocationID("to_location_id", req.ToLocationID); validationErr != nil {
+		return validationErr
+	}
+	if req.FromLocationID == req.ToLocationID {
+		return &validation.ValidationError{
+			Error:      "Source and destination locations must differ",
+			ErrorField: "to_location_id",
+		}
+	}
+
+	products := make(map[int]*models.Product)
+	seen := make(map[string]bool)
+	for i := range req.Items {
+		item := &req.Items[i]
+		item.Size = strings.TrimSpace(item.Size)
+
+		if validationErr := common.ValidateProductVariant(products, item.ProductNo, item.ColorID, item.Size, "items."); validationErr != nil {
+			validationErr.Error = fmt.Sprintf("%s at index %d", validationErr.Error, i)
+			return validationErr
+		}
+
+		key := fmt.Sprintf("%d|%d|%s", item.ProductNo, item.ColorID, item.Size)
+		if seen[key] {
+			return &validation.ValidationError{
+				Error:      fmt.Sprintf("Duplicate variant (product %d, color %d, size %s) at index %d", item.ProductNo, item.ColorID, item.Size, i),
+				ErrorField: "items",
+			}
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
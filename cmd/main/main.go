@@ -13,9 +13,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/jobs"
+	"github.com/everysoft/inventary-be/app/mailer"
 	server "github.com/everysoft/inventary-be/cmd/routes"
 	"github.com/everysoft/inventary-be/db"
 	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/google/uuid"
 )
 
 // printSeedHelp prints detailed help about available seeders
@@ -33,14 +37,79 @@ func printSeedHelp() {
 	fmt.Println("\nExamples:")
 	fmt.Println("  ./main -seed                               # Run all seeders")
 	fmt.Println("  ./main -seed-specific colors,products,sizes # Run only colors, products and sizes seeders")
+	fmt.Println("  ./main -bootstrap-admin admin:admin@example.com # Create the first admin account")
 	fmt.Println("")
 }
 
+// bootstrapAdmin creates the first admin account from a username:email spec, or promotes the
+// existing user with that username. It refuses to run once an active admin exists.
+func bootstrapAdmin(spec string) error {
+	admins, err := db.CountActiveAdmins()
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return fmt.Errorf("an active admin already exists, manage users through /api/admin/users")
+	}
+
+	username, email, _ := strings.Cut(spec, ":")
+	username = strings.TrimSpace(username)
+	email = strings.TrimSpace(email)
+	if username == "" {
+		return fmt.Errorf("username is required")
+	}
+
+	if existing, err := db.GetUserByUsername(username); err == nil {
+		if _, err := db.UpdateUserRole(existing.ID, auth.RoleAdmin); err != nil {
+			return err
+		}
+		if err := db.SetUserActive(existing.ID, true); err != nil {
+			return err
+		}
+		log.Printf("Promoted existing user %s to admin", username)
+		return nil
+	}
+
+	if email == "" {
+		return fmt.Errorf("email is required to create a new admin, use username:email")
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	generated := password == ""
+	if generated {
+		if password, err = auth.GenerateTemporaryPassword(); err != nil {
+			return err
+		}
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := db.CreateUser(auth.User{
+		ID:       uuid.New().String(),
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     auth.RoleAdmin,
+		Active:   true,
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("Created admin %s", username)
+	if generated {
+		fmt.Printf("Temporary password for %s: %s\n", username, password)
+	}
+	return nil
+}
+
 func main() {
 	// Parse command line flags
 	seedFlag := flag.Bool("seed", false, "Run all database seeders and exit")
 	seedSpecific := flag.String("seed-specific", "", "Run specific seeders (comma-separated: colors,category_color_labels,products) and exit")
 	seedHelp := flag.Bool("seed-help", false, "Show information about available seeders")
+	bootstrapAdminFlag := flag.String("bootstrap-admin", "", "Create the first admin as username:email (or promote an existing username) and exit; the password is read from ADMIN_PASSWORD or generated")
 	flag.Parse()
 
 	// Show seeder help if requested
@@ -57,6 +126,15 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Token signing keys must be valid before anything can authenticate
+	if err := auth.Configure(config); err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+
+	if err := mailer.Configure(config.Mail); err != nil {
+		log.Fatalf("Invalid mail configuration: %v", err)
+	}
+
 	// Setup database
 	_, err = db.SetupDB(config)
 	if err != nil {
@@ -69,6 +147,17 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Revoked access tokens are rejected during validation
+	auth.SetRevocationCheck(db.IsTokenRevoked)
+
+	// Handle admin bootstrap
+	if *bootstrapAdminFlag != "" {
+		if err := bootstrapAdmin(*bootstrapAdminFlag); err != nil {
+			log.Fatalf("Failed to bootstrap admin: %v", err)
+		}
+		return
+	}
+
 	// Handle seeding
 	if *seedFlag {
 		log.Println("Seeding all tables...")
@@ -112,11 +201,21 @@ func main() {
 		}
 	}()
 
+	// Run background jobs until the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobs.StartLowStockChecker(jobsCtx, config.LowStock)
+	go jobs.StartReservationSweeper(jobsCtx, config.Reservations)
+	go jobs.StartPriceScheduler(jobsCtx, config.Prices)
+	go jobs.StartPublishScheduler(jobsCtx, config.Products)
+	go jobs.StartTokenPurger(jobsCtx)
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	// Create a deadline to wait for
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,250 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/handlers"
+	settings "github.com/everysoft/inventary-be/settings"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "test-secret-0123456789-abcdefghijklmnop"
+
+var testUser = auth.User{ID: "42", Username: "tester", Email: "tester@example.com", Role: "admin"}
+
+// setupAuth configures auth with a test secret and returns a router whose protected route echoes the claims
+func setupAuth(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := auth.Configure(&settings.Config{JWTSecret: testSecret}); err != nil {
+		t.Fatalf("configure auth: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/protected", AuthMiddleware(), func(c *gin.Context) {
+		claims, ok := auth.GetClaimsFromContext(c)
+		if !ok {
+			handlers.SendError(c, http.StatusInternalServerError, "claims missing from context", nil)
+			return
+		}
+		user, err := auth.GetUserFromContext(c)
+		if err != nil {
+			handlers.SendError(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		handlers.SendSuccess(c, http.StatusOK, gin.H{"claims": claims, "user": user})
+	})
+	return router
+}
+
+// testClaims returns claims for testUser that expire at expiresAt
+func testClaims(expiresAt time.Time) auth.Claims {
+	return auth.Claims{
+		UserID:   testUser.ID,
+		Username: testUser.Username,
+		Email:    testUser.Email,
+		Role:     testUser.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-time.Hour)),
+			Subject:   testUser.ID,
+			ID:        "test-jti",
+		},
+	}
+}
+
+// signToken signs claims with method and key, failing the test on error
+func signToken(t *testing.T, method jwt.SigningMethod, claims auth.Claims, key interface{}) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign %s token: %v", method.Alg(), err)
+	}
+	return token
+}
+
+func TestAuthMiddlewareRejectsInvalidTokens(t *testing.T) {
+	router := setupAuth(t)
+
+	valid, _, err := auth.GenerateToken(testUser, false)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+
+	// Same signature over a payload that escalates the role
+	tampered := testClaims(time.Now().Add(time.Hour))
+	tampered.Role = "superadmin"
+	payload, _ := json.Marshal(tampered)
+	tamperedPayload := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+
+	// Same payload with the first signature byte changed
+	signature := []byte(parts[2])
+	if signature[0] == 'A' {
+		signature[0] = 'B'
+	} else {
+		signature[0] = 'A'
+	}
+	tamperedSignature := parts[0] + "." + parts[1] + "." + string(signature)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", signToken(t, jwt.SigningMethodHS256, testClaims(time.Now().Add(-time.Minute)), []byte(testSecret))},
+		{"tampered payload", tamperedPayload},
+		{"tampered signature", tamperedSignature},
+		{"alg none", signToken(t, jwt.SigningMethodNone, testClaims(time.Now().Add(time.Hour)), jwt.UnsafeAllowNoneSignatureType)},
+		{"alg RS256", signToken(t, jwt.SigningMethodRS256, testClaims(time.Now().Add(time.Hour)), rsaKey)},
+		{"alg HS512", signToken(t, jwt.SigningMethodHS512, testClaims(time.Now().Add(time.Hour)), []byte(testSecret))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.ValidateToken(tt.token); err == nil {
+				t.Fatal("ValidateToken accepted the token")
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			var resp handlers.APIResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Success || resp.Error != "Invalid or expired token" || resp.Data != nil {
+				t.Fatalf("response = %+v, want an error response", resp)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSetsClaims(t *testing.T) {
+	router := setupAuth(t)
+
+	token, claims, err := auth.GenerateToken(testUser, true)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Claims auth.Claims `json:"claims"`
+			User   auth.User   `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatal("success = false")
+	}
+
+	got := resp.Data.Claims
+	if got.UserID != claims.UserID || got.Username != claims.Username || got.Email != claims.Email ||
+		got.Role != claims.Role || got.ID != claims.ID || !got.TwoFactor {
+		t.Fatalf("claims in context = %+v, want %+v", got, *claims)
+	}
+	if user := resp.Data.User; user.ID != testUser.ID || user.Username != testUser.Username ||
+		user.Email != testUser.Email || user.Role != testUser.Role {
+		t.Fatalf("user in context = %+v, want %+v", user, testUser)
+	}
+}
+
+func TestAuthMiddlewareTokenSources(t *testing.T) {
+	router := setupAuth(t)
+
+	valid, claims, err := auth.GenerateToken(testUser, false)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+	tampered := testClaims(time.Now().Add(time.Hour))
+	tampered.Role = "superadmin"
+	payload, _ := json.Marshal(tampered)
+	tamperedToken := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+
+	sources := []struct {
+		name   string
+		attach func(req *http.Request, token string)
+	}{
+		{"header", func(req *http.Request, token string) { req.Header.Set("Authorization", "Bearer "+token) }},
+		{"cookie", func(req *http.Request, token string) { req.AddCookie(&http.Cookie{Name: "auth_token", Value: token}) }},
+		{"query", func(req *http.Request, token string) { req.URL.RawQuery = url.Values{"token": {token}}.Encode() }},
+	}
+
+	for _, source := range sources {
+		for _, tt := range []struct {
+			name       string
+			token      string
+			wantStatus int
+		}{
+			{"valid", valid, http.StatusOK},
+			{"tampered", tamperedToken, http.StatusUnauthorized},
+		} {
+			t.Run(source.name+"/"+tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				source.attach(req, tt.token)
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+				}
+
+				var resp struct {
+					Success bool   `json:"success"`
+					Error   string `json:"error"`
+					Data    struct {
+						Claims auth.Claims `json:"claims"`
+					} `json:"data"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+
+				if tt.wantStatus != http.StatusOK {
+					if resp.Success || resp.Error != "Invalid or expired token" {
+						t.Fatalf("response = %+v, want an error response", resp)
+					}
+					return
+				}
+				if !resp.Success || resp.Data.Claims.UserID != claims.UserID || resp.Data.Claims.ID != claims.ID ||
+					resp.Data.Claims.Role != claims.Role {
+					t.Fatalf("claims in context = %+v, want %+v", resp.Data.Claims, *claims)
+				}
+			})
+		}
+	}
+}
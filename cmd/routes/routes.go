@@ -1,11 +1,18 @@
 package routes
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/everysoft/inventary-be/app/auth"
+	"github.com/everysoft/inventary-be/app/handlers"
 	"github.com/everysoft/inventary-be/app/handlers/adminHandlers"
 	"github.com/everysoft/inventary-be/app/handlers/publicHandlers"
+	"github.com/everysoft/inventary-be/db"
+	settings "github.com/everysoft/inventary-be/settings"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,6 +20,11 @@ import (
 func SetupRoutes() *gin.Engine {
 	router := gin.Default()
 
+	// Only configured proxies may set the client IP used for login throttling and audit records
+	if err := router.SetTrustedProxies(settings.Current().Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted-proxies: %v", err)
+	}
+
 	// Set a higher limit for multipart forms (e.g., 8MB)
 	router.MaxMultipartMemory = 100 << 20 // 100MB
 
@@ -25,10 +37,26 @@ func SetupRoutes() *gin.Engine {
 	// Public routes group
 	api := router.Group("/api")
 	{
-		auth := api.Group("/auth")
+		authRoutes := api.Group("/auth")
 		{
-			auth.POST("/register", publicHandlers.RegisterHandler) // You'll need to update these handler functions
-			auth.POST("/login", publicHandlers.LoginHandler)       // to use gin.Context instead of http.HandlerFunc
+			authRoutes.POST("/register", publicHandlers.RegisterHandler) // You'll need to update these handler functions
+			authRoutes.POST("/login", publicHandlers.LoginHandler)       // to use gin.Context instead of http.HandlerFunc
+			authRoutes.POST("/login/2fa", publicHandlers.LoginTwoFactorHandler)
+			authRoutes.POST("/refresh", publicHandlers.RefreshHandler)
+			authRoutes.POST("/logout", publicHandlers.LogoutHandler)
+			authRoutes.POST("/forgot-password", publicHandlers.ForgotPasswordHandler)
+			authRoutes.POST("/reset-password", publicHandlers.ResetPasswordHandler)
+			authRoutes.POST("/verify-email", publicHandlers.VerifyEmailHandler)
+			authRoutes.POST("/verify-email/resend", publicHandlers.ResendVerificationHandler)
+
+			// Two-factor management only needs a token, so users whose role requires it can enroll
+			twoFactor := authRoutes.Group("/2fa", AuthMiddleware())
+			{
+				twoFactor.POST("/enroll", publicHandlers.EnrollTwoFactorHandler)
+				twoFactor.POST("/enable", publicHandlers.EnableTwoFactorHandler)
+				twoFactor.POST("/disable", publicHandlers.DisableTwoFactorHandler)
+				twoFactor.POST("/recovery-codes", publicHandlers.RegenerateRecoveryCodesHandler)
+			}
 		}
 
 		/**
@@ -64,31 +92,223 @@ func SetupRoutes() *gin.Engine {
 
 		/**
 		 * Admin routes
-		 * These routes require authentication
+		 * These routes require authentication, each group also requires the permissions of its resource.
+		 * Roles configured under auth.two-factor-roles must have logged in with a second factor.
 		 */
 		admin := api.Group("/admin")
-		admin.Use(AuthMiddleware())
+		admin.Use(AuthMiddleware(), RequireTwoFactor())
 		{
+			/**
+			 * Roles routes
+			 * Only admins can see the permission model
+			 */
+			rolesProtected := admin.Group("/roles", RequirePermission(auth.PermissionUsersManage))
+			{
+				rolesProtected.GET("", adminHandlers.GetRoles)
+			}
+
+			/**
+			 * Users routes
+			 * Accounts are created by admins, public registration is disabled by default
+			 */
+			usersProtected := admin.Group("/users", RequirePermission(auth.PermissionUsersManage))
+			{
+				usersProtected.GET("", adminHandlers.GetAllUsers)
+				usersProtected.POST("", adminHandlers.InviteUser)
+				usersProtected.GET("/:id", adminHandlers.GetUserByID)
+				usersProtected.PUT("/:id/role", adminHandlers.UpdateUserRole)
+				usersProtected.POST("/:id/deactivate", adminHandlers.DeactivateUser)
+				usersProtected.POST("/:id/activate", adminHandlers.ActivateUser)
+				usersProtected.POST("/:id/reset-password", adminHandlers.ResetUserPassword)
+				usersProtected.POST("/:id/revoke-sessions", adminHandlers.RevokeUserSessions)
+				usersProtected.POST("/:id/unlock", adminHandlers.UnlockUser)
+				usersProtected.POST("/:id/reset-2fa", adminHandlers.ResetUserTwoFactor)
+			}
+
+			/**
+			 * API Keys routes
+			 * Keys for integrations, sent in the X-API-Key header instead of a JWT
+			 */
+			apiKeysProtected := admin.Group("/api-keys", RequirePermission(auth.PermissionUsersManage))
+			{
+				apiKeysProtected.GET("", adminHandlers.GetAllAPIKeys)
+				apiKeysProtected.POST("", adminHandlers.CreateAPIKey)
+				apiKeysProtected.DELETE("/:id", adminHandlers.RevokeAPIKey)
+			}
+
+			/**
+			 * Login Events routes
+			 * Audit trail of every login attempt, including throttled ones
+			 */
+			loginEventsProtected := admin.Group("/login-events", RequirePermission(auth.PermissionUsersManage))
+			{
+				loginEventsProtected.GET("", adminHandlers.GetAllLoginEvents)
+			}
+
+			/**
+			 * Audit Logs routes
+			 * Who changed which product, banner, master record or newsletter entry, and how
+			 */
+			auditLogsProtected := admin.Group("/audit-logs", RequirePermission(auth.PermissionAuditLogsRead))
+			{
+				auditLogsProtected.GET("", adminHandlers.GetAllAuditLogs)
+			}
+
 			/**
 			 * Master Products routes
-			 * These routes require authentication
+			 * Stock routes of a product need stock permissions rather than product permissions
 			 */
 			productsProtected := admin.Group("/products")
 			{
-				productsProtected.GET("", adminHandlers.GetAllProducts)
-				productsProtected.POST("", adminHandlers.CreateProduct)
-				productsProtected.GET("/deleted", adminHandlers.GetDeletedProducts) // Route for fetching deleted products
-				productsProtected.GET("/:id", adminHandlers.GetProductByID)
-				productsProtected.PUT("/:id", adminHandlers.UpdateProduct)
-				productsProtected.DELETE("/:id", adminHandlers.DeleteProduct)
-				productsProtected.POST("/restore/:id", adminHandlers.RestoreProduct) // Route for restoring deleted products
+				productsProtected.GET("", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetAllProducts)
+				productsProtected.POST("", RequirePermission(auth.PermissionProductsWrite), adminHandlers.CreateProduct)
+				productsProtected.GET("/deleted", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetDeletedProducts) // Route for fetching deleted products
+				productsProtected.POST("/labels", RequirePermission(auth.PermissionProductsRead), adminHandlers.CreateLabelSheet)   // Printable label sheet (PDF) for a selection of products
+				productsProtected.GET("/:id", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetProductByID)
+				productsProtected.PUT("/:id", RequirePermission(auth.PermissionProductsWrite), adminHandlers.UpdateProduct)
+				productsProtected.DELETE("/:id", RequirePermission(auth.PermissionProductsDelete), adminHandlers.DeleteProduct)
+				productsProtected.POST("/restore/:id", RequirePermission(auth.PermissionProductsDelete), adminHandlers.RestoreProduct) // Route for restoring deleted products
+				productsProtected.POST("/:id/lifecycle", RequirePermission(auth.PermissionProductsRead), adminHandlers.UpdateProductLifecycle)
+				productsProtected.PUT("/:id/publish-schedule", RequirePermission(auth.PermissionProductsRead), adminHandlers.UpdateProductPublishSchedule)
+				productsProtected.GET("/:id/variants", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetProductVariants)
+				productsProtected.PUT("/:id/variants/:variantId", RequirePermission(auth.PermissionProductsWrite), adminHandlers.UpdateProductVariant)
+				productsProtected.GET("/:id/prices", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetProductPriceTimeline)
+				productsProtected.POST("/:id/price-schedules", RequirePermission(auth.PermissionProductsWrite), adminHandlers.CreateProductPriceSchedule)
+				productsProtected.DELETE("/:id/price-schedules/:scheduleId", RequirePermission(auth.PermissionProductsWrite), adminHandlers.CancelProductPriceSchedule)
+				productsProtected.GET("/:id/barcode", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetProductBarcode)
+				productsProtected.GET("/:id/variants/:variantId/barcode", RequirePermission(auth.PermissionProductsRead), adminHandlers.GetProductVariantBarcode)
+				productsProtected.GET("/:id/stock", RequirePermission(auth.PermissionStockRead), adminHandlers.GetProductStock)
+				productsProtected.PUT("/:id/stock", RequirePermission(auth.PermissionStockWrite), adminHandlers.UpdateProductStock)
+				productsProtected.GET("/:id/stock/reconcile", RequirePermission(auth.PermissionStockRead), adminHandlers.ReconcileProductStock)
+				productsProtected.GET("/:id/batches", RequirePermission(auth.PermissionStockRead), adminHandlers.GetProductBatches)
+				productsProtected.GET("/:id/stock-thresholds", RequirePermission(auth.PermissionStockRead), adminHandlers.GetProductStockThresholds)
+				productsProtected.PUT("/:id/stock-thresholds", RequirePermission(auth.PermissionStockWrite), adminHandlers.UpdateProductStockThresholds)
+			}
+
+			/**
+			 * Stock Reservations routes
+			 * Active reservations reduce available stock until they are fulfilled, released or expire
+			 */
+			stockReservationsProtected := admin.Group("/stock-reservations", RequireAccess(auth.PermissionStockRead, auth.PermissionStockWrite))
+			{
+				stockReservationsProtected.GET("", adminHandlers.GetAllStockReservations)
+				stockReservationsProtected.POST("", adminHandlers.CreateStockReservation)
+				stockReservationsProtected.GET("/:id", adminHandlers.GetStockReservationByID)
+				stockReservationsProtected.POST("/:id/release", adminHandlers.ReleaseStockReservation)
+				stockReservationsProtected.POST("/:id/fulfill", adminHandlers.FulfillStockReservation)
+			}
+
+			/**
+			 * Low Stock routes
+			 * Variants below their minimum stock, and the alerts raised for them by the background checker
+			 */
+			lowStockProtected := admin.Group("/low-stock", RequirePermission(auth.PermissionStockRead))
+			{
+				lowStockProtected.GET("", adminHandlers.GetLowStockVariants)
+				lowStockProtected.GET("/alerts", adminHandlers.GetAllStockAlerts)
+			}
+
+			/**
+			 * Report routes
+			 * Inventory valuation as of a date, by FIFO or moving-average cost
+			 */
+			reportsProtected := admin.Group("/reports", RequirePermission(auth.PermissionReportsRead))
+			{
+				reportsProtected.GET("/valuation", adminHandlers.GetInventoryValuation)
+			}
+
+			/**
+			 * Stock Movements routes
+			 * The ledger is append-only, so there are no update or delete routes
+			 */
+			stockMovementsProtected := admin.Group("/stock-movements", RequireAccess(auth.PermissionStockRead, auth.PermissionStockWrite))
+			{
+				stockMovementsProtected.GET("", adminHandlers.GetAllStockMovements)
+				stockMovementsProtected.POST("", adminHandlers.CreateStockMovement)
+				stockMovementsProtected.GET("/:id", adminHandlers.GetStockMovementByID)
+			}
+
+			/**
+			 * Goods Receipts routes
+			 * Posting a receipt adds its items to stock and records them as dated batches
+			 */
+			goodsReceiptsProtected := admin.Group("/goods-receipts", RequireAccess(auth.PermissionPurchasingRead, auth.PermissionPurchasingWrite))
+			{
+				goodsReceiptsProtected.GET("", adminHandlers.GetAllGoodsReceipts)
+				goodsReceiptsProtected.POST("", adminHandlers.CreateGoodsReceipt)
+				goodsReceiptsProtected.GET("/:id", adminHandlers.GetGoodsReceiptByID)
+				goodsReceiptsProtected.PUT("/:id", adminHandlers.UpdateGoodsReceipt)
+				goodsReceiptsProtected.POST("/:id/post", adminHandlers.PostGoodsReceipt)
+				goodsReceiptsProtected.POST("/:id/cancel", adminHandlers.CancelGoodsReceipt)
+			}
+
+			/**
+			 * Purchase Orders routes
+			 * Goods receipts deliver purchase order lines, orders close once everything has arrived
+			 */
+			purchaseOrdersProtected := admin.Group("/purchase-orders", RequireAccess(auth.PermissionPurchasingRead, auth.PermissionPurchasingWrite))
+			{
+				purchaseOrdersProtected.GET("", adminHandlers.GetAllPurchaseOrders)
+				purchaseOrdersProtected.POST("", adminHandlers.CreatePurchaseOrder)
+				purchaseOrdersProtected.GET("/overdue", adminHandlers.GetOverduePurchaseOrders)
+				purchaseOrdersProtected.GET("/:id", adminHandlers.GetPurchaseOrderByID)
+				purchaseOrdersProtected.PUT("/:id", adminHandlers.UpdatePurchaseOrder)
+				purchaseOrdersProtected.POST("/:id/send", adminHandlers.SendPurchaseOrder)
+				purchaseOrdersProtected.POST("/:id/close", adminHandlers.ClosePurchaseOrder)
+				purchaseOrdersProtected.POST("/:id/cancel", adminHandlers.CancelPurchaseOrder)
+			}
+
+			/**
+			 * Stock Transfers routes
+			 * Stock leaves the source location on ship and arrives at the destination on receive
+			 */
+			stockTransfersProtected := admin.Group("/stock-transfers", RequireAccess(auth.PermissionStockRead, auth.PermissionStockWrite))
+			{
+				stockTransfersProtected.GET("", adminHandlers.GetAllStockTransfers)
+				stockTransfersProtected.POST("", adminHandlers.CreateStockTransfer)
+				stockTransfersProtected.GET("/:id", adminHandlers.GetStockTransferByID)
+				stockTransfersProtected.PUT("/:id", adminHandlers.UpdateStockTransfer)
+				stockTransfersProtected.POST("/:id/ship", adminHandlers.ShipStockTransfer)
+				stockTransfersProtected.POST("/:id/receive", adminHandlers.ReceiveStockTransfer)
+				stockTransfersProtected.POST("/:id/cancel", adminHandlers.CancelStockTransfer)
+			}
+
+			/**
+			 * Stock Opname routes
+			 * A session is opened, counted, submitted and approved; approval posts the variances as adjustments
+			 */
+			stockOpnamesProtected := admin.Group("/stock-opnames", RequireAccess(auth.PermissionStockRead, auth.PermissionStockWrite))
+			{
+				stockOpnamesProtected.GET("", adminHandlers.GetAllStockOpnames)
+				stockOpnamesProtected.POST("", adminHandlers.CreateStockOpname)
+				stockOpnamesProtected.GET("/:id", adminHandlers.GetStockOpnameByID)
+				stockOpnamesProtected.PUT("/:id/counts", adminHandlers.SubmitStockOpnameCounts)
+				stockOpnamesProtected.POST("/:id/submit", adminHandlers.SubmitStockOpname)
+				stockOpnamesProtected.POST("/:id/approve", adminHandlers.ApproveStockOpname)
+				stockOpnamesProtected.POST("/:id/reopen", adminHandlers.ReopenStockOpname)
+				stockOpnamesProtected.POST("/:id/cancel", adminHandlers.CancelStockOpname)
+			}
+
+			/**
+			 * Master Locations routes
+			 * These routes require authentication
+			 */
+			locationsProtected := admin.Group("/locations", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
+			{
+				locationsProtected.GET("", adminHandlers.GetAllLocations)
+				locationsProtected.POST("", adminHandlers.CreateLocation)
+				locationsProtected.GET("/deleted", adminHandlers.GetDeletedLocations)
+				locationsProtected.GET("/:id", adminHandlers.GetLocationByID)
+				locationsProtected.PUT("/:id", adminHandlers.UpdateLocation)
+				locationsProtected.DELETE("/:id", adminHandlers.DeleteLocation)
+				locationsProtected.POST("/restore/:id", adminHandlers.RestoreLocation)
 			}
 
 			/**
 			 * Master Colors routes
 			 * These routes require authentication
 			 */
-			colorsProtected := admin.Group("/colors")
+			colorsProtected := admin.Group("/colors", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				colorsProtected.GET("", adminHandlers.GetAllColors)
 				colorsProtected.POST("", adminHandlers.CreateColor)
@@ -103,7 +323,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Grup routes
 			 * These routes require authentication
 			 */
-			grupsProtected := admin.Group("/grups")
+			grupsProtected := admin.Group("/grups", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				grupsProtected.GET("", adminHandlers.GetAllGrups)
 				grupsProtected.POST("", adminHandlers.CreateGrup)
@@ -118,7 +338,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Unit routes
 			 * These routes require authentication
 			 */
-			unitsProtected := admin.Group("/units")
+			unitsProtected := admin.Group("/units", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				unitsProtected.GET("", adminHandlers.GetAllUnits)
 				unitsProtected.POST("", adminHandlers.CreateUnit)
@@ -133,7 +353,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Kat routes
 			 * These routes require authentication
 			 */
-			katsProtected := admin.Group("/kats")
+			katsProtected := admin.Group("/kats", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				katsProtected.GET("", adminHandlers.GetAllKats)
 				katsProtected.POST("", adminHandlers.CreateKat)
@@ -148,7 +368,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Gender routes
 			 * These routes require authentication
 			 */
-			gendersProtected := admin.Group("/genders")
+			gendersProtected := admin.Group("/genders", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				gendersProtected.GET("", adminHandlers.GetAllGenders)
 				gendersProtected.POST("", adminHandlers.CreateGender)
@@ -163,7 +383,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Tipe routes
 			 * These routes require authentication
 			 */
-			tipesProtected := admin.Group("/tipes")
+			tipesProtected := admin.Group("/tipes", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				tipesProtected.GET("", adminHandlers.GetAllTipes)
 				tipesProtected.POST("", adminHandlers.CreateTipe)
@@ -174,11 +394,26 @@ func SetupRoutes() *gin.Engine {
 				tipesProtected.POST("/restore/:id", adminHandlers.RestoreTipe)
 			}
 
+			/**
+			 * Master Supplier routes
+			 * These routes require authentication
+			 */
+			suppliersProtected := admin.Group("/suppliers", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
+			{
+				suppliersProtected.GET("", adminHandlers.GetAllSuppliers)
+				suppliersProtected.POST("", adminHandlers.CreateSupplier)
+				suppliersProtected.GET("/deleted", adminHandlers.GetDeletedSuppliers)
+				suppliersProtected.GET("/:id", adminHandlers.GetSupplierByID)
+				suppliersProtected.PUT("/:id", adminHandlers.UpdateSupplier)
+				suppliersProtected.DELETE("/:id", adminHandlers.DeleteSupplier)
+				suppliersProtected.POST("/restore/:id", adminHandlers.RestoreSupplier)
+			}
+
 			/**
 			 * Master Banners routes
 			 * These routes require authentication
 			 */
-			bannersProtected := admin.Group("/banners")
+			bannersProtected := admin.Group("/banners", RequireAccess(auth.PermissionBannersRead, auth.PermissionBannersWrite))
 			{
 				bannersProtected.GET("", adminHandlers.GetAllBanners)
 				bannersProtected.POST("", adminHandlers.CreateBanner)
@@ -189,11 +424,24 @@ func SetupRoutes() *gin.Engine {
 				bannersProtected.POST("/restore/:id", adminHandlers.RestoreBanner)
 			}
 
+			/**
+			 * Promotions routes
+			 * Running promotions set the effective price in the public product responses
+			 */
+			promotionsProtected := admin.Group("/promotions", RequireAccess(auth.PermissionPromotionsRead, auth.PermissionPromotionsWrite))
+			{
+				promotionsProtected.GET("", adminHandlers.GetAllPromotions)
+				promotionsProtected.POST("", adminHandlers.CreatePromotion)
+				promotionsProtected.GET("/:id", adminHandlers.GetPromotionByID)
+				promotionsProtected.PUT("/:id", adminHandlers.UpdatePromotion)
+				promotionsProtected.DELETE("/:id", adminHandlers.DeletePromotion)
+			}
+
 			/**
 			 * Panduan Ukuran routes
 			 * These routes require authentication
 			 */
-			panduanUkuranProtected := admin.Group("/panduan-ukuran")
+			panduanUkuranProtected := admin.Group("/panduan-ukuran", RequireAccess(auth.PermissionMasterDataRead, auth.PermissionMasterDataWrite))
 			{
 				panduanUkuranProtected.POST("", adminHandlers.UploadPanduanUkuran)
 				panduanUkuranProtected.DELETE("", adminHandlers.DeletePanduanUkuran)
@@ -203,7 +451,7 @@ func SetupRoutes() *gin.Engine {
 			 * Master Newsletter routes
 			 * These routes require authentication
 			 */
-			newslettersProtected := admin.Group("/newsletters")
+			newslettersProtected := admin.Group("/newsletters", RequireAccess(auth.PermissionNewslettersRead, auth.PermissionNewslettersWrite))
 			{
 				newslettersProtected.GET("", adminHandlers.GetAllNewsletters)
 				newslettersProtected.GET("/deleted", adminHandlers.GetDeletedNewsletters)
@@ -223,7 +471,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+auth.APIKeyHeader)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Multipart", "true")
 
@@ -253,22 +501,99 @@ func GetProfile(c *gin.Context) {
 	})
 }
 
-// AuthMiddleware using Gin native syntax
+// AuthMiddleware rejects requests without a valid JWT, read from the Authorization header, the auth_token
+// cookie or the token query parameter, and stores the token claims in the context for the handlers.
+// Integrations send an API key in the X-API-Key header instead, which grants the scopes of the key.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
+		if key := c.GetHeader(auth.APIKeyHeader); key != "" {
+			authenticateAPIKey(c, key)
+			return
+		}
+
+		token := auth.ExtractTokenFromGinContext(c)
 		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "unauthorized",
-			})
+			handlers.SendError(c, http.StatusUnauthorized, "No token provided", nil)
+			c.Abort()
+			return
+		}
+
+		claims, err := auth.ValidateToken(token)
+		if err != nil {
+			handlers.SendError(c, http.StatusUnauthorized, "Invalid or expired token", nil)
+			c.Abort()
 			return
 		}
 
-		// Add your token validation logic here
-		// You might want to set some values in the context
-		// c.Set("user_id", userID)
-		// c.Set("user_role", userRole)
+		auth.SetClaimsInContext(c, claims)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey continues the request with the scopes of an active API key, or rejects it
+func authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, err := db.FetchActiveAPIKeyByHash(auth.HashToken(key))
+	if err != nil {
+		if !errors.Is(err, db.ErrAPIKeyInvalid) {
+			log.Printf("Error checking API key: %v", err)
+		}
+		handlers.SendError(c, http.StatusUnauthorized, "Invalid or expired API key", nil)
+		c.Abort()
+		return
+	}
+
+	if err := db.TouchAPIKey(apiKey.ID, c.ClientIP()); err != nil {
+		log.Printf("Error recording API key use: %v", err)
+	}
 
+	auth.SetAPIKeyInContext(c, strconv.Itoa(apiKey.ID), apiKey.Name, apiKey.CreatedBy, apiKey.Scopes)
+	c.Next()
+}
+
+// RequireTwoFactor rejects tokens of roles that require two-factor authentication when the login did not
+// verify a second factor. API keys have no login and are let through. It must run after AuthMiddleware.
+func RequireTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get(auth.APIKeyScopesContextKey); isAPIKey {
+			c.Next()
+			return
+		}
+
+		claims, ok := auth.GetClaimsFromContext(c)
+		if !ok || (auth.RequiresTwoFactor(claims.Role) && !claims.TwoFactor) {
+			handlers.SendError(c, http.StatusForbidden, "Two-factor authentication is required for your role", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests whose role, or API key scopes, do not grant permission.
+// It must run after AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.ContextHasPermission(c, permission) {
+			handlers.SendError(c, http.StatusForbidden, "Insufficient permissions", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAccess requires the read permission for GET requests and the write permission for any other method
+func RequireAccess(readPermission, writePermission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permission := writePermission
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			permission = readPermission
+		}
+		if !auth.ContextHasPermission(c, permission) {
+			handlers.SendError(c, http.StatusForbidden, "Insufficient permissions", nil)
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyInvalid is returned for API keys that are unknown, expired, revoked or created by a deactivated user
+var ErrAPIKeyInvalid = errors.New("api_key_invalid")
+
+// CreateAPIKeysTableIfNotExists creates the api_keys table
+func CreateAPIKeysTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			key_prefix VARCHAR(20) NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			created_by TEXT NOT NULL REFERENCES users(id),
+			expires_at TIMESTAMPTZ,
+			last_used_at TIMESTAMPTZ,
+			last_used_ip TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMPTZ
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured api_keys table exists")
+	return nil
+}
+
+// apiKeyColumns lists the columns scanned by scanAPIKey
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, created_by, expires_at, last_used_at, last_used_ip, created_at, revoked_at`
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.KeyPrefix, &key.KeyHash, pq.Array(&key.Scopes), &key.CreatedBy,
+		&key.ExpiresAt, &key.LastUsedAt, &key.LastUsedIP, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+// InsertAPIKey stores a new API key and returns it with its ID and creation time
+func InsertAPIKey(key models.APIKey) (models.APIKey, error) {
+	row := DB.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiKeyColumns,
+		key.Name, key.KeyPrefix, key.KeyHash, pq.Array(key.Scopes), key.CreatedBy, key.ExpiresAt)
+	return scanAPIKey(row)
+}
+
+// FetchAPIKeys retrieves API keys, newest first. Revoked keys are only included with includeRevoked.
+func FetchAPIKeys(includeRevoked bool) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys`
+	if !includeRevoked {
+		query += ` WHERE revoked_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+
+	rows, err := DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// FetchActiveAPIKeyByHash retrieves an unexpired, unrevoked API key of an active user by the hash of the key
+func FetchActiveAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	key, err := scanAPIKey(DB.QueryRow(`
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+			AND EXISTS (SELECT 1 FROM users u WHERE u.id = api_keys.created_by AND u.active)`, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, ErrAPIKeyInvalid
+	}
+	return key, err
+}
+
+// TouchAPIKey records that an API key was used. The timestamp is only written once a minute,
+// so busy integrations do not update the row on every request.
+func TouchAPIKey(id int, ipAddress string) error {
+	_, err := DB.Exec(`
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`,
+		id, ipAddress)
+	return err
+}
+
+// RevokeAPIKey revokes an API key, which stops working immediately
+func RevokeAPIKey(id int) (models.APIKey, error) {
+	key, err := scanAPIKey(DB.QueryRow(`
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE id = $1
+		RETURNING `+apiKeyColumns, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, errors.New("not_found")
+	}
+	return key, err
+}
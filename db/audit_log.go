@@ -0,0 +1,139 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// CreateAuditLogsTableIfNotExists creates the audit_logs table
+func CreateAuditLogsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS audit_logs (
+			id SERIAL PRIMARY KEY,
+			user_id TEXT,
+			username VARCHAR(255) NOT NULL,
+			entity_type VARCHAR(50) NOT NULL,
+			entity_id VARCHAR(100) NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			changes JSONB NOT NULL DEFAULT '{}',
+			ip_address TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs(entity_type, entity_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured audit_logs table exists")
+	return nil
+}
+
+// InsertAuditLog stores an audit log entry
+func InsertAuditLog(entry models.AuditLog) error {
+	_, err := DB.Exec(`
+		INSERT INTO audit_logs (user_id, username, entity_type, entity_id, action, changes, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.UserID, entry.Username, entry.EntityType, entry.EntityID, entry.Action, []byte(entry.Changes), entry.IPAddress)
+	return err
+}
+
+// buildAuditLogConditions builds the WHERE clause for listing audit logs. The search query
+// matches the username, the entity ID and the names of the changed fields.
+func buildAuditLogConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		conditions += ` AND (username ILIKE $` + fmt.Sprintf("%d", paramCount) +
+			` OR entity_id ILIKE $` + fmt.Sprintf("%d", paramCount) +
+			` OR changes::text ILIKE $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	for _, field := range []string{"user_id", "entity_type", "entity_id", "action"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND ` + field + ` = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+	if value, ok := filters["username"]; ok && value != "" {
+		conditions += ` AND LOWER(username) = LOWER($` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["field"]; ok && value != "" {
+		conditions += ` AND changes ? $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+// CountAuditLogs counts all audit logs matching the search query and filters
+func CountAuditLogs(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildAuditLogConditions(queryStr, filters)
+
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM audit_logs`+conditions, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FetchAuditLogs retrieves audit logs with pagination, newest first
+func FetchAuditLogs(limit, offset int, queryStr string, filters map[string]string) ([]models.AuditLog, error) {
+	entries := []models.AuditLog{}
+
+	conditions, args, paramCount := buildAuditLogConditions(queryStr, filters)
+	query := `
+		SELECT id, user_id, username, entity_type, entity_id, action, changes, COALESCE(ip_address, ''), created_at
+		FROM audit_logs` + conditions + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.AuditLog
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.EntityType, &e.EntityID, &e.Action, &changes, &e.IPAddress, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Changes = changes
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
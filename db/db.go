@@ -48,6 +48,30 @@ func InitDB() error {
 		return fmt.Errorf("failed to create users table: %w", err)
 	}
 
+	if err := CreateSessionsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create session tables: %w", err)
+	}
+
+	if err := CreateUserTokensTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create user_tokens table: %w", err)
+	}
+
+	if err := CreateLoginThrottleTablesIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create login throttle tables: %w", err)
+	}
+
+	if err := CreateTwoFactorTablesIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create two-factor tables: %w", err)
+	}
+
+	if err := CreateAPIKeysTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create api_keys table: %w", err)
+	}
+
+	if err := CreateAuditLogsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create audit_logs table: %w", err)
+	}
+
 	if err := CreateMasterProductsTableIfNotExists(); err != nil {
 		return fmt.Errorf("failed to create master_products table: %w", err)
 	}
@@ -60,6 +84,42 @@ func InitDB() error {
 		return fmt.Errorf("failed to create master_colors table: %w", err)
 	}
 
+	if err := CreateMasterLocationsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create master_locations table: %w", err)
+	}
+
+	if err := CreateProductStocksTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create product_stocks table: %w", err)
+	}
+
+	if err := CreateProductVariantsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create product_variants table: %w", err)
+	}
+
+	if err := CreateProductPriceTablesIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create product price tables: %w", err)
+	}
+
+	if err := CreatePromotionsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create promotions table: %w", err)
+	}
+
+	if err := CreateStockMovementsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create stock_movements table: %w", err)
+	}
+
+	if err := CreateGoodsReceiptsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create goods_receipts table: %w", err)
+	}
+
+	if err := CreateStockTransfersTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create stock_transfers table: %w", err)
+	}
+
+	if err := CreateStockOpnamesTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create stock_opnames table: %w", err)
+	}
+
 	if err := CreateMasterGrupsTableIfNotExists(); err != nil {
 		return fmt.Errorf("failed to create master_grups table: %w", err)
 	}
@@ -80,6 +140,22 @@ func InitDB() error {
 		return fmt.Errorf("failed to create master_tipes table: %w", err)
 	}
 
+	if err := CreateMasterSuppliersTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create master_suppliers table: %w", err)
+	}
+
+	if err := CreatePurchaseOrdersTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create purchase_orders table: %w", err)
+	}
+
+	if err := CreateStockThresholdsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create stock_thresholds table: %w", err)
+	}
+
+	if err := CreateStockReservationsTableIfNotExists(); err != nil {
+		return fmt.Errorf("failed to create stock_reservations table: %w", err)
+	}
+
 	if err := CreateBannersTableIfNotExists(); err != nil {
 		return fmt.Errorf("failed to create banners table: %w", err)
 	}
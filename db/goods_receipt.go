@@ -0,0 +1,562 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// CreateGoodsReceiptsTableIfNotExists ensures the goods_receipts and goods_receipt_items tables exist,
+// together with the function that derives a product's receipt date from its batches
+func CreateGoodsReceiptsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS goods_receipts (
+			id SERIAL PRIMARY KEY,
+			nomor TEXT,
+			supplier TEXT NOT NULL,
+			location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			tanggal_terima DATE NOT NULL,
+			reference TEXT,
+			status TEXT NOT NULL DEFAULT 'draft',
+			notes TEXT,
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			posted_by TEXT,
+			posted_at TIMESTAMPTZ,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_goods_receipts_nomor ON goods_receipts(nomor);`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipts_status ON goods_receipts(status);`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipts_supplier ON goods_receipts(supplier);`,
+		`CREATE TABLE IF NOT EXISTS goods_receipt_items (
+			id SERIAL PRIMARY KEY,
+			receipt_id INTEGER NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			unit_cost NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (unit_cost >= 0)
+		);`,
+		// Receipts entered before costs were tracked are valued at zero
+		`ALTER TABLE goods_receipt_items ADD COLUMN IF NOT EXISTS unit_cost NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (unit_cost >= 0);`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_receipt_id ON goods_receipt_items(receipt_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_variant ON goods_receipt_items(product_no, color_id, size);`,
+		// The receipt date of a product is the date of its oldest batch still on hand.
+		// Stock is assumed to leave oldest batch first, so the on-hand quantity of a variant
+		// is made up of its most recent batches.
+		`CREATE OR REPLACE FUNCTION product_stock_age_date(p_no INTEGER) RETURNS DATE AS $$
+			SELECT MIN(b.tanggal_terima)
+			FROM (
+				SELECT gr.tanggal_terima, gri.color_id, gri.size,
+					SUM(gri.quantity) OVER (
+						PARTITION BY gri.color_id, gri.size
+						ORDER BY gr.tanggal_terima DESC, gri.id DESC
+					) - gri.quantity AS newer_quantity
+				FROM goods_receipt_items gri
+				JOIN goods_receipts gr ON gr.id = gri.receipt_id
+				WHERE gri.product_no = p_no AND gr.status = 'posted'
+			) b
+			JOIN (
+				SELECT color_id, size, SUM(quantity) AS on_hand
+				FROM product_stocks
+				WHERE product_no = p_no
+				GROUP BY color_id, size
+			) s ON s.color_id = b.color_id AND s.size = b.size
+			WHERE b.newer_quantity < s.on_hand
+		$$ LANGUAGE sql STABLE;`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured goods_receipts table exists")
+	return nil
+}
+
+// refreshProductReceiptDate recomputes tanggal_terima of a product from its posted receipts.
+// Products without receipts or without stock on hand keep their current date.
+func refreshProductReceiptDate(tx *sql.Tx, productNo int) error {
+	_, err := tx.Exec(`
+		UPDATE master_products SET tanggal_terima = COALESCE(product_stock_age_date(no), tanggal_terima)
+		WHERE no = $1`, productNo)
+	return err
+}
+
+// ProductHasReceipts reports whether any posted receipt contains the product, in which case
+// its receipt date is derived from the receipts and can no longer be edited by hand
+func ProductHasReceipts(productNo int) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM goods_receipt_items gri
+			JOIN goods_receipts gr ON gr.id = gri.receipt_id
+			WHERE gri.product_no = $1 AND gr.status = 'posted'
+		)`, productNo).Scan(&exists)
+	return exists, err
+}
+
+// buildGoodsReceiptConditions builds the WHERE clause shared by the count and fetch queries
+func buildGoodsReceiptConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			gr.nomor ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR gr.supplier ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR gr.reference ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR gr.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR ml.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":      "gr.status",
+		"supplier_id": "gr.supplier_id",
+		"location_id": "gr.location_id",
+	}
+	for _, field := range []string{"status", "supplier_id", "location_id"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// Add date range filters on the receipt date
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND gr.tanggal_terima >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND gr.tanggal_terima <= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const goodsReceiptSelect = `
+	SELECT
+		gr.id, COALESCE(gr.nomor, ''), COALESCE(gr.supplier_id, 0), gr.supplier, gr.location_id, COALESCE(ml.nama, ''), gr.tanggal_terima,
+		COALESCE(gr.reference, ''), gr.status, COALESCE(gr.notes, ''), COALESCE(gr.created_by, ''), gr.created_at,
+		COALESCE(gr.posted_by, ''), gr.posted_at, gr.tanggal_update
+	FROM goods_receipts gr
+	LEFT JOIN master_locations ml ON ml.id = gr.location_id`
+
+// scanGoodsReceipt scans a row selected with goodsReceiptSelect
+func scanGoodsReceipt(scanner interface{ Scan(...interface{}) error }, r *models.GoodsReceipt) error {
+	return scanner.Scan(
+		&r.ID, &r.Nomor, &r.SupplierID, &r.Supplier, &r.LocationID, &r.LocationName, &r.TanggalTerima,
+		&r.Reference, &r.Status, &r.Notes, &r.CreatedBy, &r.CreatedAt,
+		&r.PostedBy, &r.PostedAt, &r.TanggalUpdate,
+	)
+}
+
+// CountGoodsReceipts counts all goods receipts matching the search query and filters
+func CountGoodsReceipts(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildGoodsReceiptConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(gr.id)
+		FROM goods_receipts gr
+		LEFT JOIN master_locations ml ON ml.id = gr.location_id`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchGoodsReceipts retrieves goods receipts matching the search query and filters with pagination
+func FetchGoodsReceipts(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.GoodsReceipt, error) {
+	receipts := []models.GoodsReceipt{}
+
+	conditions, args, paramCount := buildGoodsReceiptConditions(queryStr, filters)
+	baseQuery := goodsReceiptSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nomor": true, "supplier": true, "tanggal_terima": true, "status": true, "created_at": true, "posted_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "gr." + sortColumn + " " + sortDirection + ", gr.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.GoodsReceipt
+		if err := scanGoodsReceipt(rows, &r); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachGoodsReceiptItems(receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// attachGoodsReceiptItems loads the item lines of the given receipts using a single query
+func attachGoodsReceiptItems(receipts []models.GoodsReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(receipts))
+	index := make(map[int]int, len(receipts))
+	for i, r := range receipts {
+		ids[i] = r.ID
+		index[r.ID] = i
+		receipts[i].Items = []models.GoodsReceiptItem{}
+		receipts[i].TotalQuantity = 0
+		receipts[i].TotalValue = 0
+	}
+
+	rows, err := DB.Query(`
+		SELECT gri.receipt_id, gri.id, gri.product_no, COALESCE(mp.artikel, ''), gri.color_id, COALESCE(mc.nama, ''), gri.size, gri.quantity, gri.unit_cost,
+			COALESCE(gri.purchase_order_item_id, 0), COALESCE(po.nomor, '')
+		FROM goods_receipt_items gri
+		LEFT JOIN master_products mp ON mp.no = gri.product_no
+		LEFT JOIN master_colors mc ON mc.id = gri.color_id
+		LEFT JOIN purchase_order_items poi ON poi.id = gri.purchase_order_item_id
+		LEFT JOIN purchase_orders po ON po.id = poi.purchase_order_id
+		WHERE gri.receipt_id = ANY($1)
+		ORDER BY gri.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var receiptID int
+		var item models.GoodsReceiptItem
+		if err := rows.Scan(&receiptID, &item.ID, &item.ProductNo, &item.Artikel, &item.ColorID, &item.ColorName, &item.Size, &item.Quantity, &item.UnitCost,
+			&item.PurchaseOrderItemID, &item.PurchaseOrderNomor); err != nil {
+			return err
+		}
+		i := index[receiptID]
+		receipts[i].Items = append(receipts[i].Items, item)
+		receipts[i].TotalQuantity += item.Quantity
+		receipts[i].TotalValue += float64(item.Quantity) * item.UnitCost
+	}
+
+	return rows.Err()
+}
+
+// FetchGoodsReceiptByID retrieves a goods receipt with its items
+func FetchGoodsReceiptByID(id int) (models.GoodsReceipt, error) {
+	var r models.GoodsReceipt
+	err := scanGoodsReceipt(DB.QueryRow(goodsReceiptSelect+` WHERE gr.id = $1`, id), &r)
+	if err == sql.ErrNoRows {
+		return r, errors.New("not_found")
+	}
+	if err != nil {
+		return r, err
+	}
+
+	receipts := []models.GoodsReceipt{r}
+	if err := attachGoodsReceiptItems(receipts); err != nil {
+		return r, err
+	}
+	return receipts[0], nil
+}
+
+// replaceGoodsReceiptItems replaces all item lines of a receipt
+func replaceGoodsReceiptItems(tx *sql.Tx, receiptID int, items []models.GoodsReceiptItemInput) error {
+	if _, err := tx.Exec(`DELETE FROM goods_receipt_items WHERE receipt_id = $1`, receiptID); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO goods_receipt_items (receipt_id, product_no, color_id, size, quantity, unit_cost, purchase_order_item_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0))`,
+			receiptID, item.ProductNo, item.ColorID, strings.TrimSpace(item.Size), item.Quantity, item.UnitCost, item.PurchaseOrderItemID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertGoodsReceipt creates a draft receipt with its items and assigns its document number
+func InsertGoodsReceipt(req *models.SaveGoodsReceiptRequest, username string) (models.GoodsReceipt, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(`
+		INSERT INTO goods_receipts (supplier_id, supplier, location_id, tanggal_terima, reference, status, notes, created_by, created_at, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id`,
+		req.SupplierID, req.SupplierName, req.LocationID, req.ReceivedDate, strings.TrimSpace(req.Reference),
+		models.GoodsReceiptStatusDraft, strings.TrimSpace(req.Notes), username, now,
+	).Scan(&id); err != nil {
+		return models.GoodsReceipt{}, err
+	}
+
+	nomor := fmt.Sprintf("GRN-%s-%06d", now.Format("20060102"), id)
+	if _, err := tx.Exec(`UPDATE goods_receipts SET nomor = $1 WHERE id = $2`, nomor, id); err != nil {
+		return models.GoodsReceipt{}, err
+	}
+
+	if err := replaceGoodsReceiptItems(tx, id, req.Items); err != nil {
+		return models.GoodsReceipt{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	return FetchGoodsReceiptByID(id)
+}
+
+// lockGoodsReceipt locks a receipt row for the rest of the transaction
+func lockGoodsReceipt(tx *sql.Tx, id int) (models.GoodsReceipt, error) {
+	var r models.GoodsReceipt
+	err := tx.QueryRow(`
+		SELECT id, COALESCE(nomor, ''), supplier, location_id, tanggal_terima, status
+		FROM goods_receipts WHERE id = $1
+		FOR UPDATE`, id).Scan(&r.ID, &r.Nomor, &r.Supplier, &r.LocationID, &r.TanggalTerima, &r.Status)
+	if err == sql.ErrNoRows {
+		return r, errors.New("not_found")
+	}
+	return r, err
+}
+
+// UpdateGoodsReceipt replaces the header and items of a draft receipt
+func UpdateGoodsReceipt(id int, req *models.SaveGoodsReceiptRequest) (models.GoodsReceipt, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := lockGoodsReceipt(tx, id)
+	if err != nil {
+		return r, err
+	}
+	if r.Status != models.GoodsReceiptStatusDraft {
+		return r, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE goods_receipts
+		SET supplier_id = $1, supplier = $2, location_id = $3, tanggal_terima = $4, reference = $5, notes = $6, tanggal_update = $7
+		WHERE id = $8`,
+		req.SupplierID, req.SupplierName, req.LocationID, req.ReceivedDate, strings.TrimSpace(req.Reference), strings.TrimSpace(req.Notes), time.Now(), id); err != nil {
+		return r, err
+	}
+
+	if err := replaceGoodsReceiptItems(tx, id, req.Items); err != nil {
+		return r, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r, err
+	}
+	return FetchGoodsReceiptByID(id)
+}
+
+// PostGoodsReceipt adds the items of a draft receipt to stock at its location and marks it posted.
+// Each line becomes a batch dated with the receipt's tanggal_terima. Lines delivering a purchase order line
+// count towards its received quantity and advance the status of the purchase order.
+func PostGoodsReceipt(id int, userID, username string) (models.GoodsReceipt, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := lockGoodsReceipt(tx, id)
+	if err != nil {
+		return r, err
+	}
+	if r.Status != models.GoodsReceiptStatusDraft {
+		return r, errors.New("invalid_status")
+	}
+
+	rows, err := tx.Query(`
+		SELECT product_no, color_id, size, quantity, COALESCE(purchase_order_item_id, 0)
+		FROM goods_receipt_items WHERE receipt_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return r, err
+	}
+	movements := []models.StockMovement{}
+	orderLineQuantities := make(map[int]int)
+	for rows.Next() {
+		var m models.StockMovement
+		var orderItemID int
+		if err := rows.Scan(&m.ProductNo, &m.ColorID, &m.Size, &m.Quantity, &orderItemID); err != nil {
+			rows.Close()
+			return r, err
+		}
+		movements = append(movements, m)
+		if orderItemID != 0 {
+			orderLineQuantities[orderItemID] += m.Quantity
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return r, err
+	}
+	if len(movements) == 0 {
+		return r, errors.New("no_items")
+	}
+
+	// Outstanding quantities are checked before this receipt is counted as posted
+	orderIDs, err := lockPurchaseOrderLines(tx, orderLineQuantities)
+	if err != nil {
+		return r, err
+	}
+
+	// The batch date must be in place before the movements refresh the product receipt dates
+	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE goods_receipts SET status = $1, posted_by = $2, posted_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.GoodsReceiptStatusPosted, username, now, id); err != nil {
+		return r, err
+	}
+
+	for i := range movements {
+		m := &movements[i]
+		m.LocationID = r.LocationID
+		m.Reason = models.StockReasonReceipt
+		m.Reference = r.Nomor
+		m.Notes = "Received from " + r.Supplier
+		m.UserID = userID
+		m.Username = username
+		m.CreatedAt = now
+		if err := ApplyStockMovement(tx, m); err != nil {
+			return r, fmt.Errorf("failed to receive product %d color %d size %s: %w", m.ProductNo, m.ColorID, m.Size, err)
+		}
+	}
+
+	for _, orderID := range orderIDs {
+		if err := refreshPurchaseOrderStatus(tx, orderID, username, now); err != nil {
+			return r, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r, err
+	}
+	return FetchGoodsReceiptByID(id)
+}
+
+// CancelGoodsReceipt cancels a draft receipt. Posted receipts are corrected with return or adjustment movements instead.
+func CancelGoodsReceipt(id int) (models.GoodsReceipt, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := lockGoodsReceipt(tx, id)
+	if err != nil {
+		return r, err
+	}
+	if r.Status != models.GoodsReceiptStatusDraft {
+		return r, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`UPDATE goods_receipts SET status = $1, tanggal_update = $2 WHERE id = $3`,
+		models.GoodsReceiptStatusCancelled, time.Now(), id); err != nil {
+		return r, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r, err
+	}
+	return FetchGoodsReceiptByID(id)
+}
+
+// FetchProductBatches lists the posted receipt batches of a product, newest first, with the quantity
+// of each batch still assumed on hand when stock leaves oldest batch first
+func FetchProductBatches(productNo int) ([]models.StockBatch, error) {
+	rows, err := DB.Query(`
+		SELECT b.item_id, b.receipt_id, b.nomor, b.supplier, b.tanggal_terima, b.color_id, COALESCE(mc.nama, ''), b.size, b.quantity, b.unit_cost,
+			GREATEST(LEAST(b.quantity, COALESCE(s.on_hand, 0) - b.newer_quantity), 0) AS remaining,
+			CASE
+				WHEN (CURRENT_DATE - b.tanggal_terima) < 365 THEN 'Fresh'
+				WHEN (CURRENT_DATE - b.tanggal_terima) < 730 THEN 'Normal'
+				ELSE 'Aging'
+			END AS usia
+		FROM (
+			SELECT gri.id AS item_id, gr.id AS receipt_id, COALESCE(gr.nomor, '') AS nomor, gr.supplier, gr.tanggal_terima,
+				gri.color_id, gri.size, gri.quantity, gri.unit_cost,
+				SUM(gri.quantity) OVER (
+					PARTITION BY gri.color_id, gri.size
+					ORDER BY gr.tanggal_terima DESC, gri.id DESC
+				) - gri.quantity AS newer_quantity
+			FROM goods_receipt_items gri
+			JOIN goods_receipts gr ON gr.id = gri.receipt_id
+			WHERE gri.product_no = $1 AND gr.status = 'posted'
+		) b
+		LEFT JOIN (
+			SELECT color_id, size, SUM(quantity) AS on_hand
+			FROM product_stocks
+			WHERE product_no = $1
+			GROUP BY color_id, size
+		) s ON s.color_id = b.color_id AND s.size = b.size
+		LEFT JOIN master_colors mc ON mc.id = b.color_id
+		ORDER BY b.tanggal_terima DESC, b.item_id DESC`, productNo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []models.StockBatch{}
+	for rows.Next() {
+		var b models.StockBatch
+		if err := rows.Scan(&b.ReceiptItemID, &b.ReceiptID, &b.Nomor, &b.Supplier, &b.TanggalTerima, &b.ColorID, &b.ColorName,
+			&b.Size, &b.Quantity, &b.UnitCost, &b.Remaining, &b.Usia); err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
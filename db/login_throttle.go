@@ -0,0 +1,237 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// loginFailureWindow is how long failures count towards backoff; a key with no failure for this long starts over
+const loginFailureWindow = 24 * time.Hour
+
+// CreateLoginThrottleTablesIfNotExists creates the login throttle and login event tables
+func CreateLoginThrottleTablesIfNotExists() error {
+	statements := []string{
+		// Keys are "user:<username>", "email:<address>" and "ip:<address>"
+		`CREATE TABLE IF NOT EXISTS login_throttles (
+			key TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure_at TIMESTAMPTZ,
+			locked_until TIMESTAMPTZ
+		);`,
+		`CREATE TABLE IF NOT EXISTS login_events (
+			id SERIAL PRIMARY KEY,
+			username TEXT NOT NULL,
+			user_id TEXT,
+			ip_address TEXT,
+			user_agent TEXT,
+			success BOOLEAN NOT NULL,
+			result TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON login_events(created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_events_username ON login_events(LOWER(username));`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured login_throttles and login_events tables exist")
+	return nil
+}
+
+// LoginThrottleUserKey returns the throttle key of a username
+func LoginThrottleUserKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// LoginThrottleEmailKey returns the throttle key of an email address that password reset and verification links are sent to
+func LoginThrottleEmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginThrottleIPKey returns the throttle key of a client IP
+func LoginThrottleIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// FetchLoginLockout returns the latest time until which any of the keys is locked, or the zero time
+func FetchLoginLockout(keys []string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := DB.QueryRow(`
+		SELECT MAX(locked_until) FROM login_throttles
+		WHERE key = ANY($1) AND locked_until > $2`, pq.Array(keys), time.Now()).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lockedUntil.Time, nil
+}
+
+// RecordLoginFailure counts a failed login against key. Once the key has more than freeAttempts
+// failures it is locked for a delay that doubles with every further failure, up to maxLockout.
+func RecordLoginFailure(key string, freeAttempts int, maxLockout time.Duration) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO login_throttles (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key); err != nil {
+		return err
+	}
+
+	var failures int
+	var lastFailureAt sql.NullTime
+	if err := tx.QueryRow(`SELECT failures, last_failure_at FROM login_throttles WHERE key = $1 FOR UPDATE`, key).
+		Scan(&failures, &lastFailureAt); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if lastFailureAt.Valid && now.Sub(lastFailureAt.Time) > loginFailureWindow {
+		failures = 0
+	}
+	failures++
+
+	var lockedUntil *time.Time
+	if excess := failures - freeAttempts; excess > 0 {
+		delay := maxLockout
+		if excess <= 30 {
+			if backoff := time.Second << uint(excess-1); backoff < maxLockout {
+				delay = backoff
+			}
+		}
+		until := now.Add(delay)
+		lockedUntil = &until
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE login_throttles SET failures = $1, last_failure_at = $2, locked_until = $3 WHERE key = $4`,
+		failures, now, lockedUntil, key); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClearLoginFailures resets the failures of key, after a successful login or an admin unlock
+func ClearLoginFailures(key string) error {
+	_, err := DB.Exec(`DELETE FROM login_throttles WHERE key = $1`, key)
+	return err
+}
+
+// PurgeStaleLoginThrottles deletes throttle keys that are not locked and have had no failure within the window
+func PurgeStaleLoginThrottles() (int64, error) {
+	now := time.Now()
+	result, err := DB.Exec(`
+		DELETE FROM login_throttles
+		WHERE (locked_until IS NULL OR locked_until <= $1) AND last_failure_at < $2`, now, now.Add(-loginFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// InsertLoginEvent records a login attempt
+func InsertLoginEvent(e models.LoginEvent) error {
+	_, err := DB.Exec(`
+		INSERT INTO login_events (username, user_id, ip_address, user_agent, success, result)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.Username, e.UserID, e.IPAddress, e.UserAgent, e.Success, e.Result)
+	if err != nil {
+		return fmt.Errorf("failed to record login event: %w", err)
+	}
+	return nil
+}
+
+// buildLoginEventConditions builds the WHERE clause for listing login events
+func buildLoginEventConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		conditions += ` AND (username ILIKE $` + fmt.Sprintf("%d", paramCount) + ` OR ip_address ILIKE $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	for _, field := range []string{"user_id", "ip_address", "success", "result"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + field + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+	if value, ok := filters["username"]; ok && value != "" {
+		conditions += ` AND LOWER(username) = LOWER($` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, value)
+		paramCount++
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+// CountLoginEvents counts all login events matching the search query and filters
+func CountLoginEvents(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildLoginEventConditions(queryStr, filters)
+
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM login_events`+conditions, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FetchLoginEvents retrieves login events with pagination, newest first
+func FetchLoginEvents(limit, offset int, queryStr string, filters map[string]string) ([]models.LoginEvent, error) {
+	events := []models.LoginEvent{}
+
+	conditions, args, paramCount := buildLoginEventConditions(queryStr, filters)
+	query := `
+		SELECT id, username, user_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), success, result, created_at
+		FROM login_events` + conditions + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.LoginEvent
+		if err := rows.Scan(&e.ID, &e.Username, &e.UserID, &e.IPAddress, &e.UserAgent, &e.Success, &e.Result, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,351 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// CreateMasterLocationsTableIfNotExists ensures the master_locations table exists.
+// A default central warehouse is created on first run and the physical shops that were only
+// recorded in the products' offline JSON are migrated into shop locations.
+func CreateMasterLocationsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS master_locations (
+			id SERIAL PRIMARY KEY,
+			kode TEXT NOT NULL,
+			nama TEXT NOT NULL,
+			tipe TEXT NOT NULL DEFAULT 'warehouse',
+			alamat TEXT,
+			url TEXT,
+			is_default BOOLEAN NOT NULL DEFAULT FALSE,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			tanggal_hapus TIMESTAMPTZ
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_master_locations_kode ON master_locations(kode);`,
+		`CREATE INDEX IF NOT EXISTS idx_master_locations_nama ON master_locations(nama);`,
+		`INSERT INTO master_locations (kode, nama, tipe, is_default)
+		SELECT 'PUSAT', 'Gudang Pusat', 'warehouse', TRUE
+		WHERE NOT EXISTS (SELECT 1 FROM master_locations WHERE is_default);`,
+		`INSERT INTO master_locations (kode, nama, tipe, alamat, url)
+		SELECT
+			'TOKO-' || row_number() OVER (ORDER BY s.nama) + (SELECT COUNT(*) FROM master_locations WHERE tipe = 'shop'),
+			s.nama, 'shop', s.alamat, s.url
+		FROM (
+			SELECT DISTINCT ON (lower(trim(store->>'name')))
+				trim(store->>'name') AS nama, store->>'address' AS alamat, store->>'url' AS url
+			FROM master_products, jsonb_array_elements(offline) AS store
+			WHERE offline IS NOT NULL AND jsonb_typeof(offline) = 'array'
+				AND trim(COALESCE(store->>'name', '')) <> ''
+			ORDER BY lower(trim(store->>'name'))
+		) s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM master_locations ml WHERE lower(ml.nama) = lower(s.nama)
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured master_locations table exists")
+	return nil
+}
+
+// FetchDefaultLocationID returns the ID of the default location
+func FetchDefaultLocationID() (int, error) {
+	var id int
+	err := DB.QueryRow(`SELECT id FROM master_locations WHERE is_default AND tanggal_hapus IS NULL ORDER BY id LIMIT 1`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("not_found")
+	}
+	return id, err
+}
+
+// CountAllLocations counts all available locations matching the search query and type
+func CountAllLocations(queryStr string, tipe string) (int, error) {
+	baseQuery := "SELECT COUNT(id) FROM master_locations WHERE tanggal_hapus IS NULL"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kode ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR alamat ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add type filter
+	if tipe != "" {
+		baseQuery += fmt.Sprintf(" AND tipe = $%d", paramCount)
+		args = append(args, tipe)
+		paramCount++
+	}
+
+	var count int
+	err := DB.QueryRow(baseQuery, args...).Scan(&count)
+	return count, err
+}
+
+// FetchAllLocations retrieves all locations matching the search query and type with pagination
+func FetchAllLocations(limit, offset int, queryStr string, tipe string, sortColumn string, sortDirection string) ([]models.Location, error) {
+	return fetchLocations(false, limit, offset, queryStr, tipe, sortColumn, sortDirection)
+}
+
+// FetchDeletedLocations retrieves all deleted locations matching the search query with pagination
+func FetchDeletedLocations(limit, offset int, queryStr string, sortColumn string, sortDirection string) ([]models.Location, error) {
+	return fetchLocations(true, limit, offset, queryStr, "", sortColumn, sortDirection)
+}
+
+// fetchLocations is the shared query behind FetchAllLocations and FetchDeletedLocations
+func fetchLocations(deleted bool, limit, offset int, queryStr string, tipe string, sortColumn string, sortDirection string) ([]models.Location, error) {
+	locations := []models.Location{}
+
+	// Start building the query with parameters
+	baseQuery := `
+	SELECT 
+		id, kode, nama, tipe, COALESCE(alamat, ''), COALESCE(url, ''), is_default, is_active, tanggal_update, tanggal_hapus
+	FROM master_locations`
+	if deleted {
+		baseQuery += " WHERE tanggal_hapus IS NOT NULL"
+	} else {
+		baseQuery += " WHERE tanggal_hapus IS NULL"
+	}
+
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kode ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR alamat ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add type filter
+	if tipe != "" {
+		baseQuery += fmt.Sprintf(" AND tipe = $%d", paramCount)
+		args = append(args, tipe)
+		paramCount++
+	}
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "kode": true, "nama": true, "tipe": true, "tanggal_update": true, "tanggal_hapus": deleted,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "id"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "asc"
+	}
+
+	orderBy += sortColumn + " " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	// Execute the query with all parameters
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l models.Location
+		var isActive bool
+		if err := rows.Scan(
+			&l.ID, &l.Kode, &l.Nama, &l.Tipe, &l.Alamat, &l.URL, &l.IsDefault, &isActive, &l.TanggalUpdate, &l.TanggalHapus,
+		); err != nil {
+			return nil, err
+		}
+		l.IsActive = &isActive
+		locations = append(locations, l)
+	}
+
+	return locations, nil
+}
+
+// FetchLocationByID retrieves a location by its ID
+func FetchLocationByID(id int) (models.Location, error) {
+	var l models.Location
+	var isActive bool
+	err := DB.QueryRow(`
+		SELECT id, kode, nama, tipe, COALESCE(alamat, ''), COALESCE(url, ''), is_default, is_active, tanggal_update, tanggal_hapus
+		FROM master_locations WHERE id = $1 AND tanggal_hapus IS NULL`, id).
+		Scan(&l.ID, &l.Kode, &l.Nama, &l.Tipe, &l.Alamat, &l.URL, &l.IsDefault, &isActive, &l.TanggalUpdate, &l.TanggalHapus)
+
+	if err == sql.ErrNoRows {
+		return l, errors.New("not_found")
+	}
+	l.IsActive = &isActive
+	return l, err
+}
+
+// InsertLocation inserts a new location record
+func InsertLocation(l *models.Location) error {
+	isActive := true
+	if l.IsActive != nil {
+		isActive = *l.IsActive
+	}
+	l.IsActive = &isActive
+
+	stmt, err := DB.Prepare(`
+		INSERT INTO master_locations 
+		(kode, nama, tipe, alamat, url, is_active, tanggal_update) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryRow(
+		l.Kode,
+		l.Nama,
+		l.Tipe,
+		l.Alamat,
+		l.URL,
+		isActive,
+		time.Now(),
+	).Scan(&l.ID)
+}
+
+// UpdateLocation updates an existing location record
+func UpdateLocation(id int, l *models.Location) (models.Location, error) {
+	// First check if the location exists
+	_, err := FetchLocationByID(id)
+	if err != nil {
+		return *l, err
+	}
+
+	// Build dynamic query with only fields that need to be updated
+	query := "UPDATE master_locations SET"
+	args := []interface{}{}
+	paramCount := 1
+
+	stringFields := []struct {
+		column string
+		value  string
+	}{
+		{"kode", l.Kode},
+		{"nama", l.Nama},
+		{"tipe", l.Tipe},
+		{"alamat", l.Alamat},
+		{"url", l.URL},
+	}
+	for _, field := range stringFields {
+		if field.value != "" {
+			query += fmt.Sprintf(" %s = $%d,", field.column, paramCount)
+			args = append(args, field.value)
+			paramCount++
+		}
+	}
+
+	if l.IsActive != nil {
+		query += fmt.Sprintf(" is_active = $%d,", paramCount)
+		args = append(args, *l.IsActive)
+		paramCount++
+	}
+
+	// Add tanggal_update
+	query += fmt.Sprintf(" tanggal_update = $%d", paramCount)
+	args = append(args, time.Now())
+	paramCount++
+
+	// Add WHERE clause
+	query += fmt.Sprintf(" WHERE id = $%d", paramCount)
+	args = append(args, id)
+
+	// Execute update
+	_, err = DB.Exec(query, args...)
+	if err != nil {
+		return *l, err
+	}
+
+	// Fetch the updated record
+	return FetchLocationByID(id)
+}
+
+// DeleteLocation soft-deletes a location by setting tanggal_hapus.
+// The default location and locations that still hold stock cannot be deleted.
+func DeleteLocation(id int) error {
+	var isDefault bool
+	var onHand int
+	err := DB.QueryRow(`
+		SELECT ml.is_default, COALESCE((SELECT SUM(quantity) FROM product_stocks WHERE location_id = ml.id), 0)
+		FROM master_locations ml WHERE ml.id = $1 AND ml.tanggal_hapus IS NULL`, id).Scan(&isDefault, &onHand)
+	if err == sql.ErrNoRows {
+		return errors.New("not_found")
+	}
+	if err != nil {
+		return err
+	}
+	if isDefault {
+		return errors.New("default_location")
+	}
+	if onHand > 0 {
+		return errors.New("location_has_stock")
+	}
+
+	_, err = DB.Exec(`UPDATE master_locations SET tanggal_hapus = $1 WHERE id = $2 AND tanggal_hapus IS NULL`,
+		time.Now(), id)
+	return err
+}
+
+// RestoreLocation restores a soft-deleted location
+func RestoreLocation(id int) error {
+	_, err := DB.Exec(`UPDATE master_locations SET tanggal_hapus = NULL WHERE id = $1`, id)
+	return err
+}
+
+// CountDeletedLocations counts all deleted locations matching the search query
+func CountDeletedLocations(queryStr string) (int, error) {
+	baseQuery := "SELECT COUNT(id) FROM master_locations WHERE tanggal_hapus IS NOT NULL"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kode ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR alamat ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	var count int
+	err := DB.QueryRow(baseQuery, args...).Scan(&count)
+	return count, err
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"strconv"
 	"time"
 
 	"github.com/everysoft/inventary-be/app/models"
@@ -69,6 +70,11 @@ func CountAllProducts(queryStr string, filters map[string]string, isMarketplaceF
 			paramCount++
 		}
 	}
+	if value, ok := filters["lifecycle_status"]; ok && value != "" {
+		baseQuery += ` AND ` + productLifecycleStatusExpr + ` = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
 
 	// Handle marketplace and offline filters
 	if isMarketplaceFilter {
@@ -135,6 +141,12 @@ func CreateMasterProductsTableIfNotExists() error {
 				ALTER TABLE master_products ADD COLUMN offline JSONB;
 			END IF;
 		END$$;`,
+		// Products that existed before the publishing workflow stay published, new ones start as drafts
+		`ALTER TABLE master_products ADD COLUMN IF NOT EXISTS lifecycle_status TEXT NOT NULL DEFAULT 'published';`,
+		`ALTER TABLE master_products ALTER COLUMN lifecycle_status SET DEFAULT 'draft';`,
+		`ALTER TABLE master_products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;`,
+		`ALTER TABLE master_products ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;`,
+		`CREATE INDEX IF NOT EXISTS idx_master_products_lifecycle_status ON master_products(lifecycle_status);`,
 		`CREATE INDEX IF NOT EXISTS idx_master_products_artikel ON master_products(artikel);`,
 		`CREATE INDEX IF NOT EXISTS idx_master_products_grup ON master_products(grup);`,
 		`CREATE INDEX IF NOT EXISTS idx_master_products_offline ON master_products USING GIN (offline);`,
@@ -166,7 +178,7 @@ func FetchAllProducts(limit, offset int, queryStr string, filters map[string]str
 				END
 			ELSE 'Unknown' 
 		END AS usia,
-		status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus
+		status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus, ` + productLifecycleColumns + `
 	FROM master_products
 	WHERE tanggal_hapus IS NULL`
 
@@ -230,6 +242,11 @@ func FetchAllProducts(limit, offset int, queryStr string, filters map[string]str
 			paramCount++
 		}
 	}
+	if value, ok := filters["lifecycle_status"]; ok && value != "" {
+		baseQuery += ` AND ` + productLifecycleStatusExpr + ` = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
 
 	// Handle marketplace and offline filters
 	if isMarketplaceFilter {
@@ -298,7 +315,7 @@ func FetchAllProducts(limit, offset int, queryStr string, filters map[string]str
 			&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat,
 			&p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk,
 			&p.TanggalTerima, &usia, &p.Status, &p.Supplier,
-			&p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus,
+			&p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus, &p.LifecycleStatus, &p.PublishAt, &p.UnpublishAt,
 		); err != nil {
 			log.Println("DB: Error scanning rows", err)
 			return nil, err
@@ -357,6 +374,14 @@ func FetchAllProducts(limit, offset int, queryStr string, filters map[string]str
 		products = append(products, p)
 	}
 
+	// Attach per-variant stock quantities
+	if err := attachProductStocks(products, 0); err != nil {
+		log.Printf("Error fetching stock for products: %v", err)
+	}
+	if err := attachProductVariants(products); err != nil {
+		log.Printf("Error fetching variants for products: %v", err)
+	}
+
 	return products, nil
 }
 
@@ -380,11 +405,11 @@ func FetchProductByID(id int) (models.Product, error) {
 					END
 				ELSE 'Unknown' 
 			END AS usia,
-			status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus 
+			status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus, `+productLifecycleColumns+`
 			FROM master_products 
 		WHERE no = $1 AND tanggal_hapus IS NULL
 	`, id).
-		Scan(&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat, &p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk, &p.TanggalTerima, &usia, &p.Status, &p.Supplier, &p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus)
+		Scan(&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat, &p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk, &p.TanggalTerima, &usia, &p.Status, &p.Supplier, &p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus, &p.LifecycleStatus, &p.PublishAt, &p.UnpublishAt)
 
 	if err == sql.ErrNoRows {
 		return p, errors.New("not_found")
@@ -444,6 +469,19 @@ func FetchProductByID(id int) (models.Product, error) {
 		p.Colors = colors
 	}
 
+	// Attach per-variant stock quantities
+	withStock := []models.Product{p}
+	if err := attachProductStocks(withStock, 0); err != nil {
+		log.Printf("Error fetching stock for product %s: %v", p.Artikel, err)
+	} else {
+		p = withStock[0]
+	}
+	if err := attachProductVariants(withStock); err != nil {
+		log.Printf("Error fetching variants for product %s: %v", p.Artikel, err)
+	} else {
+		p.Variants = withStock[0].Variants
+	}
+
 	return p, nil
 }
 
@@ -467,11 +505,11 @@ func FetchProductByIDIncludeDeleted(id int) (models.Product, error) {
 					END
 				ELSE 'Unknown' 
 			END AS usia,
-			status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus 
+			status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus, `+productLifecycleColumns+`
 		FROM master_products 
 		WHERE no = $1
 	`, id).
-		Scan(&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat, &p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk, &p.TanggalTerima, &usia, &p.Status, &p.Supplier, &p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus)
+		Scan(&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat, &p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk, &p.TanggalTerima, &usia, &p.Status, &p.Supplier, &p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus, &p.LifecycleStatus, &p.PublishAt, &p.UnpublishAt)
 
 	if err == sql.ErrNoRows {
 		return p, errors.New("not_found")
@@ -554,13 +592,13 @@ func InsertProduct(p *models.Product) error {
 		INSERT INTO master_products 
 		(artikel, nama, deskripsi, rating, warna, size, grup, unit, kat, model, gender, tipe, harga, harga_diskon, marketplace, offline, gambar, tanggal_produk, tanggal_terima, status, supplier, diupdate_oleh, tanggal_update) 
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
-		RETURNING no`)
+		RETURNING no, lifecycle_status`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	return stmt.QueryRow(
+	err = stmt.QueryRow(
 		p.Artikel,
 		p.Nama,
 		p.Deskripsi,
@@ -584,7 +622,20 @@ func InsertProduct(p *models.Product) error {
 		p.Supplier,
 		p.DiupdateOleh,
 		p.TanggalUpdate,
-	).Scan(&p.No)
+	).Scan(&p.No, &p.LifecycleStatus)
+	if err != nil {
+		return err
+	}
+
+	productNo, err := strconv.Atoi(p.No)
+	if err != nil {
+		return err
+	}
+	if err := recordPriceChange(DB, productNo, p.Harga, p.HargaDiskon, p.TanggalUpdate, p.DiupdateOleh, models.PriceChangeSourceInitial, nil); err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	p.Variants, err = SyncProductVariants(productNo, p.Artikel, p.Warna, p.Size)
+	return err
 }
 
 func UpdateProduct(id int, p *models.Product) (models.Product, error) {
@@ -695,6 +746,13 @@ func UpdateProduct(id int, p *models.Product) (models.Product, error) {
 		"tanggal_terima": p.TanggalTerima,
 	}
 
+	// Once goods receipts exist the receipt date is derived from them
+	if hasReceipts, err := ProductHasReceipts(id); err != nil {
+		return *p, err
+	} else if hasReceipts {
+		delete(dateFields, "tanggal_terima")
+	}
+
 	for field, value := range dateFields {
 		if value != zeroTime {
 			fieldsToUpdate++
@@ -722,8 +780,14 @@ func UpdateProduct(id int, p *models.Product) (models.Product, error) {
 		return currentProduct, nil
 	}
 
-	// Execute the query
-	result, err := DB.Exec(query, args...)
+	// Execute the query together with the price history entry of a price change
+	tx, err := DB.Begin()
+	if err != nil {
+		return *p, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		return *p, err
 	}
@@ -736,6 +800,34 @@ func UpdateProduct(id int, p *models.Product) (models.Product, error) {
 		return *p, errors.New("not_found")
 	}
 
+	harga := currentProduct.Harga
+	if p.Harga != 0 {
+		harga = p.Harga
+	}
+	if priceChanged(harga, p.HargaDiskon, currentProduct) {
+		if err := recordPriceChange(tx, id, harga, p.HargaDiskon, p.TanggalUpdate, p.DiupdateOleh, models.PriceChangeSourceManual, nil); err != nil {
+			return *p, fmt.Errorf("failed to record price history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return *p, err
+	}
+
+	// New colors or sizes add variants, dropped ones retire theirs
+	if (p.Warna != "" && p.Warna != currentProduct.Warna) || (p.Size != "" && p.Size != currentProduct.Size) {
+		warna, size := currentProduct.Warna, currentProduct.Size
+		if p.Warna != "" {
+			warna = p.Warna
+		}
+		if p.Size != "" {
+			size = p.Size
+		}
+		if _, err := SyncProductVariants(id, currentProduct.Artikel, warna, size); err != nil {
+			return *p, fmt.Errorf("failed to sync variants: %w", err)
+		}
+	}
+
 	// Fetch and return the updated product
 	return FetchProductByID(id)
 }
@@ -777,7 +869,7 @@ func RestoreProduct(id int) error {
 	return nil
 }
 
-// FetchFilterOptions retrieves all unique values for specified fields
+// FetchFilterOptions retrieves all unique values for specified fields across the published products
 func FetchFilterOptions() (map[string]map[string]interface{}, error) {
 	// Define the fields we want to get unique values for
 	fields := []string{
@@ -794,8 +886,8 @@ func FetchFilterOptions() (map[string]map[string]interface{}, error) {
 		values := []string{}
 
 		// Get unique values for this field, excluding NULL/empty values and soft-deleted records
-		query := fmt.Sprintf("SELECT DISTINCT %s FROM master_products WHERE %s IS NOT NULL AND %s != '' AND tanggal_hapus IS NULL ORDER BY %s",
-			field, field, field, field)
+		query := fmt.Sprintf("SELECT DISTINCT %s FROM master_products WHERE %s IS NOT NULL AND %s != '' AND tanggal_hapus IS NULL AND %s = '%s' ORDER BY %s",
+			field, field, field, productLifecycleStatusExpr, models.ProductLifecyclePublished, field)
 
 		rows, err := DB.Query(query)
 		if err != nil {
@@ -840,7 +932,7 @@ func FetchDeletedProducts(limit, offset int, queryStr string, filters map[string
 				END
 			ELSE 'Unknown' 
 		END AS usia,
-		status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus
+		status, supplier, diupdate_oleh, tanggal_update, tanggal_hapus, ` + productLifecycleColumns + `
 	FROM master_products
 	WHERE tanggal_hapus IS NOT NULL`
 
@@ -945,7 +1037,7 @@ func FetchDeletedProducts(limit, offset int, queryStr string, filters map[string
 			&p.No, &p.Artikel, &p.Nama, &p.Deskripsi, &ratingJSON, &p.Warna, &p.Size, &p.Grup, &p.Unit, &p.Kat,
 			&p.Model, &p.Gender, &p.Tipe, &p.Harga, &hargaDiskonNull, &marketplaceJSON, &offlineJSON, pq.Array(&p.Gambar), &p.TanggalProduk,
 			&p.TanggalTerima, &usia, &p.Status, &p.Supplier,
-			&p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus,
+			&p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus, &p.LifecycleStatus, &p.PublishAt, &p.UnpublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,426 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// ErrSupplierNameTaken is returned when an active supplier already has a name that normalizes to the same value
+var ErrSupplierNameTaken = errors.New("duplicate_name")
+
+// CreateMasterSuppliersTableIfNotExists ensures the master_suppliers table exists.
+// On first run the free-text suppliers of existing products are merged into supplier records:
+// spellings that only differ in case, spacing or a PT/CV/UD prefix become one supplier named after
+// the most used spelling, and products and goods receipts are rewritten to that name.
+func CreateMasterSuppliersTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS master_suppliers (
+			id SERIAL PRIMARY KEY,
+			nama TEXT NOT NULL,
+			kontak TEXT,
+			telepon TEXT,
+			email TEXT,
+			alamat TEXT,
+			termin_pembayaran INTEGER CHECK (termin_pembayaran >= 0),
+			catatan TEXT,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			tanggal_hapus TIMESTAMPTZ
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_master_suppliers_nama ON master_suppliers(nama);`,
+		`CREATE OR REPLACE FUNCTION normalize_supplier_name(name TEXT) RETURNS TEXT AS $$
+			SELECT lower(regexp_replace(regexp_replace(trim(name), '^(pt|cv|ud)\.?\s+', '', 'i'), '\s+', ' ', 'g'))
+		$$ LANGUAGE sql IMMUTABLE;`,
+		`INSERT INTO master_suppliers (nama)
+		SELECT DISTINCT ON (s.key) s.nama
+		FROM (
+			SELECT trim(supplier) AS nama, normalize_supplier_name(supplier) AS key, COUNT(*) AS usage
+			FROM master_products
+			WHERE supplier IS NOT NULL AND trim(supplier) <> ''
+			GROUP BY trim(supplier), normalize_supplier_name(supplier)
+		) s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM master_suppliers ms WHERE normalize_supplier_name(ms.nama) = s.key
+		)
+		ORDER BY s.key, s.usage DESC, s.nama;`,
+		// Spellings of an older supplier created before names were unique are retired so the index can be built
+		`UPDATE master_suppliers ms SET tanggal_hapus = CURRENT_TIMESTAMP
+		WHERE ms.tanggal_hapus IS NULL AND EXISTS (
+			SELECT 1 FROM master_suppliers o
+			WHERE o.tanggal_hapus IS NULL AND o.id < ms.id
+				AND normalize_supplier_name(o.nama) = normalize_supplier_name(ms.nama)
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_master_suppliers_normalized_nama
+		ON master_suppliers (normalize_supplier_name(nama)) WHERE tanggal_hapus IS NULL;`,
+		// The unique index leaves at most one active supplier per normalized name, so products always map to the same one
+		`UPDATE master_products mp SET supplier = ms.nama
+		FROM master_suppliers ms
+		WHERE ms.tanggal_hapus IS NULL
+			AND normalize_supplier_name(mp.supplier) = normalize_supplier_name(ms.nama)
+			AND mp.supplier <> ms.nama;`,
+		// Goods receipts reference their supplier by ID
+		`ALTER TABLE goods_receipts ADD COLUMN IF NOT EXISTS supplier_id INTEGER REFERENCES master_suppliers(id);`,
+		`INSERT INTO master_suppliers (nama)
+		SELECT DISTINCT ON (normalize_supplier_name(gr.supplier)) trim(gr.supplier)
+		FROM goods_receipts gr
+		WHERE gr.supplier_id IS NULL AND NOT EXISTS (
+			SELECT 1 FROM master_suppliers ms WHERE normalize_supplier_name(ms.nama) = normalize_supplier_name(gr.supplier)
+		)
+		ORDER BY normalize_supplier_name(gr.supplier), trim(gr.supplier);`,
+		// Active suppliers are preferred over deleted spellings, then the oldest, so every run picks the same one
+		`UPDATE goods_receipts gr SET supplier_id = ms.id, supplier = ms.nama
+		FROM (
+			SELECT DISTINCT ON (normalize_supplier_name(nama)) id, nama, normalize_supplier_name(nama) AS key
+			FROM master_suppliers
+			ORDER BY normalize_supplier_name(nama), tanggal_hapus IS NOT NULL, id
+		) ms
+		WHERE gr.supplier_id IS NULL AND normalize_supplier_name(gr.supplier) = ms.key;`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipts_supplier_id ON goods_receipts(supplier_id);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured master_suppliers table exists")
+	return nil
+}
+
+// CountAllSuppliers counts all available suppliers matching the search query
+func CountAllSuppliers(queryStr string) (int, error) {
+	baseQuery := "SELECT COUNT(id) FROM master_suppliers WHERE tanggal_hapus IS NULL"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kontak ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR telepon ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR email ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	var count int
+	err := DB.QueryRow(baseQuery, args...).Scan(&count)
+	return count, err
+}
+
+// FetchAllSuppliers retrieves all suppliers matching the search query with pagination
+func FetchAllSuppliers(limit, offset int, queryStr string, sortColumn string, sortDirection string) ([]models.Supplier, error) {
+	return fetchSuppliers(false, limit, offset, queryStr, sortColumn, sortDirection)
+}
+
+// FetchDeletedSuppliers retrieves all deleted suppliers matching the search query with pagination
+func FetchDeletedSuppliers(limit, offset int, queryStr string, sortColumn string, sortDirection string) ([]models.Supplier, error) {
+	return fetchSuppliers(true, limit, offset, queryStr, sortColumn, sortDirection)
+}
+
+// fetchSuppliers is the shared query behind FetchAllSuppliers and FetchDeletedSuppliers
+func fetchSuppliers(deleted bool, limit, offset int, queryStr string, sortColumn string, sortDirection string) ([]models.Supplier, error) {
+	suppliers := []models.Supplier{}
+
+	// Start building the query with parameters
+	baseQuery := `
+	SELECT 
+		id, nama, COALESCE(kontak, ''), COALESCE(telepon, ''), COALESCE(email, ''), COALESCE(alamat, ''),
+		termin_pembayaran, COALESCE(catatan, ''), tanggal_update, tanggal_hapus
+	FROM master_suppliers`
+	if deleted {
+		baseQuery += " WHERE tanggal_hapus IS NOT NULL"
+	} else {
+		baseQuery += " WHERE tanggal_hapus IS NULL"
+	}
+
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kontak ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR telepon ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR email ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nama": true, "kontak": true, "termin_pembayaran": true, "tanggal_update": true, "tanggal_hapus": deleted,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "id"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "asc"
+	}
+
+	orderBy += sortColumn + " " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	// Execute the query with all parameters
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s models.Supplier
+		if err := rows.Scan(
+			&s.ID, &s.Nama, &s.Kontak, &s.Telepon, &s.Email, &s.Alamat,
+			&s.TerminPembayaran, &s.Catatan, &s.TanggalUpdate, &s.TanggalHapus,
+		); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+
+	return suppliers, nil
+}
+
+// FetchSupplierByID retrieves a supplier by its ID
+func FetchSupplierByID(id int) (models.Supplier, error) {
+	var s models.Supplier
+	err := DB.QueryRow(`
+		SELECT id, nama, COALESCE(kontak, ''), COALESCE(telepon, ''), COALESCE(email, ''), COALESCE(alamat, ''),
+			termin_pembayaran, COALESCE(catatan, ''), tanggal_update, tanggal_hapus
+		FROM master_suppliers WHERE id = $1 AND tanggal_hapus IS NULL`, id).
+		Scan(&s.ID, &s.Nama, &s.Kontak, &s.Telepon, &s.Email, &s.Alamat, &s.TerminPembayaran, &s.Catatan, &s.TanggalUpdate, &s.TanggalHapus)
+
+	if err == sql.ErrNoRows {
+		return s, errors.New("not_found")
+	}
+	return s, err
+}
+
+// SupplierNameExists reports whether an active supplier other than excludeID has a name that normalizes to the same value
+func SupplierNameExists(nama string, excludeID int) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM master_suppliers
+			WHERE tanggal_hapus IS NULL AND id <> $2
+				AND normalize_supplier_name(nama) = normalize_supplier_name($1)
+		)`, nama, excludeID).Scan(&exists)
+	return exists, err
+}
+
+// supplierNameError turns a violation of the unique supplier name index into ErrSupplierNameTaken
+func supplierNameError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrSupplierNameTaken
+	}
+	return err
+}
+
+// InsertSupplier inserts a new supplier record
+func InsertSupplier(s *models.Supplier) error {
+	stmt, err := DB.Prepare(`
+		INSERT INTO master_suppliers 
+		(nama, kontak, telepon, email, alamat, termin_pembayaran, catatan, tanggal_update) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(
+		s.Nama,
+		s.Kontak,
+		s.Telepon,
+		s.Email,
+		s.Alamat,
+		s.TerminPembayaran,
+		s.Catatan,
+		s.TanggalUpdate,
+	).Scan(&s.ID)
+	return supplierNameError(err)
+}
+
+// UpdateSupplier updates an existing supplier record.
+// Renaming a supplier also renames it on the products that reference it.
+func UpdateSupplier(id int, s *models.Supplier) (models.Supplier, error) {
+	// First check if the supplier exists
+	current, err := FetchSupplierByID(id)
+	if err != nil {
+		return *s, err
+	}
+
+	// Build dynamic query with only fields that need to be updated
+	query := "UPDATE master_suppliers SET"
+	args := []interface{}{}
+	paramCount := 1
+
+	stringFields := []struct {
+		column string
+		value  string
+	}{
+		{"nama", s.Nama},
+		{"kontak", s.Kontak},
+		{"telepon", s.Telepon},
+		{"email", s.Email},
+		{"alamat", s.Alamat},
+		{"catatan", s.Catatan},
+	}
+	for _, field := range stringFields {
+		if field.value != "" {
+			query += fmt.Sprintf(" %s = $%d,", field.column, paramCount)
+			args = append(args, field.value)
+			paramCount++
+		}
+	}
+
+	if s.TerminPembayaran != nil {
+		query += fmt.Sprintf(" termin_pembayaran = $%d,", paramCount)
+		args = append(args, *s.TerminPembayaran)
+		paramCount++
+	}
+
+	// Add tanggal_update
+	query += fmt.Sprintf(" tanggal_update = $%d", paramCount)
+	args = append(args, time.Now())
+	paramCount++
+
+	// Add WHERE clause
+	query += fmt.Sprintf(" WHERE id = $%d", paramCount)
+	args = append(args, id)
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return *s, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return *s, supplierNameError(err)
+	}
+
+	// Products store the supplier name, keep them in sync
+	if s.Nama != "" && s.Nama != current.Nama {
+		if _, err := tx.Exec(`UPDATE master_products SET supplier = $1 WHERE supplier = $2`, s.Nama, current.Nama); err != nil {
+			return *s, err
+		}
+		if _, err := tx.Exec(`UPDATE goods_receipts SET supplier = $1 WHERE supplier_id = $2`, s.Nama, id); err != nil {
+			return *s, err
+		}
+		if _, err := tx.Exec(`UPDATE purchase_orders SET supplier = $1 WHERE supplier_id = $2`, s.Nama, id); err != nil {
+			return *s, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return *s, err
+	}
+
+	// Fetch the updated record
+	return FetchSupplierByID(id)
+}
+
+// DeleteSupplier soft-deletes a supplier by setting tanggal_hapus. Suppliers still set on active
+// products or on purchase orders that are not closed or cancelled cannot be deleted.
+func DeleteSupplier(id int) error {
+	var products, orders int
+	err := DB.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM master_products mp WHERE mp.tanggal_hapus IS NULL
+				AND normalize_supplier_name(mp.supplier) = normalize_supplier_name(ms.nama)),
+			(SELECT COUNT(*) FROM purchase_orders po WHERE po.supplier_id = ms.id AND po.status NOT IN ($2, $3))
+		FROM master_suppliers ms WHERE ms.id = $1 AND ms.tanggal_hapus IS NULL`,
+		id, models.PurchaseOrderStatusClosed, models.PurchaseOrderStatusCancelled).Scan(&products, &orders)
+	if err == sql.ErrNoRows {
+		return errors.New("not_found")
+	}
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return errors.New("supplier_has_products")
+	}
+	if orders > 0 {
+		return errors.New("supplier_has_open_orders")
+	}
+
+	_, err = DB.Exec(`UPDATE master_suppliers SET tanggal_hapus = $1 WHERE id = $2 AND tanggal_hapus IS NULL`,
+		time.Now(), id)
+	return err
+}
+
+// RestoreSupplier restores a soft-deleted supplier, unless an active supplier has since taken its name
+func RestoreSupplier(id int) error {
+	exists, err := restoredSupplierNameTaken(id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrSupplierNameTaken
+	}
+
+	_, err = DB.Exec(`UPDATE master_suppliers SET tanggal_hapus = NULL WHERE id = $1`, id)
+	return supplierNameError(err)
+}
+
+// restoredSupplierNameTaken reports whether an active supplier other than id has a name that normalizes to the name of supplier id
+func restoredSupplierNameTaken(id int) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM master_suppliers ms
+			JOIN master_suppliers other ON normalize_supplier_name(other.nama) = normalize_supplier_name(ms.nama)
+			WHERE ms.id = $1 AND other.id <> ms.id AND other.tanggal_hapus IS NULL
+		)`, id).Scan(&exists)
+	return exists, err
+}
+
+// CountDeletedSuppliers counts all deleted suppliers matching the search query
+func CountDeletedSuppliers(queryStr string) (int, error) {
+	baseQuery := "SELECT COUNT(id) FROM master_suppliers WHERE tanggal_hapus IS NOT NULL"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR kontak ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR telepon ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR email ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		baseQuery += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	var count int
+	err := DB.QueryRow(baseQuery, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// productLifecycleStatusExpr is the lifecycle status of a product in SQL. A passed unpublish time archives a
+// published product and a passed publish time publishes a draft or in-review one right away; the publish
+// scheduler only records it.
+const productLifecycleStatusExpr = `(CASE
+		WHEN lifecycle_status = 'published' AND unpublish_at IS NOT NULL AND unpublish_at <= NOW() THEN 'archived'
+		WHEN lifecycle_status IN ('draft', 'in_review') AND publish_at IS NOT NULL AND publish_at <= NOW()
+			AND (unpublish_at IS NULL OR unpublish_at > NOW()) THEN 'published'
+		ELSE lifecycle_status
+	END)`
+
+// productLifecycleColumns selects the lifecycle status and publish schedule of a product
+const productLifecycleColumns = productLifecycleStatusExpr + ` AS lifecycle_status, publish_at, unpublish_at`
+
+// TransitionProductLifecycle moves a product from one lifecycle status to another. Publishing clears the
+// publish time and archiving the unpublish time, as they have been used; moving back to draft clears the
+// publish time so that a draft is not published by an old schedule. The product must still be in from.
+func TransitionProductLifecycle(id int, from, to, username string) (models.Product, error) {
+	query := `UPDATE master_products SET lifecycle_status = $1, diupdate_oleh = $2, tanggal_update = $3`
+	switch to {
+	case models.ProductLifecyclePublished, models.ProductLifecycleDraft:
+		query += `, publish_at = NULL`
+	case models.ProductLifecycleArchived:
+		query += `, unpublish_at = NULL`
+	}
+	query += ` WHERE no = $4 AND tanggal_hapus IS NULL AND ` + productLifecycleStatusExpr + ` = $5`
+
+	result, err := DB.Exec(query, to, username, time.Now(), id, from)
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.Product{}, err
+	}
+	if rowsAffected == 0 {
+		if _, err := FetchProductByID(id); err != nil {
+			return models.Product{}, err
+		}
+		return models.Product{}, errors.New("invalid_status")
+	}
+	return FetchProductByID(id)
+}
+
+// UpdateProductPublishSchedule sets when a product is published and unpublished; nil clears a time
+func UpdateProductPublishSchedule(id int, publishAt, unpublishAt *time.Time, username string) (models.Product, error) {
+	result, err := DB.Exec(`
+		UPDATE master_products SET publish_at = $1, unpublish_at = $2, diupdate_oleh = $3, tanggal_update = $4
+		WHERE no = $5 AND tanggal_hapus IS NULL`,
+		publishAt, unpublishAt, username, time.Now(), id)
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.Product{}, err
+	}
+	if rowsAffected == 0 {
+		return models.Product{}, errors.New("not_found")
+	}
+	return FetchProductByID(id)
+}
+
+// ApplyProductPublishSchedules publishes the draft and in-review products whose publish time has passed and
+// archives the published products whose unpublish time has passed, returning how many of each changed
+func ApplyProductPublishSchedules() (int, int, error) {
+	now := time.Now()
+	result, err := DB.Exec(`
+		UPDATE master_products SET lifecycle_status = $1, publish_at = NULL, tanggal_update = $2
+		WHERE tanggal_hapus IS NULL AND lifecycle_status IN ($3, $4) AND publish_at <= $2
+			AND (unpublish_at IS NULL OR unpublish_at > $2)`,
+		models.ProductLifecyclePublished, now, models.ProductLifecycleDraft, models.ProductLifecycleInReview)
+	if err != nil {
+		return 0, 0, err
+	}
+	published, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	result, err = DB.Exec(`
+		UPDATE master_products SET lifecycle_status = $1, unpublish_at = NULL, tanggal_update = $2
+		WHERE tanggal_hapus IS NULL AND lifecycle_status = $3 AND unpublish_at <= $2`,
+		models.ProductLifecycleArchived, now, models.ProductLifecyclePublished)
+	if err != nil {
+		return int(published), 0, err
+	}
+	archived, err := result.RowsAffected()
+	return int(published), int(archived), err
+}
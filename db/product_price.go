@@ -0,0 +1,276 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// CreateProductPriceTablesIfNotExists ensures the product_price_history and product_price_schedules tables exist.
+// Products without history get an initial entry with their current price.
+func CreateProductPriceTablesIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS product_price_schedules (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			harga NUMERIC(15,2) NOT NULL,
+			harga_diskon NUMERIC(15,2),
+			effective_at TIMESTAMPTZ NOT NULL,
+			notes TEXT,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			closed_by TEXT,
+			closed_at TIMESTAMPTZ
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_price_schedules_pending ON product_price_schedules(effective_at) WHERE status = 'pending';`,
+		`CREATE INDEX IF NOT EXISTS idx_product_price_schedules_product_no ON product_price_schedules(product_no);`,
+		`CREATE TABLE IF NOT EXISTS product_price_history (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			harga NUMERIC(15,2),
+			harga_diskon NUMERIC(15,2),
+			effective_from TIMESTAMPTZ NOT NULL,
+			effective_to TIMESTAMPTZ,
+			changed_by TEXT,
+			source TEXT NOT NULL,
+			schedule_id INTEGER REFERENCES product_price_schedules(id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_price_history_product_no ON product_price_history(product_no, effective_from);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_price_history_current ON product_price_history(product_no) WHERE effective_to IS NULL;`,
+		`INSERT INTO product_price_history (product_no, harga, harga_diskon, effective_from, changed_by, source)
+		SELECT p.no, p.harga, p.harga_diskon, COALESCE(p.tanggal_update, CURRENT_TIMESTAMP), p.diupdate_oleh, 'initial'
+		FROM master_products p
+		WHERE NOT EXISTS (SELECT 1 FROM product_price_history h WHERE h.product_no = p.no);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured product_price_history and product_price_schedules tables exist")
+	return nil
+}
+
+// recordPriceChange closes the current price history entry of a product at the given time and starts a new one
+func recordPriceChange(exec interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, productNo int, harga float64, hargaDiskon *float64, at time.Time, changedBy, source string, scheduleID *int) error {
+	if _, err := exec.Exec(`UPDATE product_price_history SET effective_to = $1 WHERE product_no = $2 AND effective_to IS NULL`,
+		at, productNo); err != nil {
+		return err
+	}
+	_, err := exec.Exec(`
+		INSERT INTO product_price_history (product_no, harga, harga_diskon, effective_from, changed_by, source, schedule_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		productNo, harga, hargaDiskon, at, changedBy, source, scheduleID)
+	return err
+}
+
+// priceChanged reports whether a price and discount differ from the current ones
+func priceChanged(harga float64, hargaDiskon *float64, current models.Product) bool {
+	if harga != current.Harga {
+		return true
+	}
+	if (hargaDiskon == nil) != (current.HargaDiskon == nil) {
+		return true
+	}
+	return hargaDiskon != nil && *hargaDiskon != *current.HargaDiskon
+}
+
+// FetchProductPriceTimeline retrieves the price history of a product, newest first, and its pending schedules
+func FetchProductPriceTimeline(productNo int) (models.ProductPriceTimeline, error) {
+	timeline := models.ProductPriceTimeline{
+		History:   []models.ProductPriceChange{},
+		Scheduled: []models.ProductPriceSchedule{},
+	}
+
+	rows, err := DB.Query(`
+		SELECT id, product_no, COALESCE(harga, 0), harga_diskon, effective_from, effective_to, COALESCE(changed_by, ''), source, schedule_id
+		FROM product_price_history
+		WHERE product_no = $1
+		ORDER BY effective_from DESC, id DESC`, productNo)
+	if err != nil {
+		return timeline, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.ProductPriceChange
+		var hargaDiskon sql.NullFloat64
+		var scheduleID sql.NullInt64
+		if err := rows.Scan(&change.ID, &change.ProductNo, &change.Harga, &hargaDiskon, &change.EffectiveFrom, &change.EffectiveTo,
+			&change.ChangedBy, &change.Source, &scheduleID); err != nil {
+			return timeline, err
+		}
+		if hargaDiskon.Valid {
+			change.HargaDiskon = &hargaDiskon.Float64
+		}
+		if scheduleID.Valid {
+			id := int(scheduleID.Int64)
+			change.ScheduleID = &id
+		}
+		timeline.History = append(timeline.History, change)
+	}
+	if err := rows.Err(); err != nil {
+		return timeline, err
+	}
+
+	timeline.Scheduled, err = fetchPriceSchedules(`WHERE product_no = $1 AND status = $2 ORDER BY effective_at, id`,
+		productNo, models.PriceScheduleStatusPending)
+	return timeline, err
+}
+
+// productPriceScheduleSelect selects the columns scanned by scanPriceSchedule
+const productPriceScheduleSelect = `
+	SELECT id, product_no, harga, harga_diskon, effective_at, COALESCE(notes, ''), status,
+		COALESCE(created_by, ''), created_at, COALESCE(closed_by, ''), closed_at
+	FROM product_price_schedules `
+
+// scanPriceSchedule scans a row selected with productPriceScheduleSelect
+func scanPriceSchedule(scanner interface{ Scan(...interface{}) error }, s *models.ProductPriceSchedule) error {
+	var hargaDiskon sql.NullFloat64
+	if err := scanner.Scan(&s.ID, &s.ProductNo, &s.Harga, &hargaDiskon, &s.EffectiveAt, &s.Notes, &s.Status,
+		&s.CreatedBy, &s.CreatedAt, &s.ClosedBy, &s.ClosedAt); err != nil {
+		return err
+	}
+	if hargaDiskon.Valid {
+		s.HargaDiskon = &hargaDiskon.Float64
+	}
+	return nil
+}
+
+// fetchPriceSchedules retrieves the price schedules matching the given WHERE and ORDER BY clause
+func fetchPriceSchedules(clause string, args ...interface{}) ([]models.ProductPriceSchedule, error) {
+	rows, err := DB.Query(productPriceScheduleSelect+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.ProductPriceSchedule{}
+	for rows.Next() {
+		var s models.ProductPriceSchedule
+		if err := scanPriceSchedule(rows, &s); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// FetchProductPriceScheduleByID retrieves a price schedule of a product
+func FetchProductPriceScheduleByID(productNo, id int) (models.ProductPriceSchedule, error) {
+	var s models.ProductPriceSchedule
+	err := scanPriceSchedule(DB.QueryRow(productPriceScheduleSelect+`WHERE product_no = $1 AND id = $2`, productNo, id), &s)
+	if err == sql.ErrNoRows {
+		return s, errors.New("not_found")
+	}
+	return s, err
+}
+
+// InsertProductPriceSchedule schedules a price change of a product
+func InsertProductPriceSchedule(productNo int, req *models.CreatePriceScheduleRequest, username string) (models.ProductPriceSchedule, error) {
+	var id int
+	if err := DB.QueryRow(`
+		INSERT INTO product_price_schedules (product_no, harga, harga_diskon, effective_at, notes, status, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		productNo, req.Harga, req.HargaDiskon, req.Effective, req.Notes, models.PriceScheduleStatusPending, username, time.Now(),
+	).Scan(&id); err != nil {
+		return models.ProductPriceSchedule{}, err
+	}
+	return FetchProductPriceScheduleByID(productNo, id)
+}
+
+// CancelProductPriceSchedule cancels a pending price schedule
+func CancelProductPriceSchedule(productNo, id int, username string) (models.ProductPriceSchedule, error) {
+	result, err := DB.Exec(`
+		UPDATE product_price_schedules SET status = $1, closed_by = $2, closed_at = $3
+		WHERE product_no = $4 AND id = $5 AND status = $6`,
+		models.PriceScheduleStatusCancelled, username, time.Now(), productNo, id, models.PriceScheduleStatusPending)
+	if err != nil {
+		return models.ProductPriceSchedule{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.ProductPriceSchedule{}, err
+	}
+	if rowsAffected == 0 {
+		if _, err := FetchProductPriceScheduleByID(productNo, id); err != nil {
+			return models.ProductPriceSchedule{}, err
+		}
+		return models.ProductPriceSchedule{}, errors.New("invalid_status")
+	}
+	return FetchProductPriceScheduleByID(productNo, id)
+}
+
+// ApplyDuePriceSchedules applies every pending price schedule whose effective time has passed and returns how many
+// were applied. When several schedules of a product are due only the latest is applied, the others are skipped.
+func ApplyDuePriceSchedules() (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(productPriceScheduleSelect+`
+		WHERE status = $1 AND effective_at <= $2
+		ORDER BY effective_at, id
+		FOR UPDATE SKIP LOCKED`, models.PriceScheduleStatusPending, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	latest := make(map[int]models.ProductPriceSchedule)
+	var skipped []int
+	for rows.Next() {
+		var s models.ProductPriceSchedule
+		if err := scanPriceSchedule(rows, &s); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if previous, ok := latest[s.ProductNo]; ok {
+			skipped = append(skipped, previous.ID)
+		}
+		latest[s.ProductNo] = s
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, id := range skipped {
+		if _, err := tx.Exec(`UPDATE product_price_schedules SET status = $1, closed_at = $2 WHERE id = $3`,
+			models.PriceScheduleStatusSkipped, now, id); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, s := range latest {
+		if _, err := tx.Exec(`UPDATE master_products SET harga = $1, harga_diskon = $2, diupdate_oleh = $3, tanggal_update = $4 WHERE no = $5`,
+			s.Harga, s.HargaDiskon, s.CreatedBy, now, s.ProductNo); err != nil {
+			return 0, err
+		}
+		scheduleID := s.ID
+		if err := recordPriceChange(tx, s.ProductNo, s.Harga, s.HargaDiskon, now, s.CreatedBy, models.PriceChangeSourceScheduled, &scheduleID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`UPDATE product_price_schedules SET status = $1, closed_at = $2 WHERE id = $3`,
+			models.PriceScheduleStatusApplied, now, s.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(latest), nil
+}
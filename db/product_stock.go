@@ -0,0 +1,319 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// variantKey identifies a product variant by color and size
+type variantKey struct {
+	ColorID int
+	Size    string
+}
+
+// CreateProductStocksTableIfNotExists ensures the product_stocks table exists
+func CreateProductStocksTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS product_stocks (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			location_id INTEGER,
+			quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		// Stock recorded before locations existed belongs to the default location
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'product_stocks' AND column_name = 'location_id'
+			) THEN
+				ALTER TABLE product_stocks ADD COLUMN location_id INTEGER;
+			END IF;
+		END$$;`,
+		`UPDATE product_stocks
+		SET location_id = (SELECT id FROM master_locations WHERE is_default ORDER BY id LIMIT 1)
+		WHERE location_id IS NULL;`,
+		`ALTER TABLE product_stocks ALTER COLUMN location_id SET NOT NULL;`,
+		`ALTER TABLE product_stocks DROP CONSTRAINT IF EXISTS uq_product_stocks_variant;`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'uq_product_stocks_variant_location'
+			) THEN
+				ALTER TABLE product_stocks ADD CONSTRAINT uq_product_stocks_variant_location UNIQUE (product_no, color_id, size, location_id);
+			END IF;
+		END$$;`,
+		`CREATE INDEX IF NOT EXISTS idx_product_stocks_product_no ON product_stocks(product_no);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_stocks_location_id ON product_stocks(location_id);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured product_stocks table exists")
+	return nil
+}
+
+// ExpandSizes turns a product size string such as "38-44" or "38,40,42" into the individual size values
+func ExpandSizes(size string) []string {
+	sizes := []string{}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(size, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if strings.Contains(part, "-") {
+			rangeParts := strings.Split(part, "-")
+			if len(rangeParts) == 2 {
+				start, errStart := strconv.Atoi(strings.TrimSpace(rangeParts[0]))
+				end, errEnd := strconv.Atoi(strings.TrimSpace(rangeParts[1]))
+				if errStart == nil && errEnd == nil && start <= end {
+					for s := start; s <= end; s++ {
+						value := strconv.Itoa(s)
+						if !seen[value] {
+							seen[value] = true
+							sizes = append(sizes, value)
+						}
+					}
+					continue
+				}
+			}
+		}
+
+		if !seen[part] {
+			seen[part] = true
+			sizes = append(sizes, part)
+		}
+	}
+
+	return sizes
+}
+
+// ParseColorIDs turns the comma-separated warna column into a list of color IDs, skipping invalid entries
+func ParseColorIDs(warna string) []int {
+	ids := []int{}
+	for _, part := range strings.Split(warna, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// fetchStockRows retrieves the stored stock rows for the given products keyed by product number,
+// aggregating locations per variant with their reserved and available quantities. A locationID of 0 includes every location.
+func fetchStockRows(productNos []int, locationID int) (map[int]map[variantKey]models.ProductStock, error) {
+	result := make(map[int]map[variantKey]models.ProductStock)
+	if len(productNos) == 0 {
+		return result, nil
+	}
+
+	reserved, err := fetchReservedQuantities(productNos, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ps.product_no, ps.color_id, COALESCE(mc.nama, ''), ps.size, ps.location_id, COALESCE(ml.nama, ''), ps.quantity, ps.tanggal_update
+		FROM product_stocks ps
+		LEFT JOIN master_colors mc ON mc.id = ps.color_id
+		LEFT JOIN master_locations ml ON ml.id = ps.location_id
+		WHERE ps.product_no = ANY($1)`
+	args := []interface{}{pq.Array(productNos)}
+	if locationID > 0 {
+		query += " AND ps.location_id = $2"
+		args = append(args, locationID)
+	}
+	query += " ORDER BY ps.location_id"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productNo int
+		var colorID int
+		var colorName, size string
+		var ls models.LocationStock
+		var tanggalUpdate time.Time
+		if err := rows.Scan(&productNo, &colorID, &colorName, &size, &ls.LocationID, &ls.LocationName, &ls.Quantity, &tanggalUpdate); err != nil {
+			return nil, err
+		}
+
+		if result[productNo] == nil {
+			result[productNo] = make(map[variantKey]models.ProductStock)
+		}
+		key := variantKey{ColorID: colorID, Size: size}
+		s, ok := result[productNo][key]
+		if !ok {
+			s = models.ProductStock{ColorID: colorID, ColorName: colorName, Size: size}
+		}
+		ls.Reserved = reserved[reservationKey{ProductNo: productNo, ColorID: colorID, Size: size, LocationID: ls.LocationID}]
+		ls.Available = ls.Quantity - ls.Reserved
+		s.Quantity += ls.Quantity
+		s.Reserved += ls.Reserved
+		s.Available += ls.Available
+		s.Locations = append(s.Locations, ls)
+		if s.TanggalUpdate == nil || tanggalUpdate.After(*s.TanggalUpdate) {
+			updated := tanggalUpdate
+			s.TanggalUpdate = &updated
+		}
+		result[productNo][key] = s
+	}
+
+	return result, rows.Err()
+}
+
+// buildVariantStocks expands the product's colors and sizes into a full variant list,
+// filling in stored quantities and keeping stored rows for variants no longer listed on the product
+func buildVariantStocks(p *models.Product, stored map[variantKey]models.ProductStock) []models.ProductStock {
+	colorNames := make(map[int]string)
+	for _, color := range p.Colors {
+		colorNames[color.ID] = color.Name
+	}
+
+	stocks := []models.ProductStock{}
+	used := make(map[variantKey]bool)
+
+	for _, colorID := range ParseColorIDs(p.Warna) {
+		for _, size := range ExpandSizes(p.Size) {
+			key := variantKey{ColorID: colorID, Size: size}
+			if used[key] {
+				continue
+			}
+			used[key] = true
+
+			if s, ok := stored[key]; ok {
+				stocks = append(stocks, s)
+				continue
+			}
+			stocks = append(stocks, models.ProductStock{
+				ColorID:   colorID,
+				ColorName: colorNames[colorID],
+				Size:      size,
+				Quantity:  0,
+			})
+		}
+	}
+
+	// Keep stock that still exists for variants removed from the product
+	for key, s := range stored {
+		if !used[key] && s.Quantity != 0 {
+			stocks = append(stocks, s)
+		}
+	}
+
+	return stocks
+}
+
+// attachProductStocks fills Stocks and TotalStock for the given products using a single query.
+// A locationID of 0 includes every location.
+func attachProductStocks(products []models.Product, locationID int) error {
+	productNos := make([]int, 0, len(products))
+	for _, p := range products {
+		if no, err := strconv.Atoi(p.No); err == nil {
+			productNos = append(productNos, no)
+		}
+	}
+
+	storedByProduct, err := fetchStockRows(productNos, locationID)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		no, _ := strconv.Atoi(products[i].No)
+		products[i].Stocks = buildVariantStocks(&products[i], storedByProduct[no])
+		products[i].TotalStock = 0
+		products[i].TotalAvailable = 0
+		for _, s := range products[i].Stocks {
+			products[i].TotalStock += s.Quantity
+			products[i].TotalAvailable += s.Available
+		}
+	}
+
+	return nil
+}
+
+// FetchProductStocks retrieves the per-variant stock of a product. A locationID of 0 includes every location.
+func FetchProductStocks(productNo int, locationID int) ([]models.ProductStock, int, error) {
+	product, err := FetchProductByID(productNo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if locationID == 0 {
+		return product.Stocks, product.TotalStock, nil
+	}
+
+	products := []models.Product{product}
+	if err := attachProductStocks(products, locationID); err != nil {
+		return nil, 0, err
+	}
+	return products[0].Stocks, products[0].TotalStock, nil
+}
+
+// SetProductStocks sets the on-hand quantity for the given variants of a product at one location in a single transaction.
+// Every change is posted to the ledger as an adjustment movement for the difference.
+func SetProductStocks(productNo int, locationID int, items []models.StockQuantityInput, userID, username string) ([]models.StockMovement, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	movements := []models.StockMovement{}
+	for _, item := range items {
+		size := strings.TrimSpace(item.Size)
+
+		current, err := lockVariantQuantity(tx, productNo, item.ColorID, size, locationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set stock for color %d size %s: %w", item.ColorID, size, err)
+		}
+
+		delta := item.Quantity - current
+		if delta == 0 {
+			continue
+		}
+
+		m := models.StockMovement{
+			ProductNo:  productNo,
+			ColorID:    item.ColorID,
+			Size:       size,
+			LocationID: locationID,
+			Quantity:   delta,
+			Reason:     models.StockReasonAdjustment,
+			Notes:      "Set on-hand quantity to " + strconv.Itoa(item.Quantity),
+			UserID:     userID,
+			Username:   username,
+		}
+		if err := ApplyStockMovement(tx, &m); err != nil {
+			return nil, fmt.Errorf("failed to set stock for color %d size %s: %w", item.ColorID, size, err)
+		}
+		movements = append(movements, m)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
@@ -0,0 +1,325 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// skuInvalidChars matches the runs of characters an artikel cannot keep in a SKU
+var skuInvalidChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// CreateProductVariantsTableIfNotExists creates the product_variants table and generates the variants
+// of products that do not have any yet
+func CreateProductVariantsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS product_variants (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL REFERENCES master_products(no),
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			sku VARCHAR(100) NOT NULL UNIQUE,
+			barcode VARCHAR(50) UNIQUE,
+			harga NUMERIC(15, 2),
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			tanggal_update TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			tanggal_hapus TIMESTAMPTZ,
+			CONSTRAINT uq_product_variants_variant UNIQUE (product_no, color_id, size)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_variants_product_no ON product_variants(product_no);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	rows, err := DB.Query(`
+		SELECT no, artikel, COALESCE(warna, ''), COALESCE(size, '') FROM master_products mp
+		WHERE NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_no = mp.no)`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		No                   int
+		Artikel, Warna, Size string
+	}
+	products := []pending{}
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.No, &p.Artikel, &p.Warna, &p.Size); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		if _, err := SyncProductVariants(p.No, p.Artikel, p.Warna, p.Size); err != nil {
+			return fmt.Errorf("failed to generate variants for product %d: %w", p.No, err)
+		}
+	}
+
+	log.Printf("Ensured product_variants table exists, generated variants for %d products", len(products))
+	return nil
+}
+
+// variantSKU builds the SKU of a variant from the artikel of its product, the color ID and the size
+func variantSKU(artikel string, productNo, colorID int, size string, withProductNo bool) string {
+	base := strings.Trim(skuInvalidChars.ReplaceAllString(strings.ToUpper(artikel), "-"), "-")
+	if base == "" || withProductNo {
+		base = strings.TrimPrefix(base+"-"+strconv.Itoa(productNo), "-")
+	}
+	size = strings.Trim(skuInvalidChars.ReplaceAllString(strings.ToUpper(size), "-"), "-")
+	return fmt.Sprintf("%s-%d-%s", base, colorID, size)
+}
+
+// variantBarcode builds the EAN-13 barcode of a variant in the 20 prefix reserved for in-store numbering
+func variantBarcode(id int) string {
+	code := fmt.Sprintf("20%010d", id)
+	sum := 0
+	for i, digit := range code {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digit-'0') * weight
+	}
+	return code + strconv.Itoa((10-sum%10)%10)
+}
+
+// SyncProductVariants brings the variants of a product in line with its warna and size: missing color ×
+// size combinations are created with a generated SKU and barcode, variants no longer listed are
+// soft-deleted and listed ones that were deleted are restored. Returns the listed variants.
+func SyncProductVariants(productNo int, artikel, warna, size string) ([]models.ProductVariant, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	combinations := []variantKey{}
+	listed := make(map[variantKey]bool)
+	for _, colorID := range ParseColorIDs(warna) {
+		for _, s := range ExpandSizes(size) {
+			key := variantKey{ColorID: colorID, Size: s}
+			if !listed[key] {
+				listed[key] = true
+				combinations = append(combinations, key)
+			}
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, color_id, size, tanggal_hapus IS NOT NULL FROM product_variants
+		WHERE product_no = $1
+		FOR UPDATE`, productNo)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[variantKey]bool)
+	var deleteIDs, restoreIDs []int
+	for rows.Next() {
+		var id int
+		var key variantKey
+		var deleted bool
+		if err := rows.Scan(&id, &key.ColorID, &key.Size, &deleted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[key] = true
+		switch {
+		case listed[key] && deleted:
+			restoreIDs = append(restoreIDs, id)
+		case !listed[key] && !deleted:
+			deleteIDs = append(deleteIDs, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if len(deleteIDs) > 0 {
+		if _, err := tx.Exec(`UPDATE product_variants SET tanggal_hapus = $1, tanggal_update = $1 WHERE id = ANY($2)`, now, pq.Array(deleteIDs)); err != nil {
+			return nil, err
+		}
+	}
+	if len(restoreIDs) > 0 {
+		if _, err := tx.Exec(`UPDATE product_variants SET tanggal_hapus = NULL, tanggal_update = $1 WHERE id = ANY($2)`, now, pq.Array(restoreIDs)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range combinations {
+		if existing[key] {
+			continue
+		}
+
+		// An artikel shared with another product gets the product number in its SKUs
+		sku := variantSKU(artikel, productNo, key.ColorID, key.Size, false)
+		var taken bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM product_variants WHERE sku = $1)`, sku).Scan(&taken); err != nil {
+			return nil, err
+		}
+		if taken {
+			sku = variantSKU(artikel, productNo, key.ColorID, key.Size, true)
+		}
+
+		var id int
+		if err := tx.QueryRow(`
+			INSERT INTO product_variants (product_no, color_id, size, sku, tanggal_update)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`, productNo, key.ColorID, key.Size, sku, now).Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to create variant color %d size %s: %w", key.ColorID, key.Size, err)
+		}
+		if _, err := tx.Exec(`UPDATE product_variants SET barcode = $1 WHERE id = $2`, variantBarcode(id), id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return FetchProductVariants(productNo)
+}
+
+// productVariantColumns lists the columns scanned by scanProductVariant
+const productVariantColumns = `pv.id, pv.product_no, pv.color_id, COALESCE(mc.nama, ''), pv.size, pv.sku, COALESCE(pv.barcode, ''),
+	pv.harga, pv.is_active, pv.tanggal_update, pv.tanggal_hapus`
+
+// scanProductVariant scans a row selected with productVariantColumns
+func scanProductVariant(row interface{ Scan(...interface{}) error }) (models.ProductVariant, error) {
+	var v models.ProductVariant
+	var harga sql.NullFloat64
+	err := row.Scan(&v.ID, &v.ProductNo, &v.ColorID, &v.ColorName, &v.Size, &v.SKU, &v.Barcode,
+		&harga, &v.IsActive, &v.TanggalUpdate, &v.TanggalHapus)
+	if harga.Valid {
+		v.Harga = &harga.Float64
+	}
+	return v, err
+}
+
+// FetchProductVariants retrieves the listed variants of a product ordered by color and size
+func FetchProductVariants(productNo int) ([]models.ProductVariant, error) {
+	variants, err := fetchVariantsForProducts([]int{productNo})
+	if err != nil {
+		return nil, err
+	}
+	if variants[productNo] == nil {
+		return []models.ProductVariant{}, nil
+	}
+	return variants[productNo], nil
+}
+
+// fetchVariantsForProducts retrieves the listed variants of the given products keyed by product number
+func fetchVariantsForProducts(productNos []int) (map[int][]models.ProductVariant, error) {
+	result := make(map[int][]models.ProductVariant)
+	if len(productNos) == 0 {
+		return result, nil
+	}
+
+	rows, err := DB.Query(`
+		SELECT `+productVariantColumns+`
+		FROM product_variants pv
+		LEFT JOIN master_colors mc ON mc.id = pv.color_id
+		WHERE pv.product_no = ANY($1) AND pv.tanggal_hapus IS NULL
+		ORDER BY pv.product_no, pv.color_id, pv.id`, pq.Array(productNos))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanProductVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[v.ProductNo] = append(result[v.ProductNo], v)
+	}
+	return result, rows.Err()
+}
+
+// attachProductVariants fills Variants for the given products using a single query
+func attachProductVariants(products []models.Product) error {
+	productNos := make([]int, 0, len(products))
+	for _, p := range products {
+		if no, err := strconv.Atoi(p.No); err == nil {
+			productNos = append(productNos, no)
+		}
+	}
+
+	variants, err := fetchVariantsForProducts(productNos)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		no, _ := strconv.Atoi(products[i].No)
+		products[i].Variants = variants[no]
+		if products[i].Variants == nil {
+			products[i].Variants = []models.ProductVariant{}
+		}
+	}
+	return nil
+}
+
+// FetchProductVariantByID retrieves a listed variant of a product
+func FetchProductVariantByID(productNo, id int) (models.ProductVariant, error) {
+	v, err := scanProductVariant(DB.QueryRow(`
+		SELECT `+productVariantColumns+`
+		FROM product_variants pv
+		LEFT JOIN master_colors mc ON mc.id = pv.color_id
+		WHERE pv.product_no = $1 AND pv.id = $2 AND pv.tanggal_hapus IS NULL`, productNo, id))
+	if err == sql.ErrNoRows {
+		return v, errors.New("not_found")
+	}
+	return v, err
+}
+
+// ProductVariantCodeExists checks whether another variant already uses a SKU or barcode
+func ProductVariantCodeExists(column, value string, excludeID int) (bool, error) {
+	if column != "sku" && column != "barcode" {
+		return false, fmt.Errorf("invalid variant code column %s", column)
+	}
+
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM product_variants WHERE `+column+` = $1 AND id <> $2)`, value, excludeID).Scan(&exists)
+	return exists, err
+}
+
+// UpdateProductVariant saves the SKU, barcode, price override and active flag of a variant
+func UpdateProductVariant(v *models.ProductVariant) (models.ProductVariant, error) {
+	result, err := DB.Exec(`
+		UPDATE product_variants SET sku = $1, barcode = NULLIF($2, ''), harga = $3, is_active = $4, tanggal_update = $5
+		WHERE id = $6 AND product_no = $7 AND tanggal_hapus IS NULL`,
+		v.SKU, v.Barcode, v.Harga, v.IsActive, time.Now(), v.ID, v.ProductNo)
+	if err != nil {
+		return *v, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return *v, err
+	}
+	if rowsAffected == 0 {
+		return *v, errors.New("not_found")
+	}
+
+	return FetchProductVariantByID(v.ProductNo, v.ID)
+}
@@ -0,0 +1,283 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// CreatePromotionsTableIfNotExists ensures the promotions table exists
+func CreatePromotionsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS promotions (
+			id SERIAL PRIMARY KEY,
+			nama TEXT NOT NULL,
+			deskripsi TEXT,
+			type TEXT NOT NULL,
+			value NUMERIC(15,2) NOT NULL DEFAULT 0,
+			buy_quantity INTEGER NOT NULL DEFAULT 0,
+			get_quantity INTEGER NOT NULL DEFAULT 0,
+			targets JSONB NOT NULL DEFAULT '{}',
+			product_nos INTEGER[] NOT NULL DEFAULT '{}',
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ,
+			priority INTEGER NOT NULL DEFAULT 0,
+			stackable BOOLEAN NOT NULL DEFAULT FALSE,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by TEXT,
+			diupdate_oleh TEXT,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			tanggal_hapus TIMESTAMPTZ
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_promotions_window ON promotions(starts_at, ends_at) WHERE is_active AND tanggal_hapus IS NULL;`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured promotions table exists")
+	return nil
+}
+
+// promotionSelect selects the columns scanned by scanPromotion; the status is derived from is_active and the date window
+const promotionSelect = `
+	SELECT id, nama, COALESCE(deskripsi, ''), type, value, buy_quantity, get_quantity, targets, product_nos,
+		starts_at, ends_at, priority, stackable, is_active,
+		CASE
+			WHEN NOT is_active THEN 'inactive'
+			WHEN starts_at > NOW() THEN 'scheduled'
+			WHEN ends_at IS NOT NULL AND ends_at <= NOW() THEN 'ended'
+			ELSE 'running'
+		END,
+		COALESCE(created_by, ''), COALESCE(diupdate_oleh, ''), tanggal_update, tanggal_hapus
+	FROM promotions`
+
+// scanPromotion scans a row selected with promotionSelect
+func scanPromotion(scanner interface{ Scan(...interface{}) error }, p *models.Promotion) error {
+	var targetsJSON []byte
+	var productNos pq.Int64Array
+	if err := scanner.Scan(&p.ID, &p.Nama, &p.Deskripsi, &p.Type, &p.Value, &p.BuyQuantity, &p.GetQuantity, &targetsJSON, &productNos,
+		&p.StartsAt, &p.EndsAt, &p.Priority, &p.Stackable, &p.IsActive, &p.Status,
+		&p.CreatedBy, &p.DiupdateOleh, &p.TanggalUpdate, &p.TanggalHapus); err != nil {
+		return err
+	}
+
+	p.Targets = map[string][]string{}
+	if err := json.Unmarshal(targetsJSON, &p.Targets); err != nil {
+		return err
+	}
+	p.ProductNos = []int{}
+	for _, no := range productNos {
+		p.ProductNos = append(p.ProductNos, int(no))
+	}
+	return nil
+}
+
+// buildPromotionConditions builds the WHERE clause for listing promotions. The search query matches the
+// nama and deskripsi; status is one of running, scheduled, ended or inactive.
+func buildPromotionConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE tanggal_hapus IS NULL"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		conditions += ` AND (nama ILIKE $` + fmt.Sprintf("%d", paramCount) +
+			` OR deskripsi ILIKE $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	if value, ok := filters["type"]; ok && value != "" {
+		conditions += ` AND type = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+
+	switch filters["status"] {
+	case models.PromotionStatusRunning:
+		conditions += ` AND is_active AND starts_at <= NOW() AND (ends_at IS NULL OR ends_at > NOW())`
+	case models.PromotionStatusScheduled:
+		conditions += ` AND is_active AND starts_at > NOW()`
+	case models.PromotionStatusEnded:
+		conditions += ` AND is_active AND ends_at IS NOT NULL AND ends_at <= NOW()`
+	case models.PromotionStatusInactive:
+		conditions += ` AND NOT is_active`
+	}
+
+	return conditions, args, paramCount
+}
+
+// CountPromotions counts the promotions matching the search query and filters
+func CountPromotions(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildPromotionConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow("SELECT COUNT(id) FROM promotions"+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchPromotions retrieves the promotions matching the search query and filters with pagination
+func FetchPromotions(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.Promotion, error) {
+	conditions, args, paramCount := buildPromotionConditions(queryStr, filters)
+
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nama": true, "type": true, "starts_at": true, "ends_at": true, "priority": true, "tanggal_update": true,
+	}
+	if !validColumns[sortColumn] {
+		sortColumn = "starts_at"
+	}
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	query := promotionSelect + conditions + " ORDER BY " + sortColumn + " " + sortDirection + ", id" +
+		` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		var p models.Promotion
+		if err := scanPromotion(rows, &p); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+// FetchRunningPromotions retrieves the active promotions whose date window includes now, highest priority first
+func FetchRunningPromotions() ([]models.Promotion, error) {
+	rows, err := DB.Query(promotionSelect + `
+		WHERE tanggal_hapus IS NULL AND is_active AND starts_at <= NOW() AND (ends_at IS NULL OR ends_at > NOW())
+		ORDER BY priority DESC, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		var p models.Promotion
+		if err := scanPromotion(rows, &p); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+// FetchPromotionByID retrieves a promotion by its ID
+func FetchPromotionByID(id int) (models.Promotion, error) {
+	var p models.Promotion
+	err := scanPromotion(DB.QueryRow(promotionSelect+` WHERE id = $1 AND tanggal_hapus IS NULL`, id), &p)
+	if err == sql.ErrNoRows {
+		return p, errors.New("not_found")
+	}
+	return p, err
+}
+
+// promotionValues returns the targets, product numbers and active flag of a promotion request as stored
+func promotionValues(req *models.PromotionRequest) ([]byte, pq.Int64Array, bool, error) {
+	targets := req.Targets
+	if targets == nil {
+		targets = map[string][]string{}
+	}
+	targetsJSON, err := json.Marshal(targets)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	productNos := pq.Int64Array{}
+	for _, no := range req.ProductNos {
+		productNos = append(productNos, int64(no))
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return targetsJSON, productNos, isActive, nil
+}
+
+// InsertPromotion creates a promotion
+func InsertPromotion(req *models.PromotionRequest, username string) (models.Promotion, error) {
+	targetsJSON, productNos, isActive, err := promotionValues(req)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	var id int
+	if err := DB.QueryRow(`
+		INSERT INTO promotions (nama, deskripsi, type, value, buy_quantity, get_quantity, targets, product_nos, starts_at, ends_at,
+			priority, stackable, is_active, created_by, diupdate_oleh, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14, $15)
+		RETURNING id`,
+		req.Nama, req.Deskripsi, req.Type, req.Value, req.BuyQuantity, req.GetQuantity, targetsJSON, productNos, req.Start, req.End,
+		req.Priority, req.Stackable, isActive, username, time.Now(),
+	).Scan(&id); err != nil {
+		return models.Promotion{}, err
+	}
+	return FetchPromotionByID(id)
+}
+
+// UpdatePromotion replaces every field of a promotion
+func UpdatePromotion(id int, req *models.PromotionRequest, username string) (models.Promotion, error) {
+	targetsJSON, productNos, isActive, err := promotionValues(req)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	result, err := DB.Exec(`
+		UPDATE promotions SET nama = $1, deskripsi = $2, type = $3, value = $4, buy_quantity = $5, get_quantity = $6,
+			targets = $7, product_nos = $8, starts_at = $9, ends_at = $10, priority = $11, stackable = $12, is_active = $13,
+			diupdate_oleh = $14, tanggal_update = $15
+		WHERE id = $16 AND tanggal_hapus IS NULL`,
+		req.Nama, req.Deskripsi, req.Type, req.Value, req.BuyQuantity, req.GetQuantity, targetsJSON, productNos, req.Start, req.End,
+		req.Priority, req.Stackable, isActive, username, time.Now(), id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	if rowsAffected == 0 {
+		return models.Promotion{}, errors.New("not_found")
+	}
+	return FetchPromotionByID(id)
+}
+
+// DeletePromotion soft-deletes a promotion by setting tanggal_hapus
+func DeletePromotion(id int) error {
+	result, err := DB.Exec(`UPDATE promotions SET tanggal_hapus = $1 WHERE id = $2 AND tanggal_hapus IS NULL`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("not_found")
+	}
+	return nil
+}
@@ -0,0 +1,596 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// ErrPurchaseOrderLineUnavailable is returned when a receipt line references a purchase order line
+// that is no longer open or has less outstanding than the quantity received
+var ErrPurchaseOrderLineUnavailable = errors.New("purchase_order_line_unavailable")
+
+// CreatePurchaseOrdersTableIfNotExists ensures the purchase_orders and purchase_order_items tables exist
+// and links goods receipt lines to the purchase order lines they deliver
+func CreatePurchaseOrdersTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS purchase_orders (
+			id SERIAL PRIMARY KEY,
+			nomor TEXT,
+			supplier_id INTEGER NOT NULL REFERENCES master_suppliers(id),
+			supplier TEXT NOT NULL,
+			location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			tanggal_order DATE NOT NULL,
+			tanggal_kedatangan DATE NOT NULL,
+			status TEXT NOT NULL DEFAULT 'draft',
+			notes TEXT,
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			sent_by TEXT,
+			sent_at TIMESTAMPTZ,
+			closed_by TEXT,
+			closed_at TIMESTAMPTZ,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_purchase_orders_nomor ON purchase_orders(nomor);`,
+		`CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status);`,
+		`CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);`,
+		`CREATE TABLE IF NOT EXISTS purchase_order_items (
+			id SERIAL PRIMARY KEY,
+			purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			unit_cost NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (unit_cost >= 0)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_purchase_order_items_purchase_order_id ON purchase_order_items(purchase_order_id);`,
+		`ALTER TABLE goods_receipt_items ADD COLUMN IF NOT EXISTS purchase_order_item_id INTEGER REFERENCES purchase_order_items(id);`,
+		`CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_purchase_order_item_id ON goods_receipt_items(purchase_order_item_id);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured purchase_orders table exists")
+	return nil
+}
+
+// purchaseOrderReceivedJoin joins the quantity received so far on each purchase order line.
+// Only posted receipts count, drafts have not delivered anything yet.
+const purchaseOrderReceivedJoin = `
+	LEFT JOIN (
+		SELECT gri.purchase_order_item_id, SUM(gri.quantity) AS received
+		FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.receipt_id
+		WHERE gr.status = 'posted' AND gri.purchase_order_item_id IS NOT NULL
+		GROUP BY gri.purchase_order_item_id
+	) rcv ON rcv.purchase_order_item_id = poi.id`
+
+// buildPurchaseOrderConditions builds the WHERE clause shared by the count and fetch queries
+func buildPurchaseOrderConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			po.nomor ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR po.supplier ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR po.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR ml.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":      "po.status",
+		"supplier_id": "po.supplier_id",
+		"location_id": "po.location_id",
+	}
+	for _, field := range []string{"status", "supplier_id", "location_id"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// Add date range filters on the order date
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND po.tanggal_order >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND po.tanggal_order <= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+
+	// Overdue orders are still waiting for goods after their expected arrival date
+	if value, ok := filters["overdue_as_of"]; ok && value != "" {
+		conditions += ` AND po.status IN ('` + models.PurchaseOrderStatusSent + `', '` + models.PurchaseOrderStatusPartiallyReceived + `')` +
+			` AND po.tanggal_kedatangan < $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const purchaseOrderSelect = `
+	SELECT
+		po.id, COALESCE(po.nomor, ''), po.supplier_id, po.supplier, po.location_id, COALESCE(ml.nama, ''),
+		po.tanggal_order, po.tanggal_kedatangan, po.status, COALESCE(po.notes, ''), COALESCE(po.created_by, ''), po.created_at,
+		COALESCE(po.sent_by, ''), po.sent_at, COALESCE(po.closed_by, ''), po.closed_at, po.tanggal_update
+	FROM purchase_orders po
+	LEFT JOIN master_locations ml ON ml.id = po.location_id`
+
+// scanPurchaseOrder scans a row selected with purchaseOrderSelect
+func scanPurchaseOrder(scanner interface{ Scan(...interface{}) error }, po *models.PurchaseOrder) error {
+	return scanner.Scan(
+		&po.ID, &po.Nomor, &po.SupplierID, &po.Supplier, &po.LocationID, &po.LocationName,
+		&po.TanggalOrder, &po.TanggalKedatangan, &po.Status, &po.Notes, &po.CreatedBy, &po.CreatedAt,
+		&po.SentBy, &po.SentAt, &po.ClosedBy, &po.ClosedAt, &po.TanggalUpdate,
+	)
+}
+
+// CountPurchaseOrders counts all purchase orders matching the search query and filters
+func CountPurchaseOrders(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildPurchaseOrderConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(po.id)
+		FROM purchase_orders po
+		LEFT JOIN master_locations ml ON ml.id = po.location_id`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchPurchaseOrders retrieves purchase orders matching the search query and filters with pagination
+func FetchPurchaseOrders(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.PurchaseOrder, error) {
+	orders := []models.PurchaseOrder{}
+
+	conditions, args, paramCount := buildPurchaseOrderConditions(queryStr, filters)
+	baseQuery := purchaseOrderSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nomor": true, "supplier": true, "tanggal_order": true, "tanggal_kedatangan": true, "status": true, "created_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "po." + sortColumn + " " + sortDirection + ", po.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var po models.PurchaseOrder
+		if err := scanPurchaseOrder(rows, &po); err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachPurchaseOrderItems(orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// attachPurchaseOrderItems loads the item lines of the given purchase orders, with their received
+// and outstanding quantities, using a single query
+func attachPurchaseOrderItems(orders []models.PurchaseOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(orders))
+	index := make(map[int]int, len(orders))
+	for i, po := range orders {
+		ids[i] = po.ID
+		index[po.ID] = i
+		orders[i].Items = []models.PurchaseOrderItem{}
+		orders[i].TotalQuantity = 0
+		orders[i].TotalReceived = 0
+		orders[i].TotalOutstanding = 0
+		orders[i].TotalAmount = 0
+	}
+
+	rows, err := DB.Query(`
+		SELECT poi.purchase_order_id, poi.id, poi.product_no, COALESCE(mp.artikel, ''), poi.color_id, COALESCE(mc.nama, ''),
+			poi.size, poi.quantity, poi.unit_cost, COALESCE(rcv.received, 0)
+		FROM purchase_order_items poi`+purchaseOrderReceivedJoin+`
+		LEFT JOIN master_products mp ON mp.no = poi.product_no
+		LEFT JOIN master_colors mc ON mc.id = poi.color_id
+		WHERE poi.purchase_order_id = ANY($1)
+		ORDER BY poi.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&orderID, &item.ID, &item.ProductNo, &item.Artikel, &item.ColorID, &item.ColorName,
+			&item.Size, &item.Quantity, &item.UnitCost, &item.ReceivedQuantity); err != nil {
+			return err
+		}
+		// Deliveries above the ordered quantity do not make the outstanding quantity negative
+		item.OutstandingQuantity = item.Quantity - item.ReceivedQuantity
+		if item.OutstandingQuantity < 0 {
+			item.OutstandingQuantity = 0
+		}
+
+		i := index[orderID]
+		orders[i].Items = append(orders[i].Items, item)
+		orders[i].TotalQuantity += item.Quantity
+		orders[i].TotalReceived += item.ReceivedQuantity
+		orders[i].TotalOutstanding += item.OutstandingQuantity
+		orders[i].TotalAmount += float64(item.Quantity) * item.UnitCost
+	}
+
+	return rows.Err()
+}
+
+// FetchPurchaseOrderByID retrieves a purchase order with its items
+func FetchPurchaseOrderByID(id int) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := scanPurchaseOrder(DB.QueryRow(purchaseOrderSelect+` WHERE po.id = $1`, id), &po)
+	if err == sql.ErrNoRows {
+		return po, errors.New("not_found")
+	}
+	if err != nil {
+		return po, err
+	}
+
+	orders := []models.PurchaseOrder{po}
+	if err := attachPurchaseOrderItems(orders); err != nil {
+		return po, err
+	}
+	return orders[0], nil
+}
+
+// FetchPurchaseOrderLine retrieves a single purchase order line together with the header of its order
+func FetchPurchaseOrderLine(itemID int) (models.PurchaseOrder, models.PurchaseOrderItem, error) {
+	var orderID int
+	err := DB.QueryRow(`SELECT purchase_order_id FROM purchase_order_items WHERE id = $1`, itemID).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return models.PurchaseOrder{}, models.PurchaseOrderItem{}, errors.New("not_found")
+	}
+	if err != nil {
+		return models.PurchaseOrder{}, models.PurchaseOrderItem{}, err
+	}
+
+	po, err := FetchPurchaseOrderByID(orderID)
+	if err != nil {
+		return po, models.PurchaseOrderItem{}, err
+	}
+	for _, item := range po.Items {
+		if item.ID == itemID {
+			return po, item, nil
+		}
+	}
+	return po, models.PurchaseOrderItem{}, errors.New("not_found")
+}
+
+// replacePurchaseOrderItems replaces all item lines of a purchase order
+func replacePurchaseOrderItems(tx *sql.Tx, orderID int, items []models.PurchaseOrderItemInput) error {
+	if _, err := tx.Exec(`DELETE FROM purchase_order_items WHERE purchase_order_id = $1`, orderID); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO purchase_order_items (purchase_order_id, product_no, color_id, size, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			orderID, item.ProductNo, item.ColorID, strings.TrimSpace(item.Size), item.Quantity, item.UnitCost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertPurchaseOrder creates a draft purchase order with its items and assigns its document number
+func InsertPurchaseOrder(req *models.SavePurchaseOrderRequest, username string) (models.PurchaseOrder, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(`
+		INSERT INTO purchase_orders (supplier_id, supplier, location_id, tanggal_order, tanggal_kedatangan, status, notes, created_by, created_at, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id`,
+		req.SupplierID, req.SupplierName, req.LocationID, req.OrderDate, req.ExpectedDate,
+		models.PurchaseOrderStatusDraft, strings.TrimSpace(req.Notes), username, now,
+	).Scan(&id); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	nomor := fmt.Sprintf("PO-%s-%06d", now.Format("20060102"), id)
+	if _, err := tx.Exec(`UPDATE purchase_orders SET nomor = $1 WHERE id = $2`, nomor, id); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if err := replacePurchaseOrderItems(tx, id, req.Items); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return FetchPurchaseOrderByID(id)
+}
+
+// lockPurchaseOrder locks a purchase order row for the rest of the transaction and returns its current status
+func lockPurchaseOrder(tx *sql.Tx, id int) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := tx.QueryRow(`
+		SELECT id, COALESCE(nomor, ''), supplier_id, supplier, location_id, status
+		FROM purchase_orders WHERE id = $1
+		FOR UPDATE`, id).Scan(&po.ID, &po.Nomor, &po.SupplierID, &po.Supplier, &po.LocationID, &po.Status)
+	if err == sql.ErrNoRows {
+		return po, errors.New("not_found")
+	}
+	return po, err
+}
+
+// UpdatePurchaseOrder replaces the header and items of a draft purchase order
+func UpdatePurchaseOrder(id int, req *models.SavePurchaseOrderRequest) (models.PurchaseOrder, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	po, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return po, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return po, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE purchase_orders
+		SET supplier_id = $1, supplier = $2, location_id = $3, tanggal_order = $4, tanggal_kedatangan = $5, notes = $6, tanggal_update = $7
+		WHERE id = $8`,
+		req.SupplierID, req.SupplierName, req.LocationID, req.OrderDate, req.ExpectedDate, strings.TrimSpace(req.Notes), time.Now(), id); err != nil {
+		return po, err
+	}
+
+	if err := replacePurchaseOrderItems(tx, id, req.Items); err != nil {
+		return po, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return po, err
+	}
+	return FetchPurchaseOrderByID(id)
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to the supplier, after which goods can be received against it
+func SendPurchaseOrder(id int, username string) (models.PurchaseOrder, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	po, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return po, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return po, errors.New("invalid_status")
+	}
+
+	var itemCount int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM purchase_order_items WHERE purchase_order_id = $1`, id).Scan(&itemCount); err != nil {
+		return po, err
+	}
+	if itemCount == 0 {
+		return po, errors.New("no_items")
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE purchase_orders SET status = $1, sent_by = $2, sent_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.PurchaseOrderStatusSent, username, now, id); err != nil {
+		return po, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return po, err
+	}
+	return FetchPurchaseOrderByID(id)
+}
+
+// ClosePurchaseOrder closes an open purchase order by hand, e.g. when the supplier will not deliver the rest
+func ClosePurchaseOrder(id int, username string) (models.PurchaseOrder, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	po, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return po, err
+	}
+	if po.Status != models.PurchaseOrderStatusSent && po.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return po, errors.New("invalid_status")
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE purchase_orders SET status = $1, closed_by = $2, closed_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.PurchaseOrderStatusClosed, username, now, id); err != nil {
+		return po, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return po, err
+	}
+	return FetchPurchaseOrderByID(id)
+}
+
+// CancelPurchaseOrder cancels a purchase order that has not received anything yet
+func CancelPurchaseOrder(id int) (models.PurchaseOrder, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	po, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return po, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft && po.Status != models.PurchaseOrderStatusSent {
+		return po, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`UPDATE purchase_orders SET status = $1, tanggal_update = $2 WHERE id = $3`,
+		models.PurchaseOrderStatusCancelled, time.Now(), id); err != nil {
+		return po, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return po, err
+	}
+	return FetchPurchaseOrderByID(id)
+}
+
+// lockPurchaseOrderLines locks the purchase orders of the given lines and checks that each line is still open
+// and has at least the given quantity outstanding. It returns the IDs of the locked purchase orders.
+func lockPurchaseOrderLines(tx *sql.Tx, quantities map[int]int) ([]int, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	itemIDs := make([]int, 0, len(quantities))
+	for itemID := range quantities {
+		itemIDs = append(itemIDs, itemID)
+	}
+
+	// Lock in ID order so concurrent receipts against the same orders cannot deadlock
+	rows, err := tx.Query(`
+		SELECT id FROM purchase_orders
+		WHERE id IN (SELECT purchase_order_id FROM purchase_order_items WHERE id = ANY($1))
+		ORDER BY id
+		FOR UPDATE`, pq.Array(itemIDs))
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orderIDs = append(orderIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`
+		SELECT poi.id, COALESCE(po.nomor, ''), po.status, poi.quantity - COALESCE(rcv.received, 0)
+		FROM purchase_order_items poi
+		JOIN purchase_orders po ON po.id = poi.purchase_order_id`+purchaseOrderReceivedJoin+`
+		WHERE poi.id = ANY($1)`, pq.Array(itemIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var itemID, outstanding int
+		var nomor, status string
+		if err := rows.Scan(&itemID, &nomor, &status, &outstanding); err != nil {
+			return nil, err
+		}
+		found++
+		if status != models.PurchaseOrderStatusSent && status != models.PurchaseOrderStatusPartiallyReceived {
+			return nil, fmt.Errorf("%w: %s is %s", ErrPurchaseOrderLineUnavailable, nomor, status)
+		}
+		if quantities[itemID] > outstanding {
+			return nil, fmt.Errorf("%w: line %d of %s has %d outstanding", ErrPurchaseOrderLineUnavailable, itemID, nomor, outstanding)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if found != len(itemIDs) {
+		return nil, fmt.Errorf("%w: line no longer exists", ErrPurchaseOrderLineUnavailable)
+	}
+
+	return orderIDs, nil
+}
+
+// refreshPurchaseOrderStatus moves an open purchase order to partially received or closed
+// according to what its posted receipts have delivered
+func refreshPurchaseOrderStatus(tx *sql.Tx, orderID int, username string, now time.Time) error {
+	var ordered, delivered int
+	if err := tx.QueryRow(`
+		SELECT COALESCE(SUM(poi.quantity), 0), COALESCE(SUM(LEAST(COALESCE(rcv.received, 0), poi.quantity)), 0)
+		FROM purchase_order_items poi`+purchaseOrderReceivedJoin+`
+		WHERE poi.purchase_order_id = $1`, orderID).Scan(&ordered, &delivered); err != nil {
+		return err
+	}
+
+	if delivered == 0 {
+		return nil
+	}
+	if delivered < ordered {
+		_, err := tx.Exec(`UPDATE purchase_orders SET status = $1, tanggal_update = $2 WHERE id = $3`,
+			models.PurchaseOrderStatusPartiallyReceived, now, orderID)
+		return err
+	}
+	_, err := tx.Exec(`UPDATE purchase_orders SET status = $1, closed_by = $2, closed_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.PurchaseOrderStatusClosed, username, now, orderID)
+	return err
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// ErrRefreshTokenInvalid is returned for refresh tokens that are unknown, expired or revoked
+var ErrRefreshTokenInvalid = errors.New("refresh_token_invalid")
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again.
+// The whole session is revoked, since either the client or an attacker holds a stolen token.
+var ErrRefreshTokenReused = errors.New("refresh_token_reused")
+
+// CreateSessionsTableIfNotExists creates the refresh token and revoked access token tables
+func CreateSessionsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			family_id TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			access_jti TEXT NOT NULL,
+			access_expires_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			user_agent TEXT,
+			ip_address TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMPTZ,
+			replaced_by TEXT
+		);`,
+		// Refreshing keeps the second factor of the login that started the session
+		`ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS two_factor BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);`,
+		// Access tokens cannot be recalled once issued, so their IDs are kept here until they expire
+		`CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti TEXT PRIMARY KEY,
+			user_id TEXT,
+			expires_at TIMESTAMPTZ NOT NULL,
+			revoked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured refresh_tokens and revoked_tokens tables exist")
+	return nil
+}
+
+// InsertRefreshToken stores a refresh token issued at login
+func InsertRefreshToken(rt models.RefreshToken) error {
+	return insertRefreshToken(DB, rt)
+}
+
+// insertRefreshToken stores a refresh token with the given executor
+func insertRefreshToken(exec interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, rt models.RefreshToken) error {
+	_, err := exec.Exec(`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, user_agent, ip_address, two_factor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rt.ID, rt.UserID, rt.FamilyID, rt.TokenHash, rt.AccessJTI, rt.AccessExpiresAt, rt.ExpiresAt, rt.UserAgent, rt.IPAddress, rt.TwoFactor)
+	return err
+}
+
+// FetchRefreshTokenByHash retrieves a refresh token by the hash of the token
+func FetchRefreshTokenByHash(tokenHash string) (models.RefreshToken, error) {
+	var rt models.RefreshToken
+	err := DB.QueryRow(`
+		SELECT id, user_id, family_id, token_hash, access_jti, access_expires_at, expires_at,
+			COALESCE(user_agent, ''), COALESCE(ip_address, ''), two_factor, created_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1`, tokenHash).Scan(
+		&rt.ID, &rt.UserID, &rt.FamilyID, &rt.TokenHash, &rt.AccessJTI, &rt.AccessExpiresAt, &rt.ExpiresAt,
+		&rt.UserAgent, &rt.IPAddress, &rt.TwoFactor, &rt.CreatedAt, &rt.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rt, ErrRefreshTokenInvalid
+	}
+	return rt, err
+}
+
+// RotateRefreshToken replaces the refresh token with tokenHash by next, in the same session.
+// A token that was already replaced revokes the whole session and returns ErrRefreshTokenReused.
+func RotateRefreshToken(tokenHash string, next models.RefreshToken) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id, familyID string
+	var expiresAt time.Time
+	var revokedAt *time.Time
+	var replacedBy sql.NullString
+	err = tx.QueryRow(`
+		SELECT id, family_id, expires_at, revoked_at, replaced_by
+		FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`, tokenHash).Scan(&id, &familyID, &expiresAt, &revokedAt, &replacedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if revokedAt != nil {
+		if !replacedBy.Valid {
+			return ErrRefreshTokenInvalid
+		}
+		if _, err := revokeRefreshTokens(tx, `family_id = $1`, familyID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+	if !expiresAt.After(now) {
+		return ErrRefreshTokenInvalid
+	}
+
+	next.FamilyID = familyID
+	if err := insertRefreshToken(tx, next); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3`, now, next.ID, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily ends the session the refresh token with tokenHash belongs to,
+// revoking its refresh tokens and the access tokens issued with them
+func RevokeRefreshTokenFamily(tokenHash string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := revokeRefreshTokens(tx, `family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)`, tokenHash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeUserSessions ends every session of a user and returns the number of sessions ended
+func RevokeUserSessions(userID string) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sessions, err := revokeRefreshTokens(tx, `user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return sessions, tx.Commit()
+}
+
+// revokeRefreshTokens revokes the refresh tokens matching condition ($1 is arg) and denies the
+// access tokens issued with them that have not expired yet. It returns the number of sessions
+// that were still active.
+func revokeRefreshTokens(tx *sql.Tx, condition string, arg interface{}) (int, error) {
+	now := time.Now()
+
+	if _, err := tx.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		SELECT access_jti, user_id, access_expires_at, $2 FROM refresh_tokens
+		WHERE `+condition+` AND access_expires_at > $2
+		ON CONFLICT (jti) DO NOTHING`, arg, now); err != nil {
+		return 0, err
+	}
+
+	var sessions int
+	if err := tx.QueryRow(`
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $2
+			WHERE `+condition+` AND revoked_at IS NULL AND expires_at > $2
+			RETURNING family_id
+		)
+		SELECT COUNT(DISTINCT family_id) FROM revoked`, arg, now).Scan(&sessions); err != nil {
+		return 0, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeAccessToken denies an access token until it expires
+func RevokeAccessToken(jti, userID string, expiresAt time.Time) error {
+	_, err := DB.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`, jti, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token with jti has been revoked
+func IsTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpiredTokens deletes refresh tokens and revoked access token IDs that have expired
+func PurgeExpiredTokens() (int64, error) {
+	now := time.Now()
+	result, err := DB.Exec(`DELETE FROM refresh_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	purged, _ := result.RowsAffected()
+
+	result, err = DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return purged, err
+	}
+	revoked, _ := result.RowsAffected()
+	return purged + revoked, nil
+}
@@ -0,0 +1,373 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// ErrInsufficientStock is returned when a movement would make the on-hand quantity negative
+var ErrInsufficientStock = errors.New("insufficient_stock")
+
+// CreateStockMovementsTableIfNotExists ensures the stock_movements table exists.
+// Rows are protected against UPDATE and DELETE by a trigger so the ledger stays append-only.
+func CreateStockMovementsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS stock_movements (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			location_id INTEGER,
+			quantity INTEGER NOT NULL CHECK (quantity <> 0),
+			balance_after INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			reference TEXT,
+			notes TEXT,
+			user_id TEXT,
+			username TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_variant ON stock_movements(product_no, color_id, size);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_location_id ON stock_movements(location_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements(created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_reason ON stock_movements(reason);`,
+		`CREATE OR REPLACE FUNCTION prevent_stock_movement_mutation() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'stock_movements is append-only';
+		END;
+		$$ LANGUAGE plpgsql;`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_trigger
+				WHERE tgname = 'trg_stock_movements_append_only'
+			) THEN
+				CREATE TRIGGER trg_stock_movements_append_only
+				BEFORE UPDATE OR DELETE ON stock_movements
+				FOR EACH ROW EXECUTE FUNCTION prevent_stock_movement_mutation();
+			END IF;
+		END$$;`,
+		// Movements recorded before locations existed belong to the default location.
+		// The append-only trigger is suspended only for this one-time backfill.
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'stock_movements' AND column_name = 'location_id'
+			) THEN
+				ALTER TABLE stock_movements ADD COLUMN location_id INTEGER;
+			END IF;
+			IF EXISTS (SELECT 1 FROM stock_movements WHERE location_id IS NULL) THEN
+				ALTER TABLE stock_movements DISABLE TRIGGER trg_stock_movements_append_only;
+				UPDATE stock_movements
+				SET location_id = (SELECT id FROM master_locations WHERE is_default ORDER BY id LIMIT 1)
+				WHERE location_id IS NULL;
+				ALTER TABLE stock_movements ENABLE TRIGGER trg_stock_movements_append_only;
+			END IF;
+		END$$;`,
+		// Record an opening balance for stock that was set before the ledger existed
+		`INSERT INTO stock_movements (product_no, color_id, size, location_id, quantity, balance_after, reason, notes, username)
+		SELECT ps.product_no, ps.color_id, ps.size, ps.location_id, ps.quantity, ps.quantity, 'opening', 'Opening balance', 'system'
+		FROM product_stocks ps
+		WHERE ps.quantity <> 0 AND NOT EXISTS (
+			SELECT 1 FROM stock_movements sm
+			WHERE sm.product_no = ps.product_no AND sm.color_id = ps.color_id AND sm.size = ps.size
+				AND sm.location_id = ps.location_id
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured stock_movements table exists")
+	return nil
+}
+
+// lockVariantQuantity creates the variant stock row at a location if needed, locks it for the rest of the
+// transaction and returns its current on-hand quantity
+func lockVariantQuantity(tx *sql.Tx, productNo, colorID int, size string, locationID int) (int, error) {
+	if _, err := tx.Exec(`
+		INSERT INTO product_stocks (product_no, color_id, size, location_id, quantity, tanggal_update)
+		VALUES ($1, $2, $3, $4, 0, $5)
+		ON CONFLICT (product_no, color_id, size, location_id) DO NOTHING`,
+		productNo, colorID, size, locationID, time.Now()); err != nil {
+		return 0, err
+	}
+
+	var current int
+	err := tx.QueryRow(`
+		SELECT quantity FROM product_stocks
+		WHERE product_no = $1 AND color_id = $2 AND size = $3 AND location_id = $4
+		FOR UPDATE`,
+		productNo, colorID, size, locationID).Scan(&current)
+	return current, err
+}
+
+// ApplyStockMovement applies a movement to the on-hand quantity of its variant and appends it to the ledger.
+// The variant row is locked for the duration of the transaction so concurrent movements serialize.
+// Movements without a location are applied to the default location.
+func ApplyStockMovement(tx *sql.Tx, m *models.StockMovement) error {
+	m.Size = strings.TrimSpace(m.Size)
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+
+	if m.LocationID == 0 {
+		if err := tx.QueryRow(`SELECT id FROM master_locations WHERE is_default AND tanggal_hapus IS NULL ORDER BY id LIMIT 1`).
+			Scan(&m.LocationID); err != nil {
+			return fmt.Errorf("failed to resolve default location: %w", err)
+		}
+	}
+
+	current, err := lockVariantQuantity(tx, m.ProductNo, m.ColorID, m.Size, m.LocationID)
+	if err != nil {
+		return err
+	}
+
+	m.BalanceAfter = current + m.Quantity
+	if m.BalanceAfter < 0 {
+		return fmt.Errorf("%w: color %d size %s has %d on hand at location %d", ErrInsufficientStock, m.ColorID, m.Size, current, m.LocationID)
+	}
+
+	// Sales and transfers cannot take stock that is held by active reservations
+	if m.Quantity < 0 && (m.Reason == models.StockReasonSale || m.Reason == models.StockReasonTransfer) {
+		reserved, err := reservedQuantityTx(tx, m.ProductNo, m.ColorID, m.Size, m.LocationID)
+		if err != nil {
+			return err
+		}
+		if m.BalanceAfter < reserved {
+			return fmt.Errorf("%w: color %d size %s has %d available at location %d", ErrInsufficientStock, m.ColorID, m.Size, current-reserved, m.LocationID)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE product_stocks SET quantity = $1, tanggal_update = $2
+		WHERE product_no = $3 AND color_id = $4 AND size = $5 AND location_id = $6`,
+		m.BalanceAfter, m.CreatedAt, m.ProductNo, m.ColorID, m.Size, m.LocationID); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(`
+		INSERT INTO stock_movements
+		(product_no, color_id, size, location_id, quantity, balance_after, reason, reference, notes, user_id, username, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`,
+		m.ProductNo, m.ColorID, m.Size, m.LocationID, m.Quantity, m.BalanceAfter, m.Reason, m.Reference, m.Notes, m.UserID, m.Username, m.CreatedAt,
+	).Scan(&m.ID); err != nil {
+		return err
+	}
+
+	// Taking stock out can use up the oldest batch still on hand, which moves the product's receipt date
+	return refreshProductReceiptDate(tx, m.ProductNo)
+}
+
+// RecordStockMovements applies all movements atomically
+func RecordStockMovements(movements []*models.StockMovement) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range movements {
+		if err := ApplyStockMovement(tx, m); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// buildStockMovementConditions builds the WHERE clause shared by the count and fetch queries
+func buildStockMovementConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			CAST(sm.id AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR CAST(sm.product_no AS TEXT) ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR mp.artikel ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR mc.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR ml.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sm.size ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sm.reason ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sm.reference ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sm.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sm.username ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"product_no":  "sm.product_no",
+		"color_id":    "sm.color_id",
+		"size":        "sm.size",
+		"location_id": "sm.location_id",
+		"reason":      "sm.reason",
+		"reference":   "sm.reference",
+		"user_id":     "sm.user_id",
+	}
+	for _, field := range []string{"product_no", "color_id", "size", "location_id", "reason", "reference", "user_id"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND sm.created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND sm.created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const stockMovementFromClause = `
+	FROM stock_movements sm
+	LEFT JOIN master_products mp ON mp.no = sm.product_no
+	LEFT JOIN master_colors mc ON mc.id = sm.color_id
+	LEFT JOIN master_locations ml ON ml.id = sm.location_id`
+
+// CountStockMovements counts all stock movements matching the search query and filters
+func CountStockMovements(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildStockMovementConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow("SELECT COUNT(sm.id)"+stockMovementFromClause+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchStockMovements retrieves stock movements matching the search query and filters with pagination
+func FetchStockMovements(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.StockMovement, error) {
+	movements := []models.StockMovement{}
+
+	conditions, args, paramCount := buildStockMovementConditions(queryStr, filters)
+
+	baseQuery := `
+	SELECT
+		sm.id, sm.product_no, COALESCE(mp.artikel, ''), sm.color_id, COALESCE(mc.nama, ''), sm.size,
+		COALESCE(sm.location_id, 0), COALESCE(ml.nama, ''), sm.quantity, sm.balance_after, sm.reason, COALESCE(sm.reference, ''), COALESCE(sm.notes, ''),
+		COALESCE(sm.user_id, ''), COALESCE(sm.username, ''), sm.created_at` + stockMovementFromClause + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "product_no": true, "color_id": true, "size": true, "location_id": true, "quantity": true,
+		"balance_after": true, "reason": true, "reference": true, "username": true, "created_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	// Tie-break on id so movements created in the same instant keep ledger order
+	orderBy += "sm." + sortColumn + " " + sortDirection + ", sm.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(
+			&m.ID, &m.ProductNo, &m.Artikel, &m.ColorID, &m.ColorName, &m.Size,
+			&m.LocationID, &m.LocationName, &m.Quantity, &m.BalanceAfter, &m.Reason, &m.Reference, &m.Notes,
+			&m.UserID, &m.Username, &m.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+// FetchStockMovementByID retrieves a single stock movement by its ID
+func FetchStockMovementByID(id int) (models.StockMovement, error) {
+	var m models.StockMovement
+	err := DB.QueryRow(`
+		SELECT
+			sm.id, sm.product_no, COALESCE(mp.artikel, ''), sm.color_id, COALESCE(mc.nama, ''), sm.size,
+			COALESCE(sm.location_id, 0), COALESCE(ml.nama, ''), sm.quantity, sm.balance_after, sm.reason, COALESCE(sm.reference, ''), COALESCE(sm.notes, ''),
+			COALESCE(sm.user_id, ''), COALESCE(sm.username, ''), sm.created_at`+stockMovementFromClause+`
+		WHERE sm.id = $1`, id).
+		Scan(&m.ID, &m.ProductNo, &m.Artikel, &m.ColorID, &m.ColorName, &m.Size,
+			&m.LocationID, &m.LocationName, &m.Quantity, &m.BalanceAfter, &m.Reason, &m.Reference, &m.Notes,
+			&m.UserID, &m.Username, &m.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return m, errors.New("not_found")
+	}
+	return m, err
+}
+
+// ReconcileProductStocks compares the on-hand quantity of every variant and location of a product with the sum of its ledger
+func ReconcileProductStocks(productNo int) ([]models.StockReconciliation, error) {
+	rows, err := DB.Query(`
+		SELECT
+			COALESCE(ps.color_id, l.color_id),
+			COALESCE(ps.size, l.size),
+			COALESCE(ps.location_id, l.location_id),
+			COALESCE(ps.quantity, 0),
+			COALESCE(l.balance, 0)
+		FROM (SELECT * FROM product_stocks WHERE product_no = $1) ps
+		FULL OUTER JOIN (
+			SELECT color_id, size, location_id, SUM(quantity) AS balance
+			FROM stock_movements
+			WHERE product_no = $1
+			GROUP BY color_id, size, location_id
+		) l ON l.color_id = ps.color_id AND l.size = ps.size AND l.location_id = ps.location_id
+		ORDER BY 1, 2, 3`, productNo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.StockReconciliation{}
+	for rows.Next() {
+		var r models.StockReconciliation
+		if err := rows.Scan(&r.ColorID, &r.Size, &r.LocationID, &r.OnHand, &r.LedgerBalance); err != nil {
+			return nil, err
+		}
+		r.Difference = r.OnHand - r.LedgerBalance
+		result = append(result, r)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,561 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// CreateStockOpnamesTableIfNotExists ensures the stock_opnames and stock_opname_items tables exist
+func CreateStockOpnamesTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS stock_opnames (
+			id SERIAL PRIMARY KEY,
+			nomor TEXT,
+			location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			filters JSONB NOT NULL DEFAULT '{}'::jsonb,
+			status TEXT NOT NULL DEFAULT 'open',
+			notes TEXT,
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			submitted_by TEXT,
+			submitted_at TIMESTAMPTZ,
+			approved_by TEXT,
+			approved_at TIMESTAMPTZ,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_opnames_nomor ON stock_opnames(nomor);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_opnames_status ON stock_opnames(status);`,
+		`CREATE TABLE IF NOT EXISTS stock_opname_items (
+			id SERIAL PRIMARY KEY,
+			opname_id INTEGER NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			book_quantity INTEGER NOT NULL,
+			counted_quantity INTEGER CHECK (counted_quantity >= 0),
+			counted_by TEXT,
+			counted_at TIMESTAMPTZ,
+			CONSTRAINT uq_stock_opname_items_variant UNIQUE (opname_id, product_no, color_id, size)
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured stock_opnames table exists")
+	return nil
+}
+
+// fetchOpnameScope returns the variants and book quantities at a location for all active products matching the filters
+func fetchOpnameScope(locationID int, filters map[string]string) ([]models.StockOpnameItem, error) {
+	query := `SELECT no, COALESCE(warna, ''), COALESCE(size, '') FROM master_products WHERE tanggal_hapus IS NULL`
+	args := []interface{}{}
+	paramCount := 1
+
+	// Filters use the same exact-match semantics as the product list
+	for _, field := range []string{"warna", "size", "grup", "unit", "kat", "model", "gender", "tipe", "status", "supplier"} {
+		if value, ok := filters[field]; ok && value != "" {
+			query += ` AND ` + field + ` = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+	query += " ORDER BY no"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	products := []models.Product{}
+	productNos := []int{}
+	for rows.Next() {
+		var no int
+		var p models.Product
+		if err := rows.Scan(&no, &p.Warna, &p.Size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.No = strconv.Itoa(no)
+		products = append(products, p)
+		productNos = append(productNos, no)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stored, err := fetchStockRows(productNos, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []models.StockOpnameItem{}
+	for i := range products {
+		no := productNos[i]
+		for _, s := range buildVariantStocks(&products[i], stored[no]) {
+			items = append(items, models.StockOpnameItem{
+				ProductNo:    no,
+				ColorID:      s.ColorID,
+				Size:         s.Size,
+				BookQuantity: s.Quantity,
+			})
+		}
+	}
+	return items, nil
+}
+
+// InsertStockOpname opens a count session and snapshots the book quantity of every variant in scope
+func InsertStockOpname(req *models.CreateStockOpnameRequest, username string) (models.StockOpname, error) {
+	items, err := fetchOpnameScope(req.LocationID, req.Filters)
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	if len(items) == 0 {
+		return models.StockOpname{}, errors.New("empty_scope")
+	}
+
+	filtersJSON, err := json.Marshal(req.Filters)
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(`
+		INSERT INTO stock_opnames (location_id, filters, status, notes, created_by, created_at, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id`,
+		req.LocationID, filtersJSON, models.StockOpnameStatusOpen, strings.TrimSpace(req.Notes), username, now,
+	).Scan(&id); err != nil {
+		return models.StockOpname{}, err
+	}
+
+	nomor := fmt.Sprintf("OPN-%s-%06d", now.Format("20060102"), id)
+	if _, err := tx.Exec(`UPDATE stock_opnames SET nomor = $1 WHERE id = $2`, nomor, id); err != nil {
+		return models.StockOpname{}, err
+	}
+
+	productNos := make([]int, len(items))
+	colorIDs := make([]int, len(items))
+	sizes := make([]string, len(items))
+	books := make([]int, len(items))
+	for i, item := range items {
+		productNos[i], colorIDs[i], sizes[i], books[i] = item.ProductNo, item.ColorID, item.Size, item.BookQuantity
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO stock_opname_items (opname_id, product_no, color_id, size, book_quantity)
+		SELECT $1, * FROM unnest($2::int[], $3::int[], $4::text[], $5::int[])`,
+		id, pq.Array(productNos), pq.Array(colorIDs), pq.Array(sizes), pq.Array(books)); err != nil {
+		return models.StockOpname{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockOpname{}, err
+	}
+	return FetchStockOpnameByID(id)
+}
+
+// buildStockOpnameConditions builds the WHERE clause shared by the count and fetch queries
+func buildStockOpnameConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			so.nomor ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR so.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR ml.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":      "so.status",
+		"location_id": "so.location_id",
+	}
+	for _, field := range []string{"status", "location_id"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND so.created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND so.created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const stockOpnameSelect = `
+	SELECT
+		so.id, COALESCE(so.nomor, ''), so.location_id, COALESCE(ml.nama, ''), so.filters, so.status, COALESCE(so.notes, ''),
+		COALESCE(so.created_by, ''), so.created_at, COALESCE(so.submitted_by, ''), so.submitted_at,
+		COALESCE(so.approved_by, ''), so.approved_at, so.tanggal_update,
+		(SELECT COUNT(*) FROM stock_opname_items i WHERE i.opname_id = so.id),
+		(SELECT COUNT(*) FROM stock_opname_items i WHERE i.opname_id = so.id AND i.counted_quantity IS NOT NULL),
+		(SELECT COALESCE(SUM(i.counted_quantity - i.book_quantity), 0) FROM stock_opname_items i
+			WHERE i.opname_id = so.id AND i.counted_quantity IS NOT NULL)
+	FROM stock_opnames so
+	LEFT JOIN master_locations ml ON ml.id = so.location_id`
+
+// scanStockOpname scans a row selected with stockOpnameSelect
+func scanStockOpname(scanner interface{ Scan(...interface{}) error }, o *models.StockOpname) error {
+	var filtersJSON []byte
+	if err := scanner.Scan(
+		&o.ID, &o.Nomor, &o.LocationID, &o.LocationName, &filtersJSON, &o.Status, &o.Notes,
+		&o.CreatedBy, &o.CreatedAt, &o.SubmittedBy, &o.SubmittedAt,
+		&o.ApprovedBy, &o.ApprovedAt, &o.TanggalUpdate,
+		&o.TotalItems, &o.CountedItems, &o.TotalVariance,
+	); err != nil {
+		return err
+	}
+
+	o.Filters = map[string]string{}
+	if len(filtersJSON) > 0 {
+		if err := json.Unmarshal(filtersJSON, &o.Filters); err != nil {
+			log.Printf("Failed to unmarshal filters for stock opname %d: %v", o.ID, err)
+		}
+	}
+	return nil
+}
+
+// CountStockOpnames counts all count sessions matching the search query and filters
+func CountStockOpnames(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildStockOpnameConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(so.id)
+		FROM stock_opnames so
+		LEFT JOIN master_locations ml ON ml.id = so.location_id`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchStockOpnames retrieves count sessions matching the search query and filters with pagination.
+// Items are not included; fetch a single session to get them.
+func FetchStockOpnames(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.StockOpname, error) {
+	opnames := []models.StockOpname{}
+
+	conditions, args, paramCount := buildStockOpnameConditions(queryStr, filters)
+	baseQuery := stockOpnameSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nomor": true, "status": true, "created_at": true, "submitted_at": true, "approved_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "so." + sortColumn + " " + sortDirection + ", so.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o models.StockOpname
+		if err := scanStockOpname(rows, &o); err != nil {
+			return nil, err
+		}
+		opnames = append(opnames, o)
+	}
+
+	return opnames, rows.Err()
+}
+
+// FetchStockOpnameByID retrieves a count session with all its items
+func FetchStockOpnameByID(id int) (models.StockOpname, error) {
+	var o models.StockOpname
+	err := scanStockOpname(DB.QueryRow(stockOpnameSelect+` WHERE so.id = $1`, id), &o)
+	if err == sql.ErrNoRows {
+		return o, errors.New("not_found")
+	}
+	if err != nil {
+		return o, err
+	}
+
+	rows, err := DB.Query(`
+		SELECT i.id, i.product_no, COALESCE(mp.artikel, ''), i.color_id, COALESCE(mc.nama, ''), i.size,
+			i.book_quantity, i.counted_quantity, COALESCE(i.counted_by, ''), i.counted_at
+		FROM stock_opname_items i
+		LEFT JOIN master_products mp ON mp.no = i.product_no
+		LEFT JOIN master_colors mc ON mc.id = i.color_id
+		WHERE i.opname_id = $1
+		ORDER BY i.product_no, i.color_id, i.size`, id)
+	if err != nil {
+		return o, err
+	}
+	defer rows.Close()
+
+	o.Items = []models.StockOpnameItem{}
+	for rows.Next() {
+		var item models.StockOpnameItem
+		if err := rows.Scan(&item.ID, &item.ProductNo, &item.Artikel, &item.ColorID, &item.ColorName, &item.Size,
+			&item.BookQuantity, &item.CountedQuantity, &item.CountedBy, &item.CountedAt); err != nil {
+			return o, err
+		}
+		if item.CountedQuantity != nil {
+			variance := *item.CountedQuantity - item.BookQuantity
+			item.Variance = &variance
+		}
+		o.Items = append(o.Items, item)
+	}
+
+	return o, rows.Err()
+}
+
+// lockStockOpname locks a count session row for the rest of the transaction
+func lockStockOpname(tx *sql.Tx, id int) (models.StockOpname, error) {
+	var o models.StockOpname
+	err := tx.QueryRow(`
+		SELECT id, COALESCE(nomor, ''), location_id, status
+		FROM stock_opnames WHERE id = $1
+		FOR UPDATE`, id).Scan(&o.ID, &o.Nomor, &o.LocationID, &o.Status)
+	if err == sql.ErrNoRows {
+		return o, errors.New("not_found")
+	}
+	return o, err
+}
+
+// ErrOpnameItemNotInScope is returned when a counted variant is not part of the count session
+var ErrOpnameItemNotInScope = errors.New("item_not_in_scope")
+
+// RecordStockOpnameCounts stores counted quantities for variants of an open session.
+// Counting the same variant again overwrites the previous count.
+func RecordStockOpnameCounts(id int, counts []models.StockOpnameCountInput, username string) (models.StockOpname, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	defer tx.Rollback()
+
+	o, err := lockStockOpname(tx, id)
+	if err != nil {
+		return o, err
+	}
+	if o.Status != models.StockOpnameStatusOpen {
+		return o, errors.New("invalid_status")
+	}
+
+	now := time.Now()
+	for _, count := range counts {
+		size := strings.TrimSpace(count.Size)
+		result, err := tx.Exec(`
+			UPDATE stock_opname_items SET counted_quantity = $1, counted_by = $2, counted_at = $3
+			WHERE opname_id = $4 AND product_no = $5 AND color_id = $6 AND size = $7`,
+			*count.CountedQuantity, username, now, id, count.ProductNo, count.ColorID, size)
+		if err != nil {
+			return o, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return o, fmt.Errorf("%w: product %d color %d size %s", ErrOpnameItemNotInScope, count.ProductNo, count.ColorID, size)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE stock_opnames SET tanggal_update = $1 WHERE id = $2`, now, id); err != nil {
+		return o, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return o, err
+	}
+	return FetchStockOpnameByID(id)
+}
+
+// SubmitStockOpname closes counting on an open session so it can be approved
+func SubmitStockOpname(id int, username string) (models.StockOpname, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	defer tx.Rollback()
+
+	o, err := lockStockOpname(tx, id)
+	if err != nil {
+		return o, err
+	}
+	if o.Status != models.StockOpnameStatusOpen {
+		return o, errors.New("invalid_status")
+	}
+
+	var counted int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM stock_opname_items WHERE opname_id = $1 AND counted_quantity IS NOT NULL`, id).
+		Scan(&counted); err != nil {
+		return o, err
+	}
+	if counted == 0 {
+		return o, errors.New("nothing_counted")
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE stock_opnames SET status = $1, submitted_by = $2, submitted_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.StockOpnameStatusSubmitted, username, now, id); err != nil {
+		return o, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return o, err
+	}
+	return FetchStockOpnameByID(id)
+}
+
+// ApproveStockOpname posts the variance of every counted item as an adjustment movement at the session's location.
+// The variance is applied on top of the current on-hand quantity, so movements recorded while counting are kept.
+// Items that were never counted are left unchanged.
+func ApproveStockOpname(id int, userID, username string) (models.StockOpname, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	defer tx.Rollback()
+
+	o, err := lockStockOpname(tx, id)
+	if err != nil {
+		return o, err
+	}
+	if o.Status != models.StockOpnameStatusSubmitted {
+		return o, errors.New("invalid_status")
+	}
+
+	rows, err := tx.Query(`
+		SELECT product_no, color_id, size, counted_quantity - book_quantity
+		FROM stock_opname_items
+		WHERE opname_id = $1 AND counted_quantity IS NOT NULL AND counted_quantity <> book_quantity
+		ORDER BY id`, id)
+	if err != nil {
+		return o, err
+	}
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ProductNo, &m.ColorID, &m.Size, &m.Quantity); err != nil {
+			rows.Close()
+			return o, err
+		}
+		movements = append(movements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return o, err
+	}
+
+	now := time.Now()
+	for i := range movements {
+		m := &movements[i]
+		m.LocationID = o.LocationID
+		m.Reason = models.StockReasonAdjustment
+		m.Reference = o.Nomor
+		m.Notes = "Stock opname variance"
+		m.UserID = userID
+		m.Username = username
+		m.CreatedAt = now
+		if err := ApplyStockMovement(tx, m); err != nil {
+			return o, fmt.Errorf("failed to adjust product %d color %d size %s: %w", m.ProductNo, m.ColorID, m.Size, err)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE stock_opnames SET status = $1, approved_by = $2, approved_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.StockOpnameStatusApproved, username, now, id); err != nil {
+		return o, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return o, err
+	}
+	return FetchStockOpnameByID(id)
+}
+
+// ReopenStockOpname sends a submitted session back to counting
+func ReopenStockOpname(id int) (models.StockOpname, error) {
+	return setStockOpnameStatus(id, []string{models.StockOpnameStatusSubmitted}, models.StockOpnameStatusOpen)
+}
+
+// CancelStockOpname abandons an open or submitted session without touching stock
+func CancelStockOpname(id int) (models.StockOpname, error) {
+	return setStockOpnameStatus(id, []string{models.StockOpnameStatusOpen, models.StockOpnameStatusSubmitted}, models.StockOpnameStatusCancelled)
+}
+
+// setStockOpnameStatus moves a session to a new status when its current status is one of the allowed ones
+func setStockOpnameStatus(id int, allowed []string, status string) (models.StockOpname, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockOpname{}, err
+	}
+	defer tx.Rollback()
+
+	o, err := lockStockOpname(tx, id)
+	if err != nil {
+		return o, err
+	}
+
+	valid := false
+	for _, s := range allowed {
+		if o.Status == s {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return o, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`UPDATE stock_opnames SET status = $1, tanggal_update = $2 WHERE id = $3`, status, time.Now(), id); err != nil {
+		return o, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return o, err
+	}
+	return FetchStockOpnameByID(id)
+}
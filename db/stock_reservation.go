@@ -0,0 +1,371 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// reservationKey identifies a product variant at one location
+type reservationKey struct {
+	ProductNo  int
+	ColorID    int
+	Size       string
+	LocationID int
+}
+
+// CreateStockReservationsTableIfNotExists ensures the stock_reservations table exists
+func CreateStockReservationsTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS stock_reservations (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			reference TEXT NOT NULL,
+			notes TEXT,
+			status TEXT NOT NULL DEFAULT 'active',
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			closed_by TEXT,
+			closed_at TIMESTAMPTZ,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_reservations_active ON stock_reservations(product_no, color_id, size, location_id) WHERE status = 'active';`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_reservations_status_expires_at ON stock_reservations(status, expires_at);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured stock_reservations table exists")
+	return nil
+}
+
+// fetchReservedQuantities sums the active reservations of the given products per variant and location.
+// Reservations past their expiry no longer hold stock even before the sweeper marks them expired.
+// A locationID of 0 includes every location.
+func fetchReservedQuantities(productNos []int, locationID int) (map[reservationKey]int, error) {
+	query := `
+		SELECT product_no, color_id, size, location_id, SUM(quantity)
+		FROM stock_reservations
+		WHERE status = $1 AND expires_at > CURRENT_TIMESTAMP AND product_no = ANY($2)`
+	args := []interface{}{models.StockReservationStatusActive, pq.Array(productNos)}
+	if locationID > 0 {
+		query += " AND location_id = $3"
+		args = append(args, locationID)
+	}
+	query += " GROUP BY product_no, color_id, size, location_id"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[reservationKey]int)
+	for rows.Next() {
+		var key reservationKey
+		var quantity int
+		if err := rows.Scan(&key.ProductNo, &key.ColorID, &key.Size, &key.LocationID, &quantity); err != nil {
+			return nil, err
+		}
+		result[key] = quantity
+	}
+	return result, rows.Err()
+}
+
+// reservedQuantityTx returns the quantity held by active reservations of a variant at a location.
+// Callers lock the variant row first so the result stays valid for the rest of the transaction.
+func reservedQuantityTx(tx *sql.Tx, productNo, colorID int, size string, locationID int) (int, error) {
+	var reserved int
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+		WHERE product_no = $1 AND color_id = $2 AND size = $3 AND location_id = $4
+			AND status = $5 AND expires_at > CURRENT_TIMESTAMP`,
+		productNo, colorID, size, locationID, models.StockReservationStatusActive).Scan(&reserved)
+	return reserved, err
+}
+
+// buildStockReservationConditions builds the WHERE clause shared by the count and fetch queries
+func buildStockReservationConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			sr.reference ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR sr.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR mp.artikel ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":      "sr.status",
+		"product_no":  "sr.product_no",
+		"color_id":    "sr.color_id",
+		"size":        "sr.size",
+		"location_id": "sr.location_id",
+		"reference":   "sr.reference",
+	}
+	for _, field := range []string{"status", "product_no", "color_id", "size", "location_id", "reference"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	return conditions, args, paramCount
+}
+
+const stockReservationSelect = `
+	SELECT
+		sr.id, sr.product_no, COALESCE(mp.artikel, ''), sr.color_id, COALESCE(mc.nama, ''), sr.size,
+		sr.location_id, COALESCE(ml.nama, ''), sr.quantity, sr.reference, COALESCE(sr.notes, ''), sr.status, sr.expires_at,
+		COALESCE(sr.created_by, ''), sr.created_at, COALESCE(sr.closed_by, ''), sr.closed_at, sr.tanggal_update
+	FROM stock_reservations sr
+	LEFT JOIN master_products mp ON mp.no = sr.product_no
+	LEFT JOIN master_colors mc ON mc.id = sr.color_id
+	LEFT JOIN master_locations ml ON ml.id = sr.location_id`
+
+// scanStockReservation scans a row selected with stockReservationSelect
+func scanStockReservation(scanner interface{ Scan(...interface{}) error }, r *models.StockReservation) error {
+	return scanner.Scan(
+		&r.ID, &r.ProductNo, &r.Artikel, &r.ColorID, &r.ColorName, &r.Size,
+		&r.LocationID, &r.LocationName, &r.Quantity, &r.Reference, &r.Notes, &r.Status, &r.ExpiresAt,
+		&r.CreatedBy, &r.CreatedAt, &r.ClosedBy, &r.ClosedAt, &r.TanggalUpdate,
+	)
+}
+
+// CountStockReservations counts all reservations matching the search query and filters
+func CountStockReservations(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildStockReservationConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(sr.id)
+		FROM stock_reservations sr
+		LEFT JOIN master_products mp ON mp.no = sr.product_no`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchStockReservations retrieves reservations matching the search query and filters with pagination
+func FetchStockReservations(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.StockReservation, error) {
+	reservations := []models.StockReservation{}
+
+	conditions, args, paramCount := buildStockReservationConditions(queryStr, filters)
+	baseQuery := stockReservationSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "product_no": true, "quantity": true, "reference": true, "status": true, "expires_at": true, "created_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "sr." + sortColumn + " " + sortDirection + ", sr.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.StockReservation
+		if err := scanStockReservation(rows, &r); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+	return reservations, rows.Err()
+}
+
+// FetchStockReservationByID retrieves a single reservation
+func FetchStockReservationByID(id int) (models.StockReservation, error) {
+	var r models.StockReservation
+	err := scanStockReservation(DB.QueryRow(stockReservationSelect+` WHERE sr.id = $1`, id), &r)
+	if err == sql.ErrNoRows {
+		return r, errors.New("not_found")
+	}
+	return r, err
+}
+
+// InsertStockReservation holds stock of a variant at a location. The variant row is locked so that
+// concurrent reservations and outgoing movements cannot take more than is available.
+func InsertStockReservation(req *models.CreateStockReservationRequest, username string) (models.StockReservation, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockReservation{}, err
+	}
+	defer tx.Rollback()
+
+	size := strings.TrimSpace(req.Size)
+	onHand, err := lockVariantQuantity(tx, req.ProductNo, req.ColorID, size, req.LocationID)
+	if err != nil {
+		return models.StockReservation{}, err
+	}
+	reserved, err := reservedQuantityTx(tx, req.ProductNo, req.ColorID, size, req.LocationID)
+	if err != nil {
+		return models.StockReservation{}, err
+	}
+	if available := onHand - reserved; req.Quantity > available {
+		return models.StockReservation{}, fmt.Errorf("%w: color %d size %s has %d available at location %d",
+			ErrInsufficientStock, req.ColorID, size, available, req.LocationID)
+	}
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(`
+		INSERT INTO stock_reservations (product_no, color_id, size, location_id, quantity, reference, notes, status, expires_at, created_by, created_at, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+		RETURNING id`,
+		req.ProductNo, req.ColorID, size, req.LocationID, req.Quantity, strings.TrimSpace(req.Reference), strings.TrimSpace(req.Notes),
+		models.StockReservationStatusActive, req.Expiry, username, now,
+	).Scan(&id); err != nil {
+		return models.StockReservation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockReservation{}, err
+	}
+	return FetchStockReservationByID(id)
+}
+
+// lockStockReservation locks a reservation row for the rest of the transaction.
+// An active reservation past its expiry is reported as expired.
+func lockStockReservation(tx *sql.Tx, id int) (models.StockReservation, error) {
+	var r models.StockReservation
+	err := tx.QueryRow(`
+		SELECT id, product_no, color_id, size, location_id, quantity, reference, status, expires_at
+		FROM stock_reservations WHERE id = $1
+		FOR UPDATE`, id).Scan(&r.ID, &r.ProductNo, &r.ColorID, &r.Size, &r.LocationID, &r.Quantity, &r.Reference, &r.Status, &r.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return r, errors.New("not_found")
+	}
+	if err == nil && r.Status == models.StockReservationStatusActive && !r.ExpiresAt.After(time.Now()) {
+		r.Status = models.StockReservationStatusExpired
+	}
+	return r, err
+}
+
+// ReleaseStockReservation releases an active reservation so its stock becomes available again
+func ReleaseStockReservation(id int, username string) (models.StockReservation, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockReservation{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := lockStockReservation(tx, id)
+	if err != nil {
+		return r, err
+	}
+	if r.Status != models.StockReservationStatusActive {
+		return r, errors.New("invalid_status")
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE stock_reservations SET status = $1, closed_by = $2, closed_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.StockReservationStatusReleased, username, now, id); err != nil {
+		return r, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r, err
+	}
+	return FetchStockReservationByID(id)
+}
+
+// FulfillStockReservation ships an active reservation: the hold is closed and a sale movement takes the stock out
+func FulfillStockReservation(id int, userID, username string) (models.StockReservation, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockReservation{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := lockStockReservation(tx, id)
+	if err != nil {
+		return r, err
+	}
+	if r.Status != models.StockReservationStatusActive {
+		return r, errors.New("invalid_status")
+	}
+
+	// The hold is closed first so the sale can use the stock it was holding
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE stock_reservations SET status = $1, closed_by = $2, closed_at = $3, tanggal_update = $3 WHERE id = $4`,
+		models.StockReservationStatusFulfilled, username, now, id); err != nil {
+		return r, err
+	}
+
+	m := models.StockMovement{
+		ProductNo:  r.ProductNo,
+		ColorID:    r.ColorID,
+		Size:       r.Size,
+		LocationID: r.LocationID,
+		Quantity:   -r.Quantity,
+		Reason:     models.StockReasonSale,
+		Reference:  r.Reference,
+		Notes:      fmt.Sprintf("Fulfilled reservation %d", r.ID),
+		UserID:     userID,
+		Username:   username,
+		CreatedAt:  now,
+	}
+	if err := ApplyStockMovement(tx, &m); err != nil {
+		return r, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r, err
+	}
+	return FetchStockReservationByID(id)
+}
+
+// ExpireStockReservations marks every active reservation past its expiry as expired and returns how many were released
+func ExpireStockReservations() (int, error) {
+	now := time.Now()
+	result, err := DB.Exec(`
+		UPDATE stock_reservations SET status = $1, closed_at = $2, tanggal_update = $2
+		WHERE status = $3 AND expires_at <= $2`,
+		models.StockReservationStatusExpired, now, models.StockReservationStatusActive)
+	if err != nil {
+		return 0, err
+	}
+	expired, err := result.RowsAffected()
+	return int(expired), err
+}
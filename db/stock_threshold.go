@@ -0,0 +1,503 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// CreateStockThresholdsTableIfNotExists ensures the stock_thresholds and stock_alerts tables exist
+func CreateStockThresholdsTableIfNotExists() error {
+	statements := []string{
+		// color_id 0 with an empty size is the product default, any other row overrides one variant
+		`CREATE TABLE IF NOT EXISTS stock_thresholds (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL DEFAULT 0,
+			size TEXT NOT NULL DEFAULT '',
+			min_stock INTEGER NOT NULL DEFAULT 0 CHECK (min_stock >= 0),
+			reorder_quantity INTEGER NOT NULL DEFAULT 0 CHECK (reorder_quantity >= 0),
+			updated_by TEXT,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uq_stock_thresholds_variant UNIQUE (product_no, color_id, size)
+		);`,
+		`CREATE TABLE IF NOT EXISTS stock_alerts (
+			id SERIAL PRIMARY KEY,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			quantity INTEGER NOT NULL,
+			min_stock INTEGER NOT NULL,
+			reorder_quantity INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			notified_at TIMESTAMPTZ,
+			resolved_at TIMESTAMPTZ
+		);`,
+		// A variant has at most one open alert, it is raised again only after it was resolved
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_alerts_open_variant ON stock_alerts(product_no, color_id, size) WHERE status = 'open';`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_alerts_status ON stock_alerts(status);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured stock_thresholds table exists")
+	return nil
+}
+
+// FetchProductStockThresholds retrieves the default threshold and variant overrides of a product
+func FetchProductStockThresholds(productNo int) (models.ProductStockThresholds, error) {
+	result := models.ProductStockThresholds{ProductNo: productNo, Variants: []models.StockThreshold{}}
+
+	rows, err := DB.Query(`
+		SELECT st.color_id, COALESCE(mc.nama, ''), st.size, st.min_stock, st.reorder_quantity, COALESCE(st.updated_by, ''), st.tanggal_update
+		FROM stock_thresholds st
+		LEFT JOIN master_colors mc ON mc.id = st.color_id
+		WHERE st.product_no = $1
+		ORDER BY st.color_id, st.size`, productNo)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.StockThreshold
+		if err := rows.Scan(&t.ColorID, &t.ColorName, &t.Size, &t.MinStock, &t.ReorderQuantity, &t.UpdatedBy, &t.TanggalUpdate); err != nil {
+			return result, err
+		}
+		if t.ColorID == 0 && t.Size == "" {
+			defaultThreshold := t
+			result.Default = &defaultThreshold
+			continue
+		}
+		result.Variants = append(result.Variants, t)
+	}
+
+	return result, rows.Err()
+}
+
+// SetProductStockThresholds replaces the default threshold and all variant overrides of a product
+func SetProductStockThresholds(productNo int, req *models.SetStockThresholdsRequest, username string) (models.ProductStockThresholds, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.ProductStockThresholds{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM stock_thresholds WHERE product_no = $1`, productNo); err != nil {
+		return models.ProductStockThresholds{}, err
+	}
+
+	now := time.Now()
+	insert := `
+		INSERT INTO stock_thresholds (product_no, color_id, size, min_stock, reorder_quantity, updated_by, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if req.Default != nil {
+		if _, err := tx.Exec(insert, productNo, 0, "", req.Default.MinStock, req.Default.ReorderQuantity, username, now); err != nil {
+			return models.ProductStockThresholds{}, err
+		}
+	}
+	for _, v := range req.Variants {
+		if _, err := tx.Exec(insert, productNo, v.ColorID, strings.TrimSpace(v.Size), v.MinStock, v.ReorderQuantity, username, now); err != nil {
+			return models.ProductStockThresholds{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ProductStockThresholds{}, err
+	}
+	return FetchProductStockThresholds(productNo)
+}
+
+// FetchLowStockVariants lists every variant whose total on-hand quantity is below its minimum stock.
+// Variant thresholds take precedence over the product default. Products can be filtered by kat, grup and supplier.
+func FetchLowStockVariants(filters map[string]string) ([]models.LowStockVariant, error) {
+	query := `
+		SELECT mp.no, COALESCE(mp.artikel, ''), COALESCE(mp.nama, ''), COALESCE(mp.kat, ''), COALESCE(mp.grup, ''),
+			COALESCE(mp.supplier, ''), COALESCE(mp.warna, ''), COALESCE(mp.size, '')
+		FROM master_products mp
+		WHERE mp.tanggal_hapus IS NULL
+			AND EXISTS (SELECT 1 FROM stock_thresholds st WHERE st.product_no = mp.no)`
+	args := []interface{}{}
+	paramCount := 1
+
+	// Filters use the same exact-match semantics as the product list
+	for _, field := range []string{"kat", "grup", "supplier"} {
+		if value, ok := filters[field]; ok && value != "" {
+			query += ` AND mp.` + field + ` = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+	if value, ok := filters["product_no"]; ok && value != "" {
+		query += ` AND CAST(mp.no AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	query += " ORDER BY mp.no"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	products := []models.Product{}
+	productNos := []int{}
+	for rows.Next() {
+		var no int
+		var p models.Product
+		if err := rows.Scan(&no, &p.Artikel, &p.Nama, &p.Kat, &p.Grup, &p.Supplier, &p.Warna, &p.Size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.No = strconv.Itoa(no)
+		products = append(products, p)
+		productNos = append(productNos, no)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	variants := []models.LowStockVariant{}
+	if len(productNos) == 0 {
+		return variants, nil
+	}
+
+	thresholds, err := fetchStockThresholdsByProduct(productNos)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := fetchStockRows(productNos, 0)
+	if err != nil {
+		return nil, err
+	}
+	incoming, err := fetchIncomingQuantities(productNos)
+	if err != nil {
+		return nil, err
+	}
+	colorNames, err := fetchColorNames()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		no := productNos[i]
+		for _, s := range buildVariantStocks(&products[i], stored[no]) {
+			key := variantKey{ColorID: s.ColorID, Size: s.Size}
+			threshold, ok := thresholds[no][key]
+			if !ok {
+				threshold, ok = thresholds[no][variantKey{}]
+			}
+			if !ok || s.Quantity >= threshold.MinStock {
+				continue
+			}
+
+			colorName := s.ColorName
+			if colorName == "" {
+				colorName = colorNames[s.ColorID]
+			}
+			variants = append(variants, models.LowStockVariant{
+				ProductNo:       no,
+				Artikel:         products[i].Artikel,
+				Nama:            products[i].Nama,
+				Kat:             products[i].Kat,
+				Grup:            products[i].Grup,
+				Supplier:        products[i].Supplier,
+				ColorID:         s.ColorID,
+				ColorName:       colorName,
+				Size:            s.Size,
+				Quantity:        s.Quantity,
+				MinStock:        threshold.MinStock,
+				ReorderQuantity: threshold.ReorderQuantity,
+				Incoming:        incoming[no][key],
+			})
+		}
+	}
+
+	return variants, nil
+}
+
+// fetchStockThresholdsByProduct retrieves the thresholds of the given products keyed by product number and variant.
+// The product default is keyed by the zero variantKey.
+func fetchStockThresholdsByProduct(productNos []int) (map[int]map[variantKey]models.StockThreshold, error) {
+	rows, err := DB.Query(`
+		SELECT product_no, color_id, size, min_stock, reorder_quantity
+		FROM stock_thresholds WHERE product_no = ANY($1)`, pq.Array(productNos))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]map[variantKey]models.StockThreshold)
+	for rows.Next() {
+		var productNo int
+		var t models.StockThreshold
+		if err := rows.Scan(&productNo, &t.ColorID, &t.Size, &t.MinStock, &t.ReorderQuantity); err != nil {
+			return nil, err
+		}
+		if result[productNo] == nil {
+			result[productNo] = make(map[variantKey]models.StockThreshold)
+		}
+		result[productNo][variantKey{ColorID: t.ColorID, Size: t.Size}] = t
+	}
+	return result, rows.Err()
+}
+
+// fetchIncomingQuantities sums the outstanding quantity of open purchase orders per product variant
+func fetchIncomingQuantities(productNos []int) (map[int]map[variantKey]int, error) {
+	rows, err := DB.Query(`
+		SELECT poi.product_no, poi.color_id, poi.size, SUM(GREATEST(poi.quantity - COALESCE(rcv.received, 0), 0))
+		FROM purchase_order_items poi
+		JOIN purchase_orders po ON po.id = poi.purchase_order_id`+purchaseOrderReceivedJoin+`
+		WHERE poi.product_no = ANY($1) AND po.status IN ($2, $3)
+		GROUP BY poi.product_no, poi.color_id, poi.size`,
+		pq.Array(productNos), models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]map[variantKey]int)
+	for rows.Next() {
+		var productNo, quantity int
+		var key variantKey
+		if err := rows.Scan(&productNo, &key.ColorID, &key.Size, &quantity); err != nil {
+			return nil, err
+		}
+		if result[productNo] == nil {
+			result[productNo] = make(map[variantKey]int)
+		}
+		result[productNo][key] = quantity
+	}
+	return result, rows.Err()
+}
+
+// fetchColorNames retrieves the names of all colors keyed by ID
+func fetchColorNames() (map[int]string, error) {
+	rows, err := DB.Query(`SELECT id, nama FROM master_colors`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var nama string
+		if err := rows.Scan(&id, &nama); err != nil {
+			return nil, err
+		}
+		names[id] = nama
+	}
+	return names, rows.Err()
+}
+
+// SyncStockAlerts opens an alert for every low variant without an open alert and resolves open alerts
+// of variants that are no longer low. It returns the number of alerts opened and resolved.
+func SyncStockAlerts() (int, int, error) {
+	low, err := FetchLowStockVariants(nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productNos := make([]int, len(low))
+	colorIDs := make([]int, len(low))
+	sizes := make([]string, len(low))
+	quantities := make([]int, len(low))
+	minStocks := make([]int, len(low))
+	reorderQuantities := make([]int, len(low))
+	for i, v := range low {
+		productNos[i] = v.ProductNo
+		colorIDs[i] = v.ColorID
+		sizes[i] = v.Size
+		quantities[i] = v.Quantity
+		minStocks[i] = v.MinStock
+		reorderQuantities[i] = v.ReorderQuantity
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO stock_alerts (product_no, color_id, size, quantity, min_stock, reorder_quantity, status, created_at)
+		SELECT l.product_no, l.color_id, l.size, l.quantity, l.min_stock, l.reorder_quantity, $7, $8
+		FROM unnest($1::int[], $2::int[], $3::text[], $4::int[], $5::int[], $6::int[])
+			AS l(product_no, color_id, size, quantity, min_stock, reorder_quantity)
+		ON CONFLICT (product_no, color_id, size) WHERE status = 'open' DO NOTHING`,
+		pq.Array(productNos), pq.Array(colorIDs), pq.Array(sizes), pq.Array(quantities), pq.Array(minStocks), pq.Array(reorderQuantities),
+		models.StockAlertStatusOpen, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	opened, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	result, err = tx.Exec(`
+		UPDATE stock_alerts sa SET status = $4, resolved_at = $5
+		WHERE sa.status = $6 AND NOT EXISTS (
+			SELECT 1 FROM unnest($1::int[], $2::int[], $3::text[]) AS l(product_no, color_id, size)
+			WHERE l.product_no = sa.product_no AND l.color_id = sa.color_id AND l.size = sa.size
+		)`,
+		pq.Array(productNos), pq.Array(colorIDs), pq.Array(sizes),
+		models.StockAlertStatusResolved, now, models.StockAlertStatusOpen)
+	if err != nil {
+		return 0, 0, err
+	}
+	resolved, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return int(opened), int(resolved), nil
+}
+
+// buildStockAlertConditions builds the WHERE clause shared by the count and fetch queries
+func buildStockAlertConditions(filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":     "sa.status",
+		"product_no": "sa.product_no",
+	}
+	for _, field := range []string{"status", "product_no"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND sa.created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND sa.created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const stockAlertSelect = `
+	SELECT
+		sa.id, sa.product_no, COALESCE(mp.artikel, ''), sa.color_id, COALESCE(mc.nama, ''), sa.size,
+		sa.quantity, sa.min_stock, sa.reorder_quantity, sa.status, sa.created_at, sa.notified_at, sa.resolved_at
+	FROM stock_alerts sa
+	LEFT JOIN master_products mp ON mp.no = sa.product_no
+	LEFT JOIN master_colors mc ON mc.id = sa.color_id`
+
+// scanStockAlert scans a row selected with stockAlertSelect
+func scanStockAlert(scanner interface{ Scan(...interface{}) error }, a *models.StockAlert) error {
+	return scanner.Scan(
+		&a.ID, &a.ProductNo, &a.Artikel, &a.ColorID, &a.ColorName, &a.Size,
+		&a.Quantity, &a.MinStock, &a.ReorderQuantity, &a.Status, &a.CreatedAt, &a.NotifiedAt, &a.ResolvedAt,
+	)
+}
+
+// CountStockAlerts counts all stock alerts matching the filters
+func CountStockAlerts(filters map[string]string) (int, error) {
+	conditions, args, _ := buildStockAlertConditions(filters)
+
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(sa.id) FROM stock_alerts sa`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchStockAlerts retrieves stock alerts matching the filters with pagination
+func FetchStockAlerts(limit, offset int, filters map[string]string, sortColumn string, sortDirection string) ([]models.StockAlert, error) {
+	alerts := []models.StockAlert{}
+
+	conditions, args, paramCount := buildStockAlertConditions(filters)
+	baseQuery := stockAlertSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "product_no": true, "quantity": true, "status": true, "created_at": true, "resolved_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "sa." + sortColumn + " " + sortDirection + ", sa.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.StockAlert
+		if err := scanStockAlert(rows, &a); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// FetchUnnotifiedStockAlerts retrieves open alerts that have not been delivered to the webhook yet
+func FetchUnnotifiedStockAlerts() ([]models.StockAlert, error) {
+	rows, err := DB.Query(stockAlertSelect+` WHERE sa.status = $1 AND sa.notified_at IS NULL ORDER BY sa.id`, models.StockAlertStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.StockAlert{}
+	for rows.Next() {
+		var a models.StockAlert
+		if err := scanStockAlert(rows, &a); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// MarkStockAlertsNotified records that the given alerts were delivered to the webhook
+func MarkStockAlertsNotified(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := DB.Exec(`UPDATE stock_alerts SET notified_at = $1 WHERE id = ANY($2)`, time.Now(), pq.Array(ids))
+	return err
+}
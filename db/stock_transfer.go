@@ -0,0 +1,475 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+	"github.com/lib/pq"
+)
+
+// CreateStockTransfersTableIfNotExists ensures the stock_transfers and stock_transfer_items tables exist
+func CreateStockTransfersTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS stock_transfers (
+			id SERIAL PRIMARY KEY,
+			nomor TEXT,
+			from_location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			to_location_id INTEGER NOT NULL REFERENCES master_locations(id),
+			status TEXT NOT NULL DEFAULT 'draft',
+			notes TEXT,
+			created_by TEXT,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			shipped_by TEXT,
+			shipped_at TIMESTAMPTZ,
+			received_by TEXT,
+			received_at TIMESTAMPTZ,
+			tanggal_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			CHECK (from_location_id <> to_location_id)
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_transfers_nomor ON stock_transfers(nomor);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers(status);`,
+		`CREATE TABLE IF NOT EXISTS stock_transfer_items (
+			id SERIAL PRIMARY KEY,
+			transfer_id INTEGER NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+			product_no INTEGER NOT NULL,
+			color_id INTEGER NOT NULL,
+			size TEXT NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity > 0)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_transfer_id ON stock_transfer_items(transfer_id);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+	}
+
+	log.Println("Ensured stock_transfers table exists")
+	return nil
+}
+
+// buildStockTransferConditions builds the WHERE clause shared by the count and fetch queries
+func buildStockTransferConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		searchQuery := ` AND (
+			st.nomor ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR st.notes ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR lf.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+			OR lt.nama ILIKE $` + fmt.Sprintf("%d", paramCount) + `
+		)`
+		conditions += searchQuery
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	filterColumns := map[string]string{
+		"status":           "st.status",
+		"from_location_id": "st.from_location_id",
+		"to_location_id":   "st.to_location_id",
+	}
+	for _, field := range []string{"status", "from_location_id", "to_location_id"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + filterColumns[field] + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	// A location filter matches transfers going in or out of it
+	if value, ok := filters["location_id"]; ok && value != "" {
+		conditions += ` AND (CAST(st.from_location_id AS TEXT) = $` + fmt.Sprintf("%d", paramCount) +
+			` OR CAST(st.to_location_id AS TEXT) = $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, value)
+		paramCount++
+	}
+
+	// Add date range filters
+	if value, ok := filters["date_from"]; ok && value != "" {
+		conditions += ` AND st.created_at >= $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["date_to"]; ok && value != "" {
+		conditions += ` AND st.created_at < CAST($` + fmt.Sprintf("%d", paramCount) + ` AS DATE) + 1`
+		args = append(args, value)
+		paramCount++
+	}
+
+	return conditions, args, paramCount
+}
+
+const stockTransferSelect = `
+	SELECT
+		st.id, COALESCE(st.nomor, ''), st.from_location_id, COALESCE(lf.nama, ''), st.to_location_id, COALESCE(lt.nama, ''),
+		st.status, COALESCE(st.notes, ''), COALESCE(st.created_by, ''), st.created_at,
+		COALESCE(st.shipped_by, ''), st.shipped_at, COALESCE(st.received_by, ''), st.received_at, st.tanggal_update
+	FROM stock_transfers st
+	LEFT JOIN master_locations lf ON lf.id = st.from_location_id
+	LEFT JOIN master_locations lt ON lt.id = st.to_location_id`
+
+// scanStockTransfer scans a row selected with stockTransferSelect
+func scanStockTransfer(scanner interface{ Scan(...interface{}) error }, t *models.StockTransfer) error {
+	return scanner.Scan(
+		&t.ID, &t.Nomor, &t.FromLocationID, &t.FromLocationName, &t.ToLocationID, &t.ToLocationName,
+		&t.Status, &t.Notes, &t.CreatedBy, &t.CreatedAt,
+		&t.ShippedBy, &t.ShippedAt, &t.ReceivedBy, &t.ReceivedAt, &t.TanggalUpdate,
+	)
+}
+
+// CountStockTransfers counts all stock transfers matching the search query and filters
+func CountStockTransfers(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildStockTransferConditions(queryStr, filters)
+
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(st.id)
+		FROM stock_transfers st
+		LEFT JOIN master_locations lf ON lf.id = st.from_location_id
+		LEFT JOIN master_locations lt ON lt.id = st.to_location_id`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// FetchStockTransfers retrieves stock transfers matching the search query and filters with pagination
+func FetchStockTransfers(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]models.StockTransfer, error) {
+	transfers := []models.StockTransfer{}
+
+	conditions, args, paramCount := buildStockTransferConditions(queryStr, filters)
+	baseQuery := stockTransferSelect + conditions
+
+	// Add sorting
+	orderBy := " ORDER BY "
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"id": true, "nomor": true, "status": true, "created_at": true, "shipped_at": true, "received_at": true,
+	}
+
+	// Default sort
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "created_at"
+	}
+
+	// Default direction
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	orderBy += "st." + sortColumn + " " + sortDirection + ", st.id " + sortDirection
+
+	// Add pagination
+	paginationQuery := orderBy + ` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	baseQuery += paginationQuery
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.StockTransfer
+		if err := scanStockTransfer(rows, &t); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachStockTransferItems(transfers); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// attachStockTransferItems loads the item lines of the given transfers using a single query
+func attachStockTransferItems(transfers []models.StockTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(transfers))
+	index := make(map[int]int, len(transfers))
+	for i, t := range transfers {
+		ids[i] = t.ID
+		index[t.ID] = i
+		transfers[i].Items = []models.StockTransferItem{}
+		transfers[i].TotalQuantity = 0
+	}
+
+	rows, err := DB.Query(`
+		SELECT sti.transfer_id, sti.id, sti.product_no, COALESCE(mp.artikel, ''), sti.color_id, COALESCE(mc.nama, ''), sti.size, sti.quantity
+		FROM stock_transfer_items sti
+		LEFT JOIN master_products mp ON mp.no = sti.product_no
+		LEFT JOIN master_colors mc ON mc.id = sti.color_id
+		WHERE sti.transfer_id = ANY($1)
+		ORDER BY sti.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transferID int
+		var item models.StockTransferItem
+		if err := rows.Scan(&transferID, &item.ID, &item.ProductNo, &item.Artikel, &item.ColorID, &item.ColorName, &item.Size, &item.Quantity); err != nil {
+			return err
+		}
+		i := index[transferID]
+		transfers[i].Items = append(transfers[i].Items, item)
+		transfers[i].TotalQuantity += item.Quantity
+	}
+
+	return rows.Err()
+}
+
+// FetchStockTransferByID retrieves a stock transfer with its items
+func FetchStockTransferByID(id int) (models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := scanStockTransfer(DB.QueryRow(stockTransferSelect+` WHERE st.id = $1`, id), &t)
+	if err == sql.ErrNoRows {
+		return t, errors.New("not_found")
+	}
+	if err != nil {
+		return t, err
+	}
+
+	transfers := []models.StockTransfer{t}
+	if err := attachStockTransferItems(transfers); err != nil {
+		return t, err
+	}
+	return transfers[0], nil
+}
+
+// replaceStockTransferItems replaces all item lines of a transfer
+func replaceStockTransferItems(tx *sql.Tx, transferID int, items []models.StockTransferItemInput) error {
+	if _, err := tx.Exec(`DELETE FROM stock_transfer_items WHERE transfer_id = $1`, transferID); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO stock_transfer_items (transfer_id, product_no, color_id, size, quantity)
+			VALUES ($1, $2, $3, $4, $5)`,
+			transferID, item.ProductNo, item.ColorID, strings.TrimSpace(item.Size), item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertStockTransfer creates a draft transfer with its items and assigns its document number
+func InsertStockTransfer(req *models.SaveStockTransferRequest, username string) (models.StockTransfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(`
+		INSERT INTO stock_transfers (from_location_id, to_location_id, status, notes, created_by, created_at, tanggal_update)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id`,
+		req.FromLocationID, req.ToLocationID, models.StockTransferStatusDraft, strings.TrimSpace(req.Notes), username, now,
+	).Scan(&id); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	nomor := fmt.Sprintf("TRF-%s-%06d", now.Format("20060102"), id)
+	if _, err := tx.Exec(`UPDATE stock_transfers SET nomor = $1 WHERE id = $2`, nomor, id); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := replaceStockTransferItems(tx, id, req.Items); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return FetchStockTransferByID(id)
+}
+
+// lockStockTransfer locks a transfer row for the rest of the transaction and returns its current status
+func lockStockTransfer(tx *sql.Tx, id int) (models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := tx.QueryRow(`
+		SELECT id, COALESCE(nomor, ''), from_location_id, to_location_id, status
+		FROM stock_transfers WHERE id = $1
+		FOR UPDATE`, id).Scan(&t.ID, &t.Nomor, &t.FromLocationID, &t.ToLocationID, &t.Status)
+	if err == sql.ErrNoRows {
+		return t, errors.New("not_found")
+	}
+	return t, err
+}
+
+// UpdateStockTransfer replaces the locations, notes and items of a draft transfer
+func UpdateStockTransfer(id int, req *models.SaveStockTransferRequest) (models.StockTransfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return t, err
+	}
+	if t.Status != models.StockTransferStatusDraft {
+		return t, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE stock_transfers SET from_location_id = $1, to_location_id = $2, notes = $3, tanggal_update = $4
+		WHERE id = $5`,
+		req.FromLocationID, req.ToLocationID, strings.TrimSpace(req.Notes), time.Now(), id); err != nil {
+		return t, err
+	}
+
+	if err := replaceStockTransferItems(tx, id, req.Items); err != nil {
+		return t, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return t, err
+	}
+	return FetchStockTransferByID(id)
+}
+
+// fetchStockTransferItemsTx reads the item lines of a transfer inside a transaction
+func fetchStockTransferItemsTx(tx *sql.Tx, transferID int) ([]models.StockTransferItem, error) {
+	rows, err := tx.Query(`
+		SELECT id, product_no, color_id, size, quantity
+		FROM stock_transfer_items WHERE transfer_id = $1
+		ORDER BY id`, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.StockTransferItem{}
+	for rows.Next() {
+		var item models.StockTransferItem
+		if err := rows.Scan(&item.ID, &item.ProductNo, &item.ColorID, &item.Size, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ShipStockTransfer takes the items of a draft transfer out of its source location and marks it shipped.
+// All items move in one transaction, so the transfer fails as a whole when any variant lacks stock.
+func ShipStockTransfer(id int, userID, username string) (models.StockTransfer, error) {
+	return advanceStockTransfer(id, models.StockTransferStatusDraft, models.StockTransferStatusShipped, userID, username)
+}
+
+// ReceiveStockTransfer puts the items of a shipped transfer into its destination location and marks it received
+func ReceiveStockTransfer(id int, userID, username string) (models.StockTransfer, error) {
+	return advanceStockTransfer(id, models.StockTransferStatusShipped, models.StockTransferStatusReceived, userID, username)
+}
+
+// advanceStockTransfer moves a transfer from one status to the next and posts the matching ledger movements
+func advanceStockTransfer(id int, from, to string, userID, username string) (models.StockTransfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return t, err
+	}
+	if t.Status != from {
+		return t, errors.New("invalid_status")
+	}
+
+	items, err := fetchStockTransferItemsTx(tx, id)
+	if err != nil {
+		return t, err
+	}
+	if len(items) == 0 {
+		return t, errors.New("no_items")
+	}
+
+	// Shipping takes stock out of the source, receiving puts it into the destination
+	locationID, sign, notes := t.FromLocationID, -1, "Shipped to location "+fmt.Sprint(t.ToLocationID)
+	if to == models.StockTransferStatusReceived {
+		locationID, sign, notes = t.ToLocationID, 1, "Received from location "+fmt.Sprint(t.FromLocationID)
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		m := models.StockMovement{
+			ProductNo:  item.ProductNo,
+			ColorID:    item.ColorID,
+			Size:       item.Size,
+			LocationID: locationID,
+			Quantity:   sign * item.Quantity,
+			Reason:     models.StockReasonTransfer,
+			Reference:  t.Nomor,
+			Notes:      notes,
+			UserID:     userID,
+			Username:   username,
+			CreatedAt:  now,
+		}
+		if err := ApplyStockMovement(tx, &m); err != nil {
+			return t, fmt.Errorf("failed to move product %d color %d size %s: %w", item.ProductNo, item.ColorID, item.Size, err)
+		}
+	}
+
+	query := `UPDATE stock_transfers SET status = $1, shipped_by = $2, shipped_at = $3, tanggal_update = $3 WHERE id = $4`
+	if to == models.StockTransferStatusReceived {
+		query = `UPDATE stock_transfers SET status = $1, received_by = $2, received_at = $3, tanggal_update = $3 WHERE id = $4`
+	}
+	if _, err := tx.Exec(query, to, username, now, id); err != nil {
+		return t, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return t, err
+	}
+	return FetchStockTransferByID(id)
+}
+
+// CancelStockTransfer cancels a draft transfer. Shipped transfers cannot be cancelled because their stock is in transit.
+func CancelStockTransfer(id int) (models.StockTransfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return t, err
+	}
+	if t.Status != models.StockTransferStatusDraft {
+		return t, errors.New("invalid_status")
+	}
+
+	if _, err := tx.Exec(`UPDATE stock_transfers SET status = $1, tanggal_update = $2 WHERE id = $3`,
+		models.StockTransferStatusCancelled, time.Now(), id); err != nil {
+		return t, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return t, err
+	}
+	return FetchStockTransferByID(id)
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrTwoFactorEnabled is returned when enrolling a user who already has two-factor authentication on
+var ErrTwoFactorEnabled = errors.New("two_factor_enabled")
+
+// ErrMFAChallengeInvalid is returned for login challenges that are unknown, expired or out of attempts
+var ErrMFAChallengeInvalid = errors.New("mfa_challenge_invalid")
+
+// maxMFAChallengeAttempts is how many codes can be tried against one login challenge
+const maxMFAChallengeAttempts = 5
+
+// CreateTwoFactorTablesIfNotExists creates the recovery code and login challenge tables
+func CreateTwoFactorTablesIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			id SERIAL PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash TEXT NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);`,
+		// Issued by the password step of a login and exchanged for tokens with a second factor
+		`CREATE TABLE IF NOT EXISTS mfa_challenges (
+			token_hash TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			expires_at TIMESTAMPTZ NOT NULL
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured user_recovery_codes and mfa_challenges tables exist")
+	return nil
+}
+
+// FetchUserTOTP retrieves the TOTP secret of a user and whether it is enabled
+func FetchUserTOTP(userID string) (secret string, enabled bool, err error) {
+	err = DB.QueryRow(`SELECT COALESCE(totp_secret, ''), totp_enabled FROM users WHERE id = $1`, userID).Scan(&secret, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, errors.New("not_found")
+	}
+	return secret, enabled, err
+}
+
+// SetPendingTOTPSecret stores a new TOTP secret that takes effect once EnableTOTP confirms it
+func SetPendingTOTPSecret(userID, secret string) error {
+	result, err := DB.Exec(`UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND NOT totp_enabled`, secret, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication after the first code was verified at step,
+// and replaces the recovery codes of the user
+func EnableTOTP(userID string, step int64, recoveryCodeHashes []string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET totp_enabled = TRUE, totp_last_step = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, step, userID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP turns off two-factor authentication and removes the secret and recovery codes
+func DisableTOTP(userID string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, userID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes discards the recovery codes of a user and stores new ones
+func ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceRecoveryCodes discards the recovery codes of a user and stores new ones within tx
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseTOTPStep records that a code for step was used, and reports false when a code for that
+// step or a later one was already used, so each code works once
+func UseTOTPStep(userID string, step int64) (bool, error) {
+	result, err := DB.Exec(`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`, step, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used, and reports whether one matched
+func UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := DB.Exec(`
+		UPDATE user_recovery_codes SET used_at = $1
+		WHERE id = (
+			SELECT id FROM user_recovery_codes WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+			LIMIT 1 FOR UPDATE
+		)`, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes a user has left
+func CountUnusedRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// CreateMFAChallenge stores the hash of a login challenge for the user
+func CreateMFAChallenge(userID, tokenHash string, expiresAt time.Time) error {
+	// Expired challenges are of no use to anyone, clear them while we are here
+	if _, err := DB.Exec(`DELETE FROM mfa_challenges WHERE expires_at <= $1`, time.Now()); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`INSERT INTO mfa_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`, tokenHash, userID, expiresAt)
+	return err
+}
+
+// AttemptMFAChallenge counts an attempt against a login challenge and returns its user.
+// Challenges that are expired or have used up their attempts return ErrMFAChallengeInvalid.
+func AttemptMFAChallenge(tokenHash string) (string, error) {
+	var userID string
+	err := DB.QueryRow(`
+		UPDATE mfa_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > $2 AND attempts < $3
+		RETURNING user_id`, tokenHash, time.Now(), maxMFAChallengeAttempts).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrMFAChallengeInvalid
+	}
+	return userID, err
+}
+
+// DeleteMFAChallenge removes a login challenge once it has been completed
+func DeleteMFAChallenge(tokenHash string) error {
+	_, err := DB.Exec(`DELETE FROM mfa_challenges WHERE token_hash = $1`, tokenHash)
+	return err
+}
@@ -20,8 +20,8 @@ func CreateUser(user auth.User) error {
 
 	// Insert user
 	query := `
-		INSERT INTO users (id, username, email, password, role)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, username, email, password, role, active)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
 	`
 	_, err = tx.Exec(query, user.ID, user.Username, user.Email, user.Password, user.Role)
 	if err != nil {
@@ -35,7 +35,7 @@ func CreateUser(user auth.User) error {
 func GetUserByUsername(username string) (auth.User, error) {
 	var user auth.User
 	query := `
-		SELECT id, username, email, password, role
+		SELECT id, username, email, password, role, active, created_at, email_verified_at, totp_enabled
 		FROM users
 		WHERE username = $1
 	`
@@ -45,6 +45,10 @@ func GetUserByUsername(username string) (auth.User, error) {
 		&user.Email,
 		&user.Password,
 		&user.Role,
+		&user.Active,
+		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TwoFactor,
 	)
 
 	if err != nil {
@@ -61,7 +65,7 @@ func GetUserByUsername(username string) (auth.User, error) {
 func GetUserByEmail(email string) (auth.User, error) {
 	var user auth.User
 	query := `
-		SELECT id, username, email, password, role
+		SELECT id, username, email, password, role, active, created_at, email_verified_at, totp_enabled
 		FROM users
 		WHERE email = $1
 	`
@@ -71,6 +75,10 @@ func GetUserByEmail(email string) (auth.User, error) {
 		&user.Email,
 		&user.Password,
 		&user.Role,
+		&user.Active,
+		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TwoFactor,
 	)
 
 	if err != nil {
@@ -87,7 +95,7 @@ func GetUserByEmail(email string) (auth.User, error) {
 func GetUserByID(id string) (auth.User, error) {
 	var user auth.User
 	query := `
-		SELECT id, username, email, password, role
+		SELECT id, username, email, password, role, active, created_at, email_verified_at, totp_enabled
 		FROM users
 		WHERE id = $1
 	`
@@ -97,6 +105,10 @@ func GetUserByID(id string) (auth.User, error) {
 		&user.Email,
 		&user.Password,
 		&user.Role,
+		&user.Active,
+		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TwoFactor,
 	)
 
 	if err != nil {
@@ -139,6 +151,13 @@ func CreateUsersTableIfNotExists() error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 		CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+		-- Deactivated users can no longer log in
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+		-- The TOTP secret is set at enrollment and only used once totp_enabled is set
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
 	`
 	
 	_, err := DB.Exec(query)
@@ -148,4 +167,135 @@ func CreateUsersTableIfNotExists() error {
 	
 	log.Println("Ensured users table exists")
 	return nil
-}
\ No newline at end of file
+}
+// UpdateUserRole assigns a role to a user and returns the updated user
+func UpdateUserRole(id, role string) (auth.User, error) {
+	var user auth.User
+	err := DB.QueryRow(`
+		UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING id, username, email, password, role, active, created_at, email_verified_at, totp_enabled`, role, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.Active,
+		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TwoFactor,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.User{}, fmt.Errorf("not_found")
+		}
+		return auth.User{}, fmt.Errorf("database error: %w", err)
+	}
+
+	return user, nil
+}
+
+// buildUserConditions builds the WHERE clause for listing users
+func buildUserConditions(queryStr string, filters map[string]string) (string, []interface{}, int) {
+	conditions := " WHERE 1=1"
+	args := []interface{}{}
+	paramCount := 1
+
+	// Add search condition if query string is provided
+	if queryStr != "" {
+		conditions += ` AND (username ILIKE $` + fmt.Sprintf("%d", paramCount) + ` OR email ILIKE $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, "%"+queryStr+"%")
+		paramCount++
+	}
+
+	// Add exact-match filters
+	for _, field := range []string{"role", "active"} {
+		if value, ok := filters[field]; ok && value != "" {
+			conditions += ` AND CAST(` + field + ` AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+			args = append(args, value)
+			paramCount++
+		}
+	}
+
+	return conditions, args, paramCount
+}
+
+// CountUsers counts all users matching the search query and filters
+func CountUsers(queryStr string, filters map[string]string) (int, error) {
+	conditions, args, _ := buildUserConditions(queryStr, filters)
+
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM users`+conditions, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FetchUsers retrieves users with pagination, search, filters and sorting
+func FetchUsers(limit, offset int, queryStr string, filters map[string]string, sortColumn string, sortDirection string) ([]auth.User, error) {
+	users := []auth.User{}
+
+	conditions, args, paramCount := buildUserConditions(queryStr, filters)
+
+	// Map of valid column names to prevent SQL injection
+	validColumns := map[string]bool{
+		"username": true, "email": true, "role": true, "active": true, "created_at": true,
+	}
+	if sortColumn == "" || !validColumns[sortColumn] {
+		sortColumn = "username"
+	}
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "asc"
+	}
+
+	query := `SELECT id, username, email, password, role, active, created_at, email_verified_at, totp_enabled FROM users` + conditions +
+		` ORDER BY ` + sortColumn + ` ` + sortDirection + `, id ` + sortDirection +
+		` LIMIT $` + fmt.Sprintf("%d", paramCount) + ` OFFSET $` + fmt.Sprintf("%d", paramCount+1)
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user auth.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.Active, &user.CreatedAt, &user.EmailVerifiedAt, &user.TwoFactor); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserActive activates or deactivates a user
+func SetUserActive(id string, active bool) error {
+	result, err := DB.Exec(`UPDATE users SET active = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, active, id)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not_found")
+	}
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of a user
+func UpdateUserPassword(id, hashedPassword string) error {
+	result, err := DB.Exec(`UPDATE users SET password = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, hashedPassword, id)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not_found")
+	}
+	return nil
+}
+
+// CountActiveAdmins counts the active users holding the admin role
+func CountActiveAdmins() (int, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1 AND active`, auth.RoleAdmin).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// ErrUserTokenInvalid is returned for email tokens that are unknown, expired or already used
+var ErrUserTokenInvalid = errors.New("user_token_invalid")
+
+// CreateUserTokensTableIfNotExists creates the table of single-use tokens sent by email
+func CreateUserTokensTableIfNotExists() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS user_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			purpose TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_tokens_user_purpose ON user_tokens(user_id, purpose);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Ensured user_tokens table exists")
+	return nil
+}
+
+// CreateUserToken stores the hash of a new token for purpose. Earlier unused tokens of the user
+// for the same purpose are discarded, so only the latest link works.
+func CreateUserToken(userID, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, purpose); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		userID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// consumeUserToken marks a valid token for purpose as used and returns its user
+func consumeUserToken(tx *sql.Tx, purpose, tokenHash string, now time.Time) (string, error) {
+	var id int
+	var userID string
+	err := tx.QueryRow(`
+		SELECT id, user_id FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		FOR UPDATE`, tokenHash, purpose, now).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserTokenInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(`UPDATE user_tokens SET used_at = $1 WHERE id = $2`, now, id); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// ResetPasswordWithToken sets a new password hash for the user of a password reset token and
+// ends all of their sessions. Following the emailed link also proves the address, so it is marked verified.
+func ResetPasswordWithToken(tokenHash, hashedPassword string) (string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	userID, err := consumeUserToken(tx, models.UserTokenPurposePasswordReset, tokenHash, now)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET password = $1, email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+		WHERE id = $3`, hashedPassword, now, userID); err != nil {
+		return "", err
+	}
+	if _, err := revokeRefreshTokens(tx, `user_id = $1`, userID); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
+
+// VerifyEmailWithToken marks the email address of the user of a verification token as verified
+func VerifyEmailWithToken(tokenHash string) (string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	userID, err := consumeUserToken(tx, models.UserTokenPurposeEmailVerification, tokenHash, now)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1
+		WHERE id = $2`, now, userID); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
@@ -0,0 +1,332 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/everysoft/inventary-be/app/models"
+)
+
+// productVariantKey identifies a product variant across all locations
+type productVariantKey struct {
+	ProductNo int
+	ColorID   int
+	Size      string
+}
+
+// receiptCostKey identifies the receipt lines that posted a receipt movement
+type receiptCostKey struct {
+	Nomor string
+	productVariantKey
+}
+
+// costLayer is a quantity of stock received at one unit cost
+type costLayer struct {
+	Quantity int
+	UnitCost float64
+}
+
+// variantCost follows the cost of a variant through its movements, across all locations
+type variantCost struct {
+	Method   string
+	Quantity int
+	Average  float64     // Moving-average cost per unit
+	Layers   []costLayer // FIFO layers, oldest first
+}
+
+// receive adds stock to the variant. Stock without a cost of its own, such as returns, positive adjustments
+// and opening balances, is added at the current average or at the cost of the newest layer.
+func (v *variantCost) receive(quantity int, unitCost float64, costed bool) {
+	if v.Method == models.ValuationMethodAverage {
+		if !costed {
+			unitCost = v.Average
+		}
+		if v.Quantity <= 0 {
+			v.Average = unitCost
+		} else {
+			v.Average = (float64(v.Quantity)*v.Average + float64(quantity)*unitCost) / float64(v.Quantity+quantity)
+		}
+		v.Quantity += quantity
+		return
+	}
+
+	if !costed && len(v.Layers) > 0 {
+		unitCost = v.Layers[len(v.Layers)-1].UnitCost
+	}
+	v.Layers = append(v.Layers, costLayer{Quantity: quantity, UnitCost: unitCost})
+	v.Quantity += quantity
+}
+
+// issue takes stock out of the variant, from the oldest layers first under FIFO
+func (v *variantCost) issue(quantity int) {
+	v.Quantity -= quantity
+	if v.Method == models.ValuationMethodAverage {
+		return
+	}
+
+	for quantity > 0 && len(v.Layers) > 0 {
+		if v.Layers[0].Quantity > quantity {
+			v.Layers[0].Quantity -= quantity
+			return
+		}
+		quantity -= v.Layers[0].Quantity
+		v.Layers = v.Layers[1:]
+	}
+}
+
+// unitCost returns the cost per unit of the stock the variant still holds
+func (v *variantCost) unitCost() float64 {
+	if v.Quantity <= 0 {
+		return 0
+	}
+	if v.Method == models.ValuationMethodAverage {
+		return v.Average
+	}
+
+	quantity, value := 0, 0.0
+	for _, layer := range v.Layers {
+		quantity += layer.Quantity
+		value += float64(layer.Quantity) * layer.UnitCost
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return value / float64(quantity)
+}
+
+// fetchReceiptCosts retrieves the unit costs of receipt lines posted by the end of asOf, in line order per receipt and variant
+func fetchReceiptCosts(asOf string) (map[receiptCostKey][]float64, error) {
+	rows, err := DB.Query(`
+		SELECT gr.nomor, gri.product_no, gri.color_id, gri.size, gri.unit_cost
+		FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.receipt_id
+		WHERE gr.status = $1 AND gr.nomor IS NOT NULL AND gr.posted_at < CAST($2 AS DATE) + 1
+		ORDER BY gri.id`, models.GoodsReceiptStatusPosted, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	costs := make(map[receiptCostKey][]float64)
+	for rows.Next() {
+		var key receiptCostKey
+		var unitCost float64
+		if err := rows.Scan(&key.Nomor, &key.ProductNo, &key.ColorID, &key.Size, &unitCost); err != nil {
+			return nil, err
+		}
+		costs[key] = append(costs[key], unitCost)
+	}
+	return costs, rows.Err()
+}
+
+// FetchInventoryValuation values the stock on hand at the end of asOf (YYYY-MM-DD) by replaying the stock ledger.
+// Receipt movements carry the unit cost of their receipt line, transfers move stock between locations without
+// changing its cost. Filters narrow the products by kat or product_no and the locations by location_id.
+func FetchInventoryValuation(asOf, method, groupBy string, filters map[string]string) (models.InventoryValuation, error) {
+	valuation := models.InventoryValuation{
+		AsOf:    asOf,
+		Method:  method,
+		GroupBy: groupBy,
+		Rows:    []models.InventoryValuationRow{},
+	}
+
+	receiptCosts, err := fetchReceiptCosts(asOf)
+	if err != nil {
+		return valuation, err
+	}
+
+	query := `
+		SELECT sm.product_no, sm.color_id, sm.size, sm.location_id, sm.quantity, sm.reason, COALESCE(sm.reference, '')
+		FROM stock_movements sm
+		WHERE sm.created_at < CAST($1 AS DATE) + 1`
+	args := []interface{}{asOf}
+	paramCount := 2
+	if value, ok := filters["kat"]; ok && value != "" {
+		query += ` AND sm.product_no IN (SELECT no FROM master_products WHERE kat = $` + fmt.Sprintf("%d", paramCount) + `)`
+		args = append(args, value)
+		paramCount++
+	}
+	if value, ok := filters["product_no"]; ok && value != "" {
+		query += ` AND CAST(sm.product_no AS TEXT) = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, value)
+		paramCount++
+	}
+	query += " ORDER BY sm.created_at, sm.id"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return valuation, err
+	}
+
+	costs := make(map[productVariantKey]*variantCost)
+	locationQuantities := make(map[productVariantKey]map[int]int)
+	for rows.Next() {
+		var key productVariantKey
+		var locationID, quantity int
+		var reason, reference string
+		if err := rows.Scan(&key.ProductNo, &key.ColorID, &key.Size, &locationID, &quantity, &reason, &reference); err != nil {
+			rows.Close()
+			return valuation, err
+		}
+
+		if locationQuantities[key] == nil {
+			locationQuantities[key] = make(map[int]int)
+			costs[key] = &variantCost{Method: method}
+		}
+		locationQuantities[key][locationID] += quantity
+
+		// Transfers only move stock between locations, the cost of the variant does not change
+		if reason == models.StockReasonTransfer {
+			continue
+		}
+
+		if quantity < 0 {
+			costs[key].issue(-quantity)
+			continue
+		}
+
+		unitCost, costed := 0.0, false
+		if reason == models.StockReasonReceipt {
+			costKey := receiptCostKey{Nomor: reference, productVariantKey: key}
+			if lineCosts := receiptCosts[costKey]; len(lineCosts) > 0 {
+				unitCost, costed = lineCosts[0], true
+				receiptCosts[costKey] = lineCosts[1:]
+			}
+		}
+		costs[key].receive(quantity, unitCost, costed)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return valuation, err
+	}
+
+	products, err := fetchValuationProducts()
+	if err != nil {
+		return valuation, err
+	}
+	locationNames, err := fetchLocationNames()
+	if err != nil {
+		return valuation, err
+	}
+	locationNames[0] = "In transit"
+
+	locationFilter := 0
+	if value, ok := filters["location_id"]; ok && value != "" {
+		locationFilter, _ = strconv.Atoi(value)
+	}
+
+	groups := make(map[string]*models.InventoryValuationRow)
+	addToGroup := func(key productVariantKey, locationID, quantity int, value float64) {
+		var groupKey string
+		row := models.InventoryValuationRow{}
+		switch groupBy {
+		case models.ValuationGroupKat:
+			groupKey = products[key.ProductNo].Kat
+			row.Kat = groupKey
+		case models.ValuationGroupLocation:
+			groupKey = strconv.Itoa(locationID)
+			id := locationID
+			row.LocationID = &id
+			row.LocationName = locationNames[locationID]
+		default:
+			groupKey = strconv.Itoa(key.ProductNo)
+			row = products[key.ProductNo]
+			row.ProductNo = key.ProductNo
+		}
+
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &row
+			groups[groupKey] = group
+		}
+		group.Quantity += quantity
+		group.Value += value
+	}
+
+	for key, byLocation := range locationQuantities {
+		cost := costs[key]
+		unitCost := cost.unitCost()
+
+		// Whatever the ledger no longer holds at a location was shipped and has not arrived yet
+		inTransit := cost.Quantity
+		for locationID, quantity := range byLocation {
+			inTransit -= quantity
+			if quantity == 0 || (locationFilter != 0 && locationID != locationFilter) {
+				continue
+			}
+			addToGroup(key, locationID, quantity, float64(quantity)*unitCost)
+		}
+		if inTransit > 0 && locationFilter == 0 {
+			addToGroup(key, 0, inTransit, float64(inTransit)*unitCost)
+		}
+	}
+
+	for _, group := range groups {
+		if group.Quantity == 0 && group.Value == 0 {
+			continue
+		}
+		group.Value = math.Round(group.Value*100) / 100
+		if group.Quantity > 0 {
+			group.UnitCost = math.Round(group.Value/float64(group.Quantity)*100) / 100
+		}
+		valuation.Rows = append(valuation.Rows, *group)
+		valuation.TotalQuantity += group.Quantity
+		valuation.TotalValue += group.Value
+	}
+	valuation.TotalValue = math.Round(valuation.TotalValue*100) / 100
+
+	sort.Slice(valuation.Rows, func(i, j int) bool {
+		a, b := valuation.Rows[i], valuation.Rows[j]
+		switch groupBy {
+		case models.ValuationGroupKat:
+			return a.Kat < b.Kat
+		case models.ValuationGroupLocation:
+			return *a.LocationID < *b.LocationID
+		default:
+			return a.ProductNo < b.ProductNo
+		}
+	})
+
+	return valuation, nil
+}
+
+// fetchValuationProducts retrieves the descriptive fields of every product, including deleted ones that may still hold stock
+func fetchValuationProducts() (map[int]models.InventoryValuationRow, error) {
+	rows, err := DB.Query(`SELECT no, COALESCE(artikel, ''), COALESCE(nama, ''), COALESCE(kat, '') FROM master_products`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[int]models.InventoryValuationRow)
+	for rows.Next() {
+		var row models.InventoryValuationRow
+		if err := rows.Scan(&row.ProductNo, &row.Artikel, &row.Nama, &row.Kat); err != nil {
+			return nil, err
+		}
+		products[row.ProductNo] = row
+	}
+	return products, rows.Err()
+}
+
+// fetchLocationNames retrieves the names of all locations keyed by ID
+func fetchLocationNames() (map[int]string, error) {
+	rows, err := DB.Query(`SELECT id, nama FROM master_locations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var nama string
+		if err := rows.Scan(&id, &nama); err != nil {
+			return nil, err
+		}
+		names[id] = nama
+	}
+	return names, rows.Err()
+}
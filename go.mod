@@ -3,6 +3,7 @@ module github.com/everysoft/inventary-be
 go 1.24.2
 
 require (
+	github.com/boombuler/barcode v1.1.0
 	github.com/gin-gonic/gin v1.10.0
 	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/google/uuid v1.6.0
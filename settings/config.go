@@ -1,16 +1,28 @@
 package setting
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3" // Updated to v3
 )
 
 type Config struct {
-	Database      DatabaseConfig `yaml:"database"`
-	Server        ServerConfig   `yaml:"server"`
-	JWTSecret     string         `yaml:"jwt-secret"`
-	JWTExpiration string         `yaml:"jwt-expiration"`
+	Database             DatabaseConfig     `yaml:"database"`
+	Server               ServerConfig       `yaml:"server"`
+	JWTSecret            string             `yaml:"jwt-secret"`             // Signing secret, used when jwt-keys is empty
+	JWTExpiration        string             `yaml:"jwt-expiration"`         // Access token lifetime, defaults to 15m
+	JWTRefreshExpiration string             `yaml:"jwt-refresh-expiration"` // Refresh token lifetime, defaults to 720h
+	JWTKeys              []JWTKeyConfig     `yaml:"jwt-keys"`               // Keys for rotation, replaces jwt-secret
+	JWTSigningKey        string             `yaml:"jwt-signing-key"`        // ID of the key in jwt-keys that signs new tokens
+	LowStock             LowStockConfig     `yaml:"low-stock"`
+	Reservations         ReservationsConfig `yaml:"reservations"`
+	Prices               PricesConfig       `yaml:"prices"`
+	Products             ProductsConfig     `yaml:"products"`
+	Valuation            ValuationConfig    `yaml:"valuation"`
+	Auth                 AuthConfig         `yaml:"auth"`
+	Mail                 MailConfig         `yaml:"mail"`
 }
 
 type DatabaseConfig struct {
@@ -22,10 +34,72 @@ type DatabaseConfig struct {
 }
 
 type ServerConfig struct {
-	Port int `yaml:"port"`
+	Port           int      `yaml:"port"`
+	TrustedProxies []string `yaml:"trusted-proxies"` // IPs or CIDRs allowed to set X-Forwarded-For, none by default
 }
 
-// Remove the AuthConfig struct since we're not using it anymore
+// LowStockConfig configures the background low stock checker
+type LowStockConfig struct {
+	CheckInterval string `yaml:"check-interval"` // Go duration, defaults to 1h; 0 disables the checker
+	WebhookURL    string `yaml:"webhook-url"`    // Optional, new alerts are POSTed here as JSON
+}
+
+// ReservationsConfig configures the background sweeper that expires stock reservations
+type ReservationsConfig struct {
+	SweepInterval string `yaml:"sweep-interval"` // Go duration, defaults to 1m; 0 disables the sweeper
+}
+
+// PricesConfig configures the background scheduler that applies scheduled price changes
+type PricesConfig struct {
+	ApplyInterval string `yaml:"apply-interval"` // Go duration, defaults to 1m; 0 disables the scheduler
+}
+
+// ProductsConfig configures the product publishing workflow
+type ProductsConfig struct {
+	RequireApproval bool   `yaml:"require-approval"` // Products must pass review and be published by a user with products:publish
+	PublishInterval string `yaml:"publish-interval"` // Go duration for applying publish schedules, defaults to 1m; 0 disables it
+}
+
+// ValuationConfig configures how inventory is valued
+type ValuationConfig struct {
+	Method string `yaml:"method"` // "fifo" (default) or "average" for moving-average cost
+}
+
+// JWTKeyConfig is a token verification key. During rotation the new key is added and made the
+// signing key, and the old one stays listed until every token it signed has expired.
+type JWTKeyConfig struct {
+	ID     string `yaml:"id"` // Sent as the kid header of tokens signed with this key
+	Secret string `yaml:"secret"`
+}
+
+// MailConfig configures how account emails such as password resets are sent
+type MailConfig struct {
+	Driver      string `yaml:"driver"` // "smtp", "file" (writes .eml files to dir) or "log" (default)
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"` // Defaults to 587
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	From        string `yaml:"from"`
+	Dir         string `yaml:"dir"`
+	LinkBaseURL string `yaml:"link-base-url"` // Frontend URL that reset and verification links point to
+}
+
+// AuthConfig configures account creation
+type AuthConfig struct {
+	AllowRegistration bool     `yaml:"allow-registration"`  // Public self-registration, off unless explicitly enabled
+	LoginUserAttempts int      `yaml:"login-user-attempts"` // Failed logins per username before backoff starts, defaults to 5
+	LoginIPAttempts   int      `yaml:"login-ip-attempts"`   // Failed logins per IP before backoff starts, defaults to 20
+	LoginMaxLockout   string   `yaml:"login-max-lockout"`   // Longest lockout once backoff has doubled up, defaults to 15m
+	TwoFactorRoles    []string `yaml:"two-factor-roles"`    // Roles that can only use admin routes after signing in with TOTP
+}
+
+// current holds the configuration loaded at start-up for packages that are not handed the config directly
+var current = &Config{}
+
+// Current returns the configuration loaded by LoadConfig, or an empty configuration before it is loaded
+func Current() *Config {
+	return current
+}
 
 func LoadConfig(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
@@ -39,5 +113,39 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := applyJWTEnv(&config); err != nil {
+		return nil, err
+	}
+
+	current = &config
 	return &config, nil
 }
+
+// applyJWTEnv lets the environment set the token secrets and lifetimes, taking precedence over the
+// config file so secrets need not be committed. JWT_KEYS lists rotation keys as "id:secret,id:secret".
+func applyJWTEnv(config *Config) error {
+	if value := os.Getenv("JWT_SECRET"); value != "" {
+		config.JWTSecret = value
+	}
+	if value := os.Getenv("JWT_EXPIRATION"); value != "" {
+		config.JWTExpiration = value
+	}
+	if value := os.Getenv("JWT_REFRESH_EXPIRATION"); value != "" {
+		config.JWTRefreshExpiration = value
+	}
+	if value := os.Getenv("JWT_SIGNING_KEY"); value != "" {
+		config.JWTSigningKey = value
+	}
+	if value := os.Getenv("JWT_KEYS"); value != "" {
+		keys := []JWTKeyConfig{}
+		for i, entry := range strings.Split(value, ",") {
+			id, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if !ok {
+				return fmt.Errorf("JWT_KEYS entry %d: expected id:secret", i)
+			}
+			keys = append(keys, JWTKeyConfig{ID: id, Secret: secret})
+		}
+		config.JWTKeys = keys
+	}
+	return nil
+}